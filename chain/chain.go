@@ -1,7 +1,33 @@
 package chain
 
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotAnchored is returned by Anchorer if the post is not found on chain.
+var ErrNotAnchored = errors.New("post is not anchored on chain")
+
 type Chain interface {
 	Post(dna string) error
+	// Update records on chain that the post of dna is replaced by the post of newDNA.
+	Update(dna string, newDNA string) error
+	// Retract records on chain that the post of dna is retracted.
+	Retract(dna string) error
 	Verify(dna string) error
 	Close() error
 }
+
+// Anchor is the transaction and the block which record a post on chain. TxID is empty and
+// BlockNum is 0 if only the block time is found.
+type Anchor struct {
+	TxID      string
+	BlockNum  uint32
+	BlockTime time.Time
+}
+
+// Anchorer is implemented by the chains which can locate the records of posts.
+type Anchorer interface {
+	// Anchor returns the anchor of the post of dna, or ErrNotAnchored if it is not found.
+	Anchor(dna string) (*Anchor, error)
+}
@@ -3,6 +3,7 @@ package client
 import (
 	"github.com/weibocom/ipc/keys"
 	"github.com/weibocom/ipc/model"
+	"github.com/weibocom/ipc/store"
 )
 
 func (c *client) checkAccount(name string) (bool, error) {
@@ -21,19 +22,32 @@ func (c *client) GetAccounts(company string, offset int, limit int) ([]*model.Ac
 	return c.store.GetAccounts(company, offset, limit)
 }
 
+func (c *client) GetAccountsAfter(company string, cursor string, limit int) ([]*model.Account, string, error) {
+	after, err := store.ParseCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	accounts, next, err := c.store.GetAccountsAfter(company, after, limit)
+	return accounts, next.String(), err
+}
+
 func (c *client) AccountCount() (uint32, error) {
 	count, err := c.store.GetAccountCount()
 	return uint32(count), err
 }
 
 func (c *client) GetAccountPostCount(name string) (int, error) {
-	return c.store.GetAccountCount()
+	return c.store.GetAccountPostCount(name)
 }
 
 func (c *client) LookupAccount(name string) (*model.Account, error) {
 	return c.store.LoadAccount(name)
 }
 
+func (c *client) LookupAccountByPublicKey(pubKey string) (*model.Account, error) {
+	return c.store.LoadAccountByPublicKey(pubKey)
+}
+
 func (c *client) CreateAccount(name string, meta string) (*model.Account, error) {
 	exist, err := c.checkAccount(name)
 	if exist {
@@ -48,8 +62,9 @@ func (c *client) CreateAccount(name string, meta string) (*model.Account, error)
 		return nil, err
 	}
 	account := &model.Account{
-		Name: name,
-		WIF:  wif.String(),
+		Name:      name,
+		WIF:       wif.String(),
+		PublicKey: wif.PublicKey().String(),
 	}
 	err = c.saveAccount(account)
 	return account, err
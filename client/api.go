@@ -4,45 +4,99 @@ import (
 	"errors"
 
 	"github.com/weibocom/ipc/chain"
+	"github.com/weibocom/ipc/content"
 	"github.com/weibocom/ipc/model"
 	"github.com/weibocom/ipc/store"
 )
 
 var (
 	ErrAccountAlreadyExist = errors.New("account is already existed")
+	ErrInvalidDNA          = errors.New("invalid dna")
+	ErrNoBlobStore         = errors.New("no blob store of the store type")
+	ErrContentEncrypted    = errors.New("content is encrypted")
+	ErrUnauthorized        = errors.New("not authorized to decrypt the content")
+	ErrNoKeyOwner          = errors.New("no account of the key to encrypt the content")
+	ErrPostRetracted       = errors.New("post is retracted")
+	ErrPostExist           = errors.New("post of the same content is already registered")
+	ErrNotMedia            = errors.New("content type is not an image or a video")
+	ErrNoKeyframeExtractor = errors.New("no keyframe extractor for videos")
 )
 
 type Client interface {
 	AccountCount() (uint32, error)
 	CreateAccount(name string, meta string) (*model.Account, error)
 	LookupAccount(name string) (*model.Account, error)
+	LookupAccountByPublicKey(pubKey string) (*model.Account, error)
 	GetAccounts(company string, offset int, limit int) ([]*model.Account, error)
+	// GetAccountsAfter returns the page of accounts after the cursor, and the cursor of the next page.
+	// The cursors are opaque tokens, an empty cursor is the first page or no next page.
+	GetAccountsAfter(company string, cursor string, limit int) ([]*model.Account, string, error)
 	GetAccountPostCount(name string) (int, error)
 
 	// chain
 	Post(author string, mid int64, content []byte, contentType ContentType) (model.DNA, error)
+	UpdatePost(author string, mid int64, content []byte, contentType ContentType) (model.DNA, error)
+	RetractPost(author string, mid int64) error
 	Verify(dna model.DNA) bool
+	LookupSigner(dna model.DNA, digest []byte) (*model.Account, error)
 
 	CheckSimilar(a, b model.DNA) (float64, error)
 	LookupContent(dna model.DNA) (model.Content, error)
+	LookupContentWithKey(dna model.DNA, wif string) (model.Content, error)
 	LookupPost(author string, dna model.DNA) (*model.Post, error)
 	LookupPostByMsgID(author string, mid int64) (*model.Post, error)
 	LookupPostByDNA(dna model.DNA) (*model.Post, error)
+	// LookupPostVersions returns the previous versions of the post of author and mid, ordered by version.
+	LookupPostVersions(author string, mid int64) ([]*model.PostVersion, error)
 	LookupPostByAuthor(author string, offset int, limit int) ([]*model.Post, error)
+	LookupPostByAuthorAfter(author string, cursor string, limit int) ([]*model.Post, string, error)
 	GetLatestPost() (*model.Post, error)
 	PostCount() (int, error)
 	LookupSimilarPosts(dna string, keywords string, offset int, limit int) ([]*model.Post, error)
+	LookupSimilarPostsAfter(dna string, keywords string, cursor string, limit int) ([]*model.Post, string, error)
+	// LookupNearDuplicatePosts returns at most limit posts whose simhash is within maxDistance
+	// (at most the MaxSimHashDistance of the store) of the one of the normalized text, the nearest first.
+	LookupNearDuplicatePosts(text string, maxDistance int, limit int) ([]*model.Post, error)
+	// FindSimilarContent returns at most limit posts whose similarity to text by sim is at least
+	// threshold in [0, 1], the most similar first. A nil sim is content.Compare. The texts are
+	// normalized before compared, see SetNormalization.
+	FindSimilarContent(text string, sim content.Similarity, threshold float64, limit int) ([]*SimilarPost, error)
+	// FindSimilarMedia returns at most limit image or video posts whose similarity to data by
+	// perceptual hashes is at least threshold in [0, 1], the most similar first.
+	FindSimilarMedia(data []byte, contentType ContentType, threshold float64, limit int) ([]*SimilarPost, error)
+	// SearchPosts returns the page of posts whose contents match the full-text query q, the newest
+	// first. Encrypted and media posts, and posts whose contents are in blob stores, are not searchable.
+	SearchPosts(q *store.SearchQuery, offset int, limit int) ([]*model.Post, error)
+	// CheckOriginality finds the posts similar to text, and returns the verdict of which one is
+	// registered first by the block time of the posts on chain, see OriginalityVerdict.
+	CheckOriginality(text string, sim content.Similarity, threshold float64, limit int) (*OriginalityVerdict, error)
+	// CheckPostOriginality is CheckOriginality of the content of the post of dna, which is one of
+	// the registrations. Images and videos are compared by their perceptual hashes.
+	CheckPostOriginality(dna model.DNA, sim content.Similarity, threshold float64, limit int) (*OriginalityVerdict, error)
+	// ReindexPosts fingerprints the page of posts after the cursor again, and rebuilds their
+	// indexes. It returns the number of reindexed posts and the cursor of the next page, which
+	// is empty after the last page.
+	ReindexPosts(cursor string, limit int) (int, string, error)
 
 	Close() error
 }
 
-func NewClient(ipchain chain.Chain, store store.Store) (Client, error) {
+func NewClient(ipchain chain.Chain, store store.Store, options ...Option) (Client, error) {
 	client := &client{
-		ipchain: ipchain,
-		store:   store,
-		done:    make(chan struct{}),
+		ipchain:       ipchain,
+		store:         store,
+		blobStores:    make(map[StoreType]content.BlobStore),
+		postStoreType: StoreInDB,
+		normalization: content.DefaultPipeline,
+		done:          make(chan struct{}),
+	}
+	for _, opt := range options {
+		opt(client)
 	}
 
+	if !client.postStoreType.Inline() && client.blobStores[client.postStoreType] == nil {
+		return nil, ErrNoBlobStore
+	}
 	return client, nil
 }
 
@@ -50,6 +104,12 @@ type client struct {
 	ipchain chain.Chain
 	store   store.Store
 
+	blobStores    map[StoreType]content.BlobStore
+	postStoreType StoreType
+	encryption    EncryptionMode
+	keyframes     content.KeyframeExtractor
+	normalization content.Pipeline
+
 	done chan struct{}
 }
 
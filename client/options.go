@@ -0,0 +1,61 @@
+package client
+
+import (
+	"github.com/weibocom/ipc/content"
+)
+
+// Option represents an option that can be passed into the client constructor.
+type Option func(*client)
+
+// SetBlobStore sets the backend of post contents whose StoreType is st.
+// Posts saved before with st are read from it, see SetPostStoreType.
+func SetBlobStore(st StoreType, bs content.BlobStore) Option {
+	return func(c *client) {
+		c.blobStores[st] = bs
+	}
+}
+
+// SetPostStoreType sets where the contents of new posts are stored.
+// For StoreInDB and StoreInRedis the content is saved in the post itself,
+// for others it is put into the blob store set by SetBlobStore and the post only
+// keeps its address.
+//
+// The default value is StoreInDB.
+func SetPostStoreType(st StoreType) Option {
+	return func(c *client) {
+		c.postStoreType = st
+	}
+}
+
+// SetKeyframeExtractor sets how the keyframes of videos are extracted, e.g. content.FFmpeg.
+// Videos are fingerprinted by the perceptual hashes of their keyframes, and without an
+// extractor they are saved without fingerprints.
+func SetKeyframeExtractor(e content.KeyframeExtractor) Option {
+	return func(c *client) {
+		c.keyframes = e
+	}
+}
+
+// SetNormalization sets how the contents of posts are normalized before the normalized
+// digest, keywords, simhash and similarity are computed, so that trivially modified copies
+// of a content are found. A nil pipeline does not normalize contents.
+//
+// The default value is content.DefaultPipeline.
+func SetNormalization(p content.Pipeline) Option {
+	return func(c *client) {
+		c.normalization = p
+	}
+}
+
+// SetEncryption sets how contents of new posts are encrypted.
+// Every post is encrypted with a random data key, which is saved in the post encrypted
+// by the public key of the author or the company account, see LookupContentWithKey.
+// The digest and DNA are still computed over the plain content, and the keywords of the
+// content are still saved for similar lookups.
+//
+// The default value is EncryptNone.
+func SetEncryption(mode EncryptionMode) Option {
+	return func(c *client) {
+		c.encryption = mode
+	}
+}
@@ -0,0 +1,183 @@
+package client
+
+import (
+	"sort"
+	"time"
+
+	"github.com/weibocom/ipc/chain"
+	"github.com/weibocom/ipc/content"
+	"github.com/weibocom/ipc/model"
+)
+
+// Evidence is the proof of a registration: the dna is the signature of the digest of the content
+// by the author, and the transaction in the block records the dna on chain.
+type Evidence struct {
+	Author   string `json:"author"`
+	DNA      string `json:"dna"`
+	Digest   string `json:"digest"`
+	TxID     string `json:"tx_id,omitempty"`
+	BlockNum uint32 `json:"block_num,omitempty"`
+	// RegisteredAt is the block time if the post is anchored, otherwise the time it is saved
+	// in the store, which is not a proof of the registration time and is not compared with
+	// block times.
+	RegisteredAt time.Time `json:"registered_at"`
+	Anchored     bool      `json:"anchored"`
+}
+
+// Registration is a registered post similar to the checked content.
+type Registration struct {
+	Post       *model.Post `json:"post"`
+	Similarity float64     `json:"similarity"`
+	Evidence   *Evidence   `json:"evidence"`
+}
+
+// OriginalityVerdict is the result of an originality check. Anchored registrations are ordered by
+// their block times and come before the ones not anchored, which are ordered by the time they are
+// saved. Original is the earliest anchored one, or nil if no similar post is anchored.
+type OriginalityVerdict struct {
+	Original      *Registration   `json:"original"`
+	Registrations []*Registration `json:"registrations"`
+	// Confidence in [0, 1] is how sure Original is registered first, see originalityConfidence.
+	Confidence float64 `json:"confidence"`
+}
+
+// CheckOriginality finds the posts similar to text like FindSimilarContent, and returns the
+// verdict of which one is registered first.
+func (c *client) CheckOriginality(text string, sim content.Similarity, threshold float64, limit int) (*OriginalityVerdict, error) {
+	posts, err := c.FindSimilarContent(text, sim, threshold, limit)
+	if err != nil {
+		return nil, err
+	}
+	return c.originality(posts)
+}
+
+// CheckPostOriginality finds the posts similar to the post of dna, which are compared by
+// perceptual hashes for images and videos, and returns the verdict of which one is registered
+// first. The post itself is always one of the registrations.
+func (c *client) CheckPostOriginality(dna model.DNA, sim content.Similarity, threshold float64, limit int) (*OriginalityVerdict, error) {
+	post, err := c.store.LoadPost(dna)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.LookupContent(dna)
+	if err != nil {
+		return nil, err
+	}
+
+	var posts []*SimilarPost
+	if post.MediaHashes != "" {
+		posts, err = c.FindSimilarMedia(data, ContentType(post.ContentType), threshold, limit)
+	} else {
+		posts, err = c.FindSimilarContent(string(data), sim, threshold, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range posts {
+		if p.DNA == post.DNA {
+			return c.originality(posts)
+		}
+	}
+	// it may be left out by the limit.
+	post.Content = string(data)
+	return c.originality(append(posts, &SimilarPost{Post: post, Similarity: 1}))
+}
+
+// originality orders the posts by the time they are registered. Posts without anchors are
+// anchored first if the chain is a chain.Anchorer.
+func (c *client) originality(posts []*SimilarPost) (*OriginalityVerdict, error) {
+	registrations := make([]*Registration, 0, len(posts))
+	for _, p := range posts {
+		e, err := c.evidence(p.Post)
+		if err != nil {
+			return nil, err
+		}
+		registrations = append(registrations, &Registration{Post: p.Post, Similarity: p.Similarity, Evidence: e})
+	}
+
+	sort.SliceStable(registrations, func(i, j int) bool {
+		a, b := registrations[i].Evidence, registrations[j].Evidence
+		if a.Anchored != b.Anchored {
+			return a.Anchored
+		}
+		if !a.RegisteredAt.Equal(b.RegisteredAt) {
+			return a.RegisteredAt.Before(b.RegisteredAt)
+		}
+		if a.BlockNum != b.BlockNum {
+			return a.BlockNum < b.BlockNum
+		}
+		return a.DNA < b.DNA
+	})
+
+	verdict := &OriginalityVerdict{Registrations: registrations}
+	if len(registrations) > 0 && registrations[0].Evidence.Anchored {
+		verdict.Original = registrations[0]
+		verdict.Confidence = originalityConfidence(registrations)
+	}
+	return verdict, nil
+}
+
+// evidence returns the evidence of post, which is anchored and saved if it is not yet.
+func (c *client) evidence(post *model.Post) (*Evidence, error) {
+	if post.BlockTime == nil {
+		if err := c.anchor(post); err != nil && err != chain.ErrNotAnchored {
+			return nil, err
+		}
+	}
+
+	e := &Evidence{
+		Author:       post.Author,
+		DNA:          post.DNA,
+		Digest:       post.Digest,
+		TxID:         post.TxID,
+		BlockNum:     post.BlockNum,
+		RegisteredAt: post.CreatedAt,
+	}
+	if post.BlockTime != nil {
+		e.RegisteredAt = *post.BlockTime
+		e.Anchored = true
+	}
+	return e, nil
+}
+
+// anchor sets the anchor of post on chain, and saves it into the store. It returns
+// chain.ErrNotAnchored if the chain can not locate posts.
+func (c *client) anchor(post *model.Post) error {
+	anchorer, ok := c.ipchain.(chain.Anchorer)
+	if !ok {
+		return chain.ErrNotAnchored
+	}
+	a, err := anchorer.Anchor(post.DNA)
+	if err != nil {
+		return err
+	}
+
+	// only the anchor is saved, as post may be resolved or stale.
+	if err := c.store.SetPostAnchor(model.DNA(post.DNA), a.TxID, a.BlockNum, a.BlockTime); err != nil {
+		return err
+	}
+	post.TxID = a.TxID
+	post.BlockNum = a.BlockNum
+	post.BlockTime = &a.BlockTime
+	return nil
+}
+
+// originalityConfidence is the similarity of the earliest registration, which is anchored,
+// halved if the next one is anchored at the same block time or in the same block, which can
+// not be told apart. The block of an anchor may be unknown, see chain.Anchor.
+func originalityConfidence(registrations []*Registration) float64 {
+	first := registrations[0].Evidence
+	confidence := registrations[0].Similarity
+	if confidence > 1 {
+		confidence = 1
+	}
+	if len(registrations) > 1 {
+		next := registrations[1].Evidence
+		sameBlock := first.BlockNum != 0 && first.BlockNum == next.BlockNum
+		if next.Anchored && (sameBlock || first.RegisteredAt.Equal(next.RegisteredAt)) {
+			confidence /= 2
+		}
+	}
+	return confidence
+}
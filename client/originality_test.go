@@ -0,0 +1,143 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weibocom/ipc/chain"
+	"github.com/weibocom/ipc/model"
+	"github.com/weibocom/ipc/store"
+)
+
+// anchorChain anchors the posts with the given anchors in order, the posts after them are not anchored.
+type anchorChain struct {
+	fakeChain
+	next    []*chain.Anchor
+	anchors map[string]*chain.Anchor
+}
+
+func (c *anchorChain) Post(dna string) error {
+	if len(c.next) > 0 {
+		c.anchors[dna] = c.next[0]
+		c.next = c.next[1:]
+	}
+	return nil
+}
+
+func (c *anchorChain) Anchor(dna string) (*chain.Anchor, error) {
+	a, ok := c.anchors[dna]
+	if !ok {
+		return nil, chain.ErrNotAnchored
+	}
+	return a, nil
+}
+
+func TestCheckOriginality(t *testing.T) {
+	const (
+		text = "The quick brown fox jumps over the lazy dog, and runs away into the forest before the hunter comes back."
+		edit = "The quick brown fox jumps over the lazy dog, and then runs away into the forest before the hunter comes back."
+	)
+	blockTime := time.Date(2018, 5, 21, 11, 24, 45, 0, time.UTC)
+	ch := &anchorChain{
+		// the copy is saved later, but anchored in an earlier block.
+		next: []*chain.Anchor{
+			{TxID: "tx-2", BlockNum: 102, BlockTime: blockTime.Add(6 * time.Second)},
+			{TxID: "tx-1", BlockNum: 100, BlockTime: blockTime},
+		},
+		anchors: make(map[string]*chain.Anchor),
+	}
+	s := store.NewMemStore("test")
+	c, err := NewClient(ch, s)
+	require.NoError(t, err)
+	defer c.Close()
+
+	for _, name := range []string{"wb-1", "qq-1", "qq-2"} {
+		_, err = c.CreateAccount(name, "")
+		require.NoError(t, err)
+	}
+	dna1, err := c.Post("wb-1", 1, []byte(text), ContentPost)
+	require.NoError(t, err)
+	dna2, err := c.Post("qq-1", 1, []byte(edit), ContentPost)
+	require.NoError(t, err)
+
+	p, err := s.LoadPost(dna2)
+	require.NoError(t, err)
+	assert.Equal(t, "tx-1", p.TxID, "new posts are anchored")
+
+	verdict, err := c.CheckOriginality(text, nil, 0.8, 10)
+	require.NoError(t, err)
+	require.Len(t, verdict.Registrations, 2)
+	assert.Equal(t, dna2.String(), verdict.Original.Post.DNA, "ordered by block time")
+	assert.Equal(t, &Evidence{
+		Author:       "qq-1",
+		DNA:          dna2.String(),
+		Digest:       p.Digest,
+		TxID:         "tx-1",
+		BlockNum:     100,
+		RegisteredAt: blockTime,
+		Anchored:     true,
+	}, verdict.Original.Evidence)
+	assert.Equal(t, dna1.String(), verdict.Registrations[1].Post.DNA)
+	assert.InDelta(t, verdict.Original.Similarity, verdict.Confidence, 1e-9, "anchored in different blocks")
+
+	// posts which are not anchored come after the anchored ones.
+	_, err = c.Post("qq-2", 1, []byte(text), ContentPost)
+	require.NoError(t, err)
+	verdict, err = c.CheckPostOriginality(dna1, nil, 0.8, 10)
+	require.NoError(t, err)
+	require.Len(t, verdict.Registrations, 3)
+	assert.Equal(t, dna2.String(), verdict.Original.Post.DNA)
+	last := verdict.Registrations[2].Evidence
+	assert.Equal(t, "qq-2", last.Author)
+	assert.False(t, last.Anchored)
+	assert.Empty(t, last.TxID)
+
+	// anchors found later are saved.
+	ch.anchors[last.DNA] = &chain.Anchor{TxID: "tx-3", BlockNum: 100, BlockTime: blockTime}
+	verdict, err = c.CheckOriginality(text, nil, 0.8, 10)
+	require.NoError(t, err)
+	require.Len(t, verdict.Registrations, 3)
+	p, err = s.LoadPost(model.DNA(last.DNA))
+	require.NoError(t, err)
+	assert.Equal(t, "tx-3", p.TxID)
+	assert.InDelta(t, verdict.Original.Similarity/2, verdict.Confidence, 1e-9, "anchored in the same block")
+
+	verdict, err = c.CheckOriginality("something else entirely", nil, 0.8, 10)
+	require.NoError(t, err)
+	assert.Nil(t, verdict.Original)
+	assert.Empty(t, verdict.Registrations)
+	assert.Zero(t, verdict.Confidence)
+}
+
+func TestCheckOriginalityNotAnchored(t *testing.T) {
+	const text = "The quick brown fox jumps over the lazy dog, and runs away into the forest before the hunter comes back."
+	c, err := NewClient(&fakeChain{}, store.NewMemStore("test"))
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.CreateAccount("wb-1", "")
+	require.NoError(t, err)
+	_, err = c.Post("wb-1", 1, []byte(text), ContentPost)
+	require.NoError(t, err)
+
+	verdict, err := c.CheckOriginality(text, nil, 0.8, 10)
+	require.NoError(t, err)
+	require.Len(t, verdict.Registrations, 1)
+	assert.False(t, verdict.Registrations[0].Evidence.Anchored)
+	assert.Nil(t, verdict.Original, "registrations which are not anchored are never original")
+	assert.Zero(t, verdict.Confidence)
+}
+
+func TestOriginalityConfidence(t *testing.T) {
+	now := time.Now()
+	reg := func(similarity float64, anchored bool, block uint32, at time.Time) *Registration {
+		return &Registration{Similarity: similarity, Evidence: &Evidence{Anchored: anchored, BlockNum: block, RegisteredAt: at}}
+	}
+	assert.Equal(t, 1.0, originalityConfidence([]*Registration{reg(1, true, 1, now)}))
+	assert.Equal(t, 0.9, originalityConfidence([]*Registration{reg(0.9, true, 1, now), reg(1, true, 2, now.Add(3*time.Second))}))
+	assert.Equal(t, 0.9, originalityConfidence([]*Registration{reg(0.9, true, 1, now), reg(1, false, 0, now)}), "saved at the block time")
+	assert.Equal(t, 0.5, originalityConfidence([]*Registration{reg(1, true, 1, now), reg(1, true, 1, now)}))
+	assert.Equal(t, 0.5, originalityConfidence([]*Registration{reg(1, true, 1, now), reg(1, true, 2, now)}), "same block time")
+}
@@ -2,44 +2,197 @@ package client
 
 import (
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"errors"
+	"sort"
 	"time"
 
 	"github.com/weibocom/ipc/content"
 	"github.com/weibocom/ipc/keys"
 	"github.com/weibocom/ipc/model"
 	"github.com/weibocom/ipc/signature"
+	"github.com/weibocom/ipc/store"
 	"github.com/weibocom/ipc/util"
 )
 
-// TODO
-// snapshot 1. 加密存储； 2. 返回存储后的唯一id。通常是snapshot的digest
+// snapshot 1. 加密存储(见SetEncryption)； 2. 返回存储后的唯一id。通常是snapshot的digest
 func (c *client) snapshot(account *model.Account, mid int64, author string, content []byte, contentType ContentType) ([]byte, model.DNA, error) {
+	digest, dna, post, err := c.newPost(account, mid, author, content, contentType)
+	if err != nil {
+		return digest, dna, err
+	}
+	// the same content of the same author has the same dna, which is registered by another mid.
+	exist, err := c.store.ExistPost(dna)
+	if err != nil {
+		return digest, dna, err
+	}
+	if exist {
+		return digest, dna, ErrPostExist
+	}
+	err = c.store.SavePost(post)
+	if err == store.ErrExist {
+		err = ErrPostExist
+	}
+	return digest, dna, err
+}
+
+// newPost signs content and returns the post of it to save. The content is encrypted and
+// put into the blob store of the post store type.
+func (c *client) newPost(account *model.Account, mid int64, author string, data []byte, contentType ContentType) ([]byte, model.DNA, *model.Post, error) {
 	sha := sha256.New()
 	sha.Write(util.String2Bytes(author))
-	sha.Write(content)
+	sha.Write(data)
 	digest := sha.Sum(nil)
 
 	dna, err := c.sign(account, digest)
 
 	if err != nil {
-		return digest, nil, err
+		return digest, nil, nil, err
 	}
 
 	post := &model.Post{
 		MSGID:       mid,
 		Author:      author,
-		Content:     string(content),     // store in db in default
+		Content:     string(data),        // store in db in default
 		ContentType: contentType.Value(), // 0 is post
-		StoreType:   StoreInDB.Value(),
+		StoreType:   c.postStoreType.Value(),
 		Digest:      hex.EncodeToString(digest),
 		DNA:         dna.String(),
+		Version:     1,
 		CreatedAt:   time.Now(),
 	}
+	// contents of media types which can not be decoded are saved as before.
+	c.fingerprint(post, data)
 
-	err = c.store.SavePost(post)
-	return digest, dna, err
+	if c.encryption != EncryptNone || !c.postStoreType.Inline() {
+		// the store gets the sealed content or the address only, so the fingerprints are
+		// extracted from the plain content here.
+		extractFingerprints(post)
+	}
+
+	stored := data
+	if c.encryption != EncryptNone {
+		if stored, err = c.encrypt(account, post, data); err != nil {
+			return digest, dna, nil, err
+		}
+		post.Content = base64.StdEncoding.EncodeToString(stored)
+	}
+
+	if !c.postStoreType.Inline() {
+		// the post keeps the address only.
+		addr, err := c.blobStores[c.postStoreType].Put(stored)
+		if err != nil {
+			return digest, dna, nil, err
+		}
+		post.Content = addr
+	}
+	return digest, dna, post, nil
+}
+
+// extractFingerprints extracts the keywords, simhash and search terms of the content of post
+// unless they are set, like the store does for the contents kept in it. Media posts are
+// fingerprinted by their perceptual hashes only.
+func extractFingerprints(post *model.Post) {
+	if post.MediaHashes != "" {
+		return
+	}
+	if post.Keywords == "" {
+		post.Keywords = store.ExtractKeywords(post.Content)
+	}
+	if post.SimHash == 0 {
+		post.SimHash = int64(content.SimHash(post.Content))
+	}
+	if post.Terms == nil {
+		post.Terms = searchTerms(post.Content)
+	}
+}
+
+// searchTerms returns the tokens of s for full-text search, which are not nil even if s has
+// none, so that the store does not extract them again, see model.Post.Terms.
+func searchTerms(s string) []string {
+	terms := content.SearchTokens(s)
+	if terms == nil {
+		terms = []string{}
+	}
+	return terms
+}
+
+// fingerprint sets the perceptual hashes of image and video posts, or the keywords and simhash
+// of the normalized content of the others, so that trivially modified copies have the same ones,
+// and the search terms of the content. It returns the error of decoding media contents.
+func (c *client) fingerprint(post *model.Post, data []byte) error {
+	hashes, err := c.mediaHashes(data, ContentType(post.ContentType))
+	if err == nil {
+		post.MediaHashes = content.FormatHashes(hashes)
+		return nil
+	}
+	if err != ErrNotMedia {
+		return err
+	}
+
+	post.Terms = searchTerms(string(data))
+	if normalized := c.normalization.Normalize(string(data)); normalized != "" {
+		post.NormalizedDigest = content.NormalizedDigest(normalized)
+		post.Language = content.DetectLanguage(normalized)
+		post.Keywords = store.ExtractKeywords(normalized)
+		post.SimHash = int64(content.SimHash(normalized))
+	}
+	return nil
+}
+
+// mediaHashes returns the pHash of an image, or the pHashes of the keyframes of a video.
+func (c *client) mediaHashes(data []byte, contentType ContentType) ([]uint64, error) {
+	switch contentType {
+	case ContentImage:
+		img, err := content.DecodeImage(data)
+		if err != nil {
+			return nil, err
+		}
+		return []uint64{content.PerceptualHash(img)}, nil
+	case ContentVideo:
+		if c.keyframes == nil {
+			return nil, ErrNoKeyframeExtractor
+		}
+		return content.VideoHashes(data, c.keyframes)
+	default:
+		return nil, ErrNotMedia
+	}
+}
+
+// encrypt seals data with a random data key, and saves the key wrapped by
+// the public key of the key owner in post.
+func (c *client) encrypt(account *model.Account, post *model.Post, data []byte) ([]byte, error) {
+	owner := account
+	if c.encryption == EncryptForCompany {
+		if account.Company == "" {
+			return nil, ErrNoKeyOwner
+		}
+		var err error
+		if owner, err = c.store.LoadAccount(account.Company); err != nil {
+			if err == store.ErrNonExist {
+				return nil, ErrNoKeyOwner
+			}
+			return nil, err
+		}
+	}
+
+	w, err := keys.DecodeWIF(owner.WIF)
+	if err != nil {
+		return nil, err
+	}
+	sealed, key, err := content.Seal(data)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := w.PublicKey().Encrypt(key)
+	if err != nil {
+		return nil, err
+	}
+
+	post.KeyOwner = owner.Name
+	post.WrappedKey = hex.EncodeToString(wrapped)
+	return sealed, nil
 }
 
 func (c *client) sign(a *model.Account, digest []byte) (model.DNA, error) {
@@ -61,36 +214,250 @@ func (c *client) sign(a *model.Account, digest []byte) (model.DNA, error) {
 	return model.DNA(hex.EncodeToString(sigs[0])), nil
 }
 
+// LookupSigner recovers the public key from dna (the hex encoded signature of digest)
+// and returns the account which owns that key.
+func (c *client) LookupSigner(dna model.DNA, digest []byte) (*model.Account, error) {
+	sig, err := hex.DecodeString(dna.String())
+	if err != nil {
+		return nil, ErrInvalidDNA
+	}
+
+	pubKey, err := signature.Recover(digest, sig)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.store.LoadAccountByPublicKey(pubKey.String())
+}
+
 func (c *client) PostCount() (int, error) {
 	return c.store.GetPostCount()
 }
 
+// Post registers content as the post of author and mid, and returns the dna of it. The post
+// of the same author and mid is not registered again. It returns ErrPostExist and the dna if
+// the same content of author is registered by another mid.
 func (c *client) Post(author string, mid int64, content []byte, contentType ContentType) (model.DNA, error) {
 	account, err := c.lookupAccount(author)
 	if err != nil {
 		return nil, err
 	}
 
-	post, err := c.LookupPostByMsgID(author, mid)
+	post, err := c.store.GetPostByMsgID(author, mid)
 
 	if err == nil && post != nil {
 		return model.DNA(post.DNA), nil
 	}
 	_, dna, err := c.snapshot(account, mid, author, content, contentType)
+	if err == ErrPostExist {
+		return dna, err
+	}
 	if err != nil {
 		return nil, err
 	}
-	err = c.ipchain.Post(dna.String())
-	return dna, err
+	if err := c.ipchain.Post(dna.String()); err != nil {
+		return dna, err
+	}
+	c.anchorNew(dna)
+	return dna, nil
+}
+
+// anchorNew anchors the new post of dna. Posts which can not be anchored now are anchored
+// by the originality checks, so the errors are ignored.
+func (c *client) anchorNew(dna model.DNA) {
+	if post, err := c.store.LoadPost(dna); err == nil {
+		c.anchor(post)
+	}
 }
 
+// UpdatePost replaces the content of the post of author and mid with a new version, and returns
+// the dna of it. The dna and digest of the previous versions are kept, see LookupPostVersions,
+// and the new version is linked to the previous one on chain. It returns ErrPostExist and the
+// dna if the content is registered by another mid of author.
+func (c *client) UpdatePost(author string, mid int64, content []byte, contentType ContentType) (model.DNA, error) {
+	account, err := c.lookupAccount(author)
+	if err != nil {
+		return nil, err
+	}
+
+	old, err := c.store.GetPostByMsgID(author, mid)
+	if err != nil {
+		return nil, err
+	}
+	if old.Retracted {
+		return nil, ErrPostRetracted
+	}
+
+	_, dna, post, err := c.newPost(account, mid, author, content, contentType)
+	if err != nil {
+		return nil, err
+	}
+	// the same content of the same author has the same dna.
+	if post.DNA == old.DNA {
+		return dna, nil
+	}
+	exist, err := c.store.ExistPost(dna)
+	if err != nil {
+		return nil, err
+	}
+	if exist {
+		return dna, ErrPostExist
+	}
+	err = c.store.UpdatePost(model.DNA(old.DNA), post)
+	if err == store.ErrExist {
+		return dna, ErrPostExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := c.ipchain.Update(old.DNA, post.DNA); err != nil {
+		return dna, err
+	}
+	c.anchorNew(dna)
+	return dna, nil
+}
+
+// RetractPost retracts the post of author and mid. The post is kept as a tombstone without
+// the content, so that its dna and digest are still the proof of it, and the retraction
+// is recorded on chain. Contents in blob stores are not removed, as they are shared by
+// the posts of the same content.
+func (c *client) RetractPost(author string, mid int64) error {
+	post, err := c.store.GetPostByMsgID(author, mid)
+	if err != nil {
+		return err
+	}
+	if post.Retracted {
+		return ErrPostRetracted
+	}
+
+	if err := c.ipchain.Retract(post.DNA); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	post.Content = ""
+	post.Keywords = ""
+	post.Language = ""
+	post.SimHash = 0
+	post.MediaHashes = ""
+	post.NormalizedDigest = ""
+	post.KeyOwner = ""
+	post.WrappedKey = ""
+	post.Retracted = true
+	post.RetractedAt = &now
+	return c.store.SavePost(post)
+}
+
+func (c *client) LookupPostVersions(author string, mid int64) ([]*model.PostVersion, error) {
+	return c.store.GetPostVersions(author, mid)
+}
+
+// LookupContent returns the content of dna. It returns ErrContentEncrypted if the content is
+// encrypted, which can only be read by LookupContentWithKey.
 func (c *client) LookupContent(dna model.DNA) (model.Content, error) {
 	post, err := c.store.LoadPost(dna)
 	if err != nil {
 		return nil, err
 	}
+	if post.Retracted {
+		return nil, ErrPostRetracted
+	}
+	if post.WrappedKey != "" {
+		return nil, ErrContentEncrypted
+	}
 
-	return []byte(post.Content), nil
+	return c.fetchContent(post)
+}
+
+// LookupContentWithKey returns the content of dna, and decrypts it with wif if it is encrypted.
+// wif must be the key of the KeyOwner of the post, otherwise ErrUnauthorized is returned.
+func (c *client) LookupContentWithKey(dna model.DNA, wif string) (model.Content, error) {
+	post, err := c.store.LoadPost(dna)
+	if err != nil {
+		return nil, err
+	}
+	if post.Retracted {
+		return nil, ErrPostRetracted
+	}
+	return c.openContent(post, wif)
+}
+
+// openContent returns the content of post, which is decrypted with wif if it is encrypted.
+func (c *client) openContent(post *model.Post, wif string) (model.Content, error) {
+	if post.WrappedKey == "" {
+		return c.fetchContent(post)
+	}
+
+	w, err := keys.DecodeWIF(wif)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	wrapped, err := hex.DecodeString(post.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	// the wrapped key can only be decrypted by the private key of the owner.
+	key, err := w.PrivateKey().Decrypt(wrapped)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+
+	sealed, err := c.fetchContent(post)
+	if err != nil {
+		return nil, err
+	}
+	return content.Open(sealed, key)
+}
+
+// fetchContent returns the content of post from the backend of its StoreType.
+// The content is still sealed if the post is encrypted.
+func (c *client) fetchContent(post *model.Post) (model.Content, error) {
+	st := StoreType(post.StoreType)
+	if st.Inline() {
+		if post.WrappedKey != "" {
+			return base64.StdEncoding.DecodeString(post.Content)
+		}
+		return []byte(post.Content), nil
+	}
+
+	bs := c.blobStores[st]
+	if bs == nil {
+		return nil, ErrNoBlobStore
+	}
+	return bs.Get(post.Content)
+}
+
+// resolvePost replaces the content address of post with the content.
+// Encrypted contents are cleared, see LookupContentWithKey, and retracted posts have no content.
+func (c *client) resolvePost(post *model.Post, err error) (*model.Post, error) {
+	if err != nil {
+		return post, err
+	}
+	if post.Retracted {
+		return post, nil
+	}
+	if post.WrappedKey != "" {
+		post.Content = ""
+		return post, nil
+	}
+	data, err := c.fetchContent(post)
+	if err != nil {
+		return nil, err
+	}
+	post.Content = string(data)
+	return post, nil
+}
+
+func (c *client) resolvePosts(posts []*model.Post, err error) ([]*model.Post, error) {
+	if err != nil {
+		return posts, err
+	}
+	for _, p := range posts {
+		if _, err := c.resolvePost(p, nil); err != nil {
+			return nil, err
+		}
+	}
+	return posts, nil
 }
 
 func (c *client) existPost(author string, dna model.DNA) (bool, error) {
@@ -99,33 +466,190 @@ func (c *client) existPost(author string, dna model.DNA) (bool, error) {
 }
 
 func (c *client) LookupPost(author string, dna model.DNA) (*model.Post, error) {
-	return c.store.LoadPost(dna)
+	return c.resolvePost(c.store.LoadPost(dna))
 }
 
 func (c *client) LookupPostByAuthor(author string, offset int, limit int) ([]*model.Post, error) {
-	return c.store.GetPostByAuthor(author, offset, limit)
+	return c.resolvePosts(c.store.GetPostByAuthor(author, offset, limit))
+}
+
+func (c *client) LookupPostByAuthorAfter(author string, cursor string, limit int) ([]*model.Post, string, error) {
+	after, err := store.ParseCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	posts, next, err := c.store.GetPostByAuthorAfter(author, after, limit)
+	posts, err = c.resolvePosts(posts, err)
+	return posts, next.String(), err
 }
 
 func (c *client) GetLatestPost() (*model.Post, error) {
-	return c.store.GetLatestPost()
+	return c.resolvePost(c.store.GetLatestPost())
 }
 
 func (c *client) LookupPostByMsgID(author string, mid int64) (*model.Post, error) {
-	return c.store.GetPostByMsgID(author, mid)
+	return c.resolvePost(c.store.GetPostByMsgID(author, mid))
 }
 
 func (c *client) LookupPostByDNA(dna model.DNA) (*model.Post, error) {
-	return c.store.GetPostByDNA(dna)
+	return c.resolvePost(c.store.GetPostByDNA(dna))
 }
 
 func (c *client) LookupSimilarPosts(dna string, keywords string, offset int, limit int) ([]*model.Post, error) {
-	return c.store.LookupSimilarPosts(dna, keywords, offset, limit)
+	return c.resolvePosts(c.store.LookupSimilarPosts(dna, keywords, offset, limit))
+}
+
+func (c *client) LookupSimilarPostsAfter(dna string, keywords string, cursor string, limit int) ([]*model.Post, string, error) {
+	after, err := store.ParseCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	posts, next, err := c.store.LookupSimilarPostsAfter(dna, keywords, after, limit)
+	posts, err = c.resolvePosts(posts, err)
+	return posts, next.String(), err
+}
+
+func (c *client) LookupNearDuplicatePosts(text string, maxDistance int, limit int) ([]*model.Post, error) {
+	if max := c.store.MaxSimHashDistance(); maxDistance > max {
+		maxDistance = max
+	}
+	simhash := content.SimHash(c.normalization.Normalize(text))
+	if simhash == 0 {
+		return nil, nil
+	}
+	return c.resolvePosts(c.store.LookupNearDuplicatePosts(simhash, maxDistance, limit))
+}
+
+func (c *client) SearchPosts(q *store.SearchQuery, offset int, limit int) ([]*model.Post, error) {
+	return c.resolvePosts(c.store.SearchPosts(q, offset, limit))
 }
+
 func (c *client) Verify(dna model.DNA) bool {
 	err := c.ipchain.Verify(dna.String())
 	return err == nil
 }
 
+// similarCandidates is the max number of posts of each index compared by FindSimilarContent.
+const similarCandidates = 200
+
+// SimilarPost is a post found by FindSimilarContent with its similarity to the text.
+type SimilarPost struct {
+	*model.Post
+	Similarity float64 `json:"similarity"`
+}
+
+// FindSimilarContent compares text with the candidates found by its simhash, its keywords and
+// its terms. Near-duplicates are found by the simhash, while paraphrases are far from it and
+// have other keywords, but share the most terms.
+// Encrypted and retracted posts have no content to compare, so they are not returned.
+func (c *client) FindSimilarContent(text string, sim content.Similarity, threshold float64, limit int) ([]*SimilarPost, error) {
+	if sim == nil {
+		sim = content.BagOfWords{}
+	}
+	text = c.normalization.Normalize(text)
+
+	var candidates []*model.Post
+	if simhash := content.SimHash(text); simhash != 0 {
+		posts, err := c.store.LookupNearDuplicatePosts(simhash, c.store.MaxSimHashDistance(), similarCandidates)
+		if err != nil && err != store.ErrNotImplemented {
+			return nil, err
+		}
+		candidates = append(candidates, posts...)
+	}
+	if keywords := store.ExtractKeywords(text); keywords != "" {
+		posts, err := c.store.LookupSimilarPosts("", keywords, 0, similarCandidates)
+		if err != nil && err != store.ErrNotImplemented {
+			return nil, err
+		}
+		candidates = append(candidates, posts...)
+	}
+	if terms := content.SearchTokens(text); len(terms) > 0 {
+		posts, err := c.store.LookupPostsByTerms(terms, similarCandidates)
+		if err != nil && err != store.ErrNotImplemented {
+			return nil, err
+		}
+		candidates = append(candidates, posts...)
+	}
+
+	seen := make(map[string]bool, len(candidates))
+	var result []*SimilarPost
+	for _, p := range candidates {
+		if seen[p.DNA] {
+			continue
+		}
+		seen[p.DNA] = true
+		// media posts are compared by FindSimilarMedia.
+		if p.MediaHashes != "" {
+			continue
+		}
+		if _, err := c.resolvePost(p, nil); err != nil {
+			return nil, err
+		}
+		if p.Content == "" {
+			continue
+		}
+		if s := sim.Compare(text, c.normalization.Normalize(p.Content)); s >= threshold {
+			result = append(result, &SimilarPost{Post: p, Similarity: s})
+		}
+	}
+
+	return rankSimilarPosts(result, limit), nil
+}
+
+// FindSimilarMedia looks up the candidates which have a pHash within content.MediaMatchDistance
+// of any pHash of data, so that a clip of a video is found by its keyframes.
+// Encrypted media posts are found too, as the pHashes are of the plain content.
+func (c *client) FindSimilarMedia(data []byte, contentType ContentType, threshold float64, limit int) ([]*SimilarPost, error) {
+	hashes, err := c.mediaHashes(data, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := c.store.LookupMediaPosts(hashes, similarCandidates)
+	if err == store.ErrNotImplemented {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var result []*SimilarPost
+	for _, p := range posts {
+		s, err := mediaSimilarity(hashes, p)
+		if err != nil {
+			return nil, err
+		}
+		if s < threshold {
+			continue
+		}
+		if _, err := c.resolvePost(p, nil); err != nil {
+			return nil, err
+		}
+		result = append(result, &SimilarPost{Post: p, Similarity: s})
+	}
+	return rankSimilarPosts(result, limit), nil
+}
+
+// rankSimilarPosts returns at most limit posts, the most similar first.
+func rankSimilarPosts(posts []*SimilarPost, limit int) []*SimilarPost {
+	sort.SliceStable(posts, func(i, j int) bool {
+		return posts[i].Similarity > posts[j].Similarity
+	})
+	if limit > 0 && len(posts) > limit {
+		posts = posts[:limit]
+	}
+	return posts
+}
+
+func mediaSimilarity(hashes []uint64, p *model.Post) (float64, error) {
+	other, err := content.ParseHashes(p.MediaHashes)
+	if err != nil {
+		return 0, err
+	}
+	return content.MediaSimilarity(hashes, other), nil
+}
+
+// CheckSimilar compares image and video posts by their perceptual hashes, and others by
+// their normalized text. A media post is not similar to a text post.
 func (c *client) CheckSimilar(a, b model.DNA) (float64, error) {
 	post1, err := c.store.LoadPost(a)
 	if err != nil {
@@ -135,6 +659,25 @@ func (c *client) CheckSimilar(a, b model.DNA) (float64, error) {
 	if err != nil {
 		return 0, err
 	}
+	if post1.Retracted || post2.Retracted {
+		return 0, ErrPostRetracted
+	}
+	if post1.MediaHashes != "" || post2.MediaHashes != "" {
+		hashes, err := content.ParseHashes(post1.MediaHashes)
+		if err != nil {
+			return 0, err
+		}
+		return mediaSimilarity(hashes, post2)
+	}
+
+	content1, err := c.LookupContent(a)
+	if err != nil {
+		return 0, err
+	}
+	content2, err := c.LookupContent(b)
+	if err != nil {
+		return 0, err
+	}
 
-	return content.Similarity(post1.Content, post2.Content), nil
+	return content.Compare(c.normalization.Normalize(string(content1)), c.normalization.Normalize(string(content2))), nil
 }
@@ -0,0 +1,665 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weibocom/ipc/content"
+	"github.com/weibocom/ipc/content/ipfstest"
+	"github.com/weibocom/ipc/model"
+	"github.com/weibocom/ipc/store"
+)
+
+// fakeChain accepts all posts without a blockchain.
+type fakeChain struct{}
+
+func (fakeChain) Post(dna string) error                  { return nil }
+func (fakeChain) Update(dna string, newDNA string) error { return nil }
+func (fakeChain) Retract(dna string) error               { return nil }
+func (fakeChain) Verify(dna string) error                { return nil }
+func (fakeChain) Close() error                           { return nil }
+
+// recordChain records the updates and retractions.
+type recordChain struct {
+	fakeChain
+	updates   map[string]string
+	retracted []string
+}
+
+func (c *recordChain) Update(dna string, newDNA string) error {
+	c.updates[dna] = newDNA
+	return nil
+}
+
+func (c *recordChain) Retract(dna string) error {
+	c.retracted = append(c.retracted, dna)
+	return nil
+}
+
+func testPostContent(t *testing.T, st StoreType, bs content.BlobStore) {
+	s := store.NewMemStore("test")
+	c, err := NewClient(fakeChain{}, s, SetBlobStore(st, bs), SetPostStoreType(st))
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.CreateAccount("wb-1", "")
+	require.NoError(t, err)
+	dna, err := c.Post("wb-1", 1, []byte("hello world"), ContentPost)
+	require.NoError(t, err)
+
+	// the store keeps the address of the content only.
+	p, err := s.LoadPost(dna)
+	require.NoError(t, err)
+	assert.Equal(t, st.Value(), p.StoreType)
+	assert.NotEqual(t, "hello world", p.Content)
+	assert.Equal(t, store.ExtractKeywords("hello world"), p.Keywords, "keywords of the content")
+	addr := p.Content
+	data, err := bs.Get(addr)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(data))
+
+	cc, err := c.LookupContent(dna)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(cc))
+
+	p, err = c.LookupPostByDNA(dna)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", p.Content, "post with content")
+	p, err = c.LookupPostByMsgID("wb-1", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", p.Content, "post with content")
+	posts, err := c.LookupPostByAuthor("wb-1", 0, 10)
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+	assert.Equal(t, "hello world", posts[0].Content, "post with content")
+
+	// the terms of the content are searchable, rather than the address.
+	posts, err = c.SearchPosts(&store.SearchQuery{Text: "hello"}, 0, 10)
+	require.NoError(t, err)
+	if assert.Len(t, posts, 1, "search the content") {
+		assert.Equal(t, "hello world", posts[0].Content)
+	}
+	posts, err = c.SearchPosts(&store.SearchQuery{Text: addr}, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, posts, "search the address")
+
+	// posts saved in the store before are still readable.
+	require.NoError(t, s.SavePost(&model.Post{DNA: "dna-old", Author: "wb-1", MSGID: 2, Content: "old post", StoreType: StoreInDB.Value()}))
+	cc, err = c.LookupContent(model.DNA("dna-old"))
+	require.NoError(t, err)
+	assert.Equal(t, "old post", string(cc))
+}
+
+func TestPostInIPFS(t *testing.T) {
+	testPostContent(t, StoreInIPFS, content.NewIPFSClientWithShell(ipfstest.NewShell()))
+}
+
+func TestPostInFS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blobs")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	bs, err := content.NewFSBlobStore(dir)
+	require.NoError(t, err)
+	testPostContent(t, StoreInFS, bs)
+}
+
+func TestPostStoreTypeWithoutBlobStore(t *testing.T) {
+	_, err := NewClient(fakeChain{}, store.NewMemStore("test"), SetPostStoreType(StoreInIPFS))
+	assert.Equal(t, ErrNoBlobStore, err)
+}
+
+func testEncryptedPost(t *testing.T, mode EncryptionMode, options ...Option) {
+	s := store.NewMemStore("test")
+	c, err := NewClient(fakeChain{}, s, append(options, SetEncryption(mode))...)
+	require.NoError(t, err)
+	defer c.Close()
+
+	author, err := c.CreateAccount("wb-1", "")
+	require.NoError(t, err)
+	company, err := c.CreateAccount("wb", "")
+	require.NoError(t, err)
+	other, err := c.CreateAccount("wb-2", "")
+	require.NoError(t, err)
+	owner, notOwner := author, company
+	if mode == EncryptForCompany {
+		owner, notOwner = company, author
+	}
+
+	dna, err := c.Post("wb-1", 1, []byte("hello world"), ContentPost)
+	require.NoError(t, err)
+
+	p, err := s.LoadPost(dna)
+	require.NoError(t, err)
+	assert.Equal(t, owner.Name, p.KeyOwner)
+	assert.NotEmpty(t, p.WrappedKey)
+	assert.NotContains(t, p.Content, "hello world", "content is encrypted")
+	assert.Equal(t, store.ExtractKeywords("hello world"), p.Keywords, "keywords of the plain content")
+
+	// the dna is still the signature of the plain content.
+	digest := sha256.Sum256([]byte("wb-1hello world"))
+	assert.Equal(t, hex.EncodeToString(digest[:]), p.Digest)
+	signer, err := c.LookupSigner(dna, digest[:])
+	require.NoError(t, err)
+	assert.Equal(t, "wb-1", signer.Name)
+
+	_, err = c.LookupContent(dna)
+	assert.Equal(t, ErrContentEncrypted, err)
+	p, err = c.LookupPostByDNA(dna)
+	require.NoError(t, err)
+	assert.Empty(t, p.Content, "encrypted content is not returned")
+
+	cc, err := c.LookupContentWithKey(dna, owner.WIF)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(cc))
+	_, err = c.LookupContentWithKey(dna, notOwner.WIF)
+	assert.Equal(t, ErrUnauthorized, err)
+	_, err = c.LookupContentWithKey(dna, other.WIF)
+	assert.Equal(t, ErrUnauthorized, err)
+	_, err = c.LookupContentWithKey(dna, "invalid")
+	assert.Equal(t, ErrUnauthorized, err)
+}
+
+func TestEncryptForAuthor(t *testing.T) {
+	testEncryptedPost(t, EncryptForAuthor)
+}
+
+func TestEncryptForCompany(t *testing.T) {
+	testEncryptedPost(t, EncryptForCompany)
+}
+
+func TestEncryptInIPFS(t *testing.T) {
+	testEncryptedPost(t, EncryptForAuthor,
+		SetBlobStore(StoreInIPFS, content.NewIPFSClientWithShell(ipfstest.NewShell())), SetPostStoreType(StoreInIPFS))
+}
+
+func TestEncryptWithoutCompanyAccount(t *testing.T) {
+	c, err := NewClient(fakeChain{}, store.NewMemStore("test"), SetEncryption(EncryptForCompany))
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.CreateAccount("wb-1", "")
+	require.NoError(t, err)
+	_, err = c.Post("wb-1", 1, []byte("hello world"), ContentPost)
+	assert.Equal(t, ErrNoKeyOwner, err)
+}
+
+func TestLookupPostByAuthorAfter(t *testing.T) {
+	c, err := NewClient(fakeChain{}, store.NewMemStore("test"))
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.CreateAccount("wb-1", "")
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = c.Post("wb-1", int64(i), []byte(fmt.Sprintf("post %d", i)), ContentPost)
+		require.NoError(t, err)
+	}
+
+	posts, cursor, err := c.LookupPostByAuthorAfter("wb-1", "", 2)
+	require.NoError(t, err)
+	assert.Len(t, posts, 2)
+	assert.NotEmpty(t, cursor, "cursor of the next page")
+
+	posts, cursor, err = c.LookupPostByAuthorAfter("wb-1", cursor, 2)
+	require.NoError(t, err)
+	assert.Len(t, posts, 1)
+	assert.Empty(t, cursor, "no next page")
+
+	_, _, err = c.LookupPostByAuthorAfter("wb-1", "invalid cursor", 2)
+	assert.Equal(t, store.ErrInvalidCursor, err)
+}
+
+func TestPostSameContent(t *testing.T) {
+	s := store.NewMemStore("test")
+	c, err := NewClient(fakeChain{}, s)
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.CreateAccount("wb-1", "")
+	require.NoError(t, err)
+	dna1, err := c.Post("wb-1", 1, []byte("hello world"), ContentPost)
+	require.NoError(t, err)
+	dna, err := c.Post("wb-1", 1, []byte("hello world"), ContentPost)
+	require.NoError(t, err)
+	assert.Equal(t, dna1, dna, "same mid is not registered again")
+
+	dna, err = c.Post("wb-1", 2, []byte("hello world"), ContentPost)
+	assert.Equal(t, ErrPostExist, err, "same content by another mid")
+	assert.Equal(t, dna1, dna)
+
+	p, err := c.LookupPostByMsgID("wb-1", 1)
+	require.NoError(t, err)
+	assert.Equal(t, dna1.String(), p.DNA, "first registration is kept")
+	_, err = c.LookupPostByMsgID("wb-1", 2)
+	assert.Equal(t, store.ErrNonExist, err)
+	count, err := s.GetPostCount()
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestUpdatePost(t *testing.T) {
+	ch := &recordChain{updates: make(map[string]string)}
+	c, err := NewClient(ch, store.NewMemStore("test"))
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.CreateAccount("wb-1", "")
+	require.NoError(t, err)
+	_, err = c.UpdatePost("wb-1", 1, []byte("hello again"), ContentPost)
+	assert.Equal(t, store.ErrNonExist, err, "update missing post")
+
+	dna1, err := c.Post("wb-1", 1, []byte("hello world"), ContentPost)
+	require.NoError(t, err)
+	dna2, err := c.UpdatePost("wb-1", 1, []byte("hello again"), ContentPost)
+	require.NoError(t, err)
+	assert.NotEqual(t, dna1, dna2)
+	assert.Equal(t, dna2.String(), ch.updates[dna1.String()], "update on chain")
+
+	dna, err := c.UpdatePost("wb-1", 1, []byte("hello again"), ContentPost)
+	require.NoError(t, err)
+	assert.Equal(t, dna2, dna, "same content is not a new version")
+
+	p, err := c.LookupPostByMsgID("wb-1", 1)
+	require.NoError(t, err)
+	assert.Equal(t, dna2.String(), p.DNA)
+	assert.Equal(t, "hello again", p.Content)
+	assert.Equal(t, 2, p.Version)
+	_, err = c.LookupPostByDNA(dna1)
+	assert.Equal(t, store.ErrNonExist, err, "previous version is replaced")
+
+	versions, err := c.LookupPostVersions("wb-1", 1)
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	assert.Equal(t, dna1.String(), versions[0].DNA)
+	assert.Equal(t, 1, versions[0].Version)
+	digest := sha256.Sum256([]byte("wb-1hello world"))
+	assert.Equal(t, hex.EncodeToString(digest[:]), versions[0].Digest, "digest of the previous content")
+	signer, err := c.LookupSigner(model.DNA(versions[0].DNA), digest[:])
+	require.NoError(t, err)
+	assert.Equal(t, "wb-1", signer.Name, "previous version is still a proof")
+
+	dna3, err := c.Post("wb-1", 2, []byte("hello world"), ContentPost)
+	require.NoError(t, err)
+	dna, err = c.UpdatePost("wb-1", 1, []byte("hello world"), ContentPost)
+	assert.Equal(t, ErrPostExist, err, "update to the content of another mid")
+	assert.Equal(t, dna3, dna)
+	p, err = c.LookupPostByMsgID("wb-1", 2)
+	require.NoError(t, err)
+	assert.Equal(t, dna3.String(), p.DNA, "the other post is kept")
+}
+
+func TestRetractPost(t *testing.T) {
+	ch := &recordChain{updates: make(map[string]string)}
+	c, err := NewClient(ch, store.NewMemStore("test"))
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.CreateAccount("wb-1", "")
+	require.NoError(t, err)
+	dna, err := c.Post("wb-1", 1, []byte("hello world"), ContentPost)
+	require.NoError(t, err)
+
+	require.NoError(t, c.RetractPost("wb-1", 1))
+	assert.Equal(t, []string{dna.String()}, ch.retracted, "retraction on chain")
+	assert.Equal(t, ErrPostRetracted, c.RetractPost("wb-1", 1), "retract again")
+
+	// the tombstone keeps the proof without the content.
+	p, err := c.LookupPostByDNA(dna)
+	require.NoError(t, err)
+	assert.True(t, p.Retracted)
+	assert.NotNil(t, p.RetractedAt)
+	assert.Empty(t, p.Content)
+	assert.NotEmpty(t, p.Digest)
+	_, err = c.LookupContent(dna)
+	assert.Equal(t, ErrPostRetracted, err)
+	assert.Zero(t, p.SimHash, "fingerprints are cleared")
+	assert.Empty(t, p.NormalizedDigest)
+	posts, err := c.LookupNearDuplicatePosts("hello world", 3, 10)
+	require.NoError(t, err)
+	assert.Empty(t, posts, "retracted posts are not near-duplicates")
+
+	_, err = c.UpdatePost("wb-1", 1, []byte("hello again"), ContentPost)
+	assert.Equal(t, ErrPostRetracted, err, "update retracted post")
+}
+
+func TestLookupNearDuplicatePosts(t *testing.T) {
+	const (
+		text = "The quick brown fox jumps over the lazy dog, and runs away into the forest before the hunter comes back."
+		edit = "The quick brown fox jumps over the lazy dog, and then runs away into the forest before the hunter comes back."
+	)
+	c, err := NewClient(fakeChain{}, store.NewMemStore("test"), SetEncryption(EncryptForAuthor))
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.CreateAccount("wb-1", "")
+	require.NoError(t, err)
+	dna1, err := c.Post("wb-1", 1, []byte(text), ContentPost)
+	require.NoError(t, err)
+	dna2, err := c.Post("wb-1", 2, []byte(edit), ContentPost)
+	require.NoError(t, err)
+	_, err = c.Post("wb-1", 3, []byte("something else entirely"), ContentPost)
+	require.NoError(t, err)
+
+	// simhashes are computed from the plain content of encrypted posts.
+	posts, err := c.LookupNearDuplicatePosts(text, 64, 10)
+	require.NoError(t, err)
+	if assert.Len(t, posts, 2) {
+		assert.Equal(t, dna1.String(), posts[0].DNA, "the nearest first")
+		assert.Equal(t, dna2.String(), posts[1].DNA)
+	}
+
+	require.NoError(t, c.RetractPost("wb-1", 1))
+	posts, err = c.LookupNearDuplicatePosts(text, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, posts, "retracted posts are not indexed")
+}
+
+func TestSearchPosts(t *testing.T) {
+	c, err := NewClient(fakeChain{}, store.NewMemStore("test"))
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.CreateAccount("wb-1", "")
+	require.NoError(t, err)
+	dna1, err := c.Post("wb-1", 1, []byte("The quick brown fox jumps over the lazy dog"), ContentPost)
+	require.NoError(t, err)
+	dna2, err := c.Post("wb-1", 2, []byte("A lazy brown dog"), ContentPost)
+	require.NoError(t, err)
+
+	posts, err := c.SearchPosts(&store.SearchQuery{Text: "brown", Author: "wb-1"}, 0, 10)
+	require.NoError(t, err)
+	if assert.Len(t, posts, 2) {
+		assert.Equal(t, dna2.String(), posts[0].DNA, "the newest first")
+		assert.Equal(t, dna1.String(), posts[1].DNA)
+		assert.Equal(t, "A lazy brown dog", posts[0].Content)
+	}
+
+	posts, err = c.SearchPosts(&store.SearchQuery{Text: `"lazy dog"`}, 0, 10)
+	require.NoError(t, err)
+	if assert.Len(t, posts, 1) {
+		assert.Equal(t, dna1.String(), posts[0].DNA)
+	}
+
+	require.NoError(t, c.RetractPost("wb-1", 1))
+	posts, err = c.SearchPosts(&store.SearchQuery{Text: "fox"}, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, posts, "retracted posts are not searchable")
+
+	_, err = c.SearchPosts(&store.SearchQuery{Text: `"brown`}, 0, 10)
+	assert.Equal(t, content.ErrUnclosedQuote, err)
+}
+
+func TestFindSimilarContent(t *testing.T) {
+	const (
+		text = "The quick brown fox jumps over the lazy dog, and runs away into the forest before the hunter comes back."
+		edit = "The quick brown fox jumps over the lazy dog, and then runs away into the forest before the hunter comes back."
+	)
+	c, err := NewClient(fakeChain{}, store.NewMemStore("test"))
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.CreateAccount("wb-1", "")
+	require.NoError(t, err)
+	dna1, err := c.Post("wb-1", 1, []byte(text), ContentPost)
+	require.NoError(t, err)
+	dna2, err := c.Post("wb-1", 2, []byte(edit), ContentPost)
+	require.NoError(t, err)
+	_, err = c.Post("wb-1", 3, []byte("something else entirely"), ContentPost)
+	require.NoError(t, err)
+
+	matches, err := c.FindSimilarContent(text, nil, 0.5, 10)
+	require.NoError(t, err)
+	if assert.Len(t, matches, 2) {
+		assert.Equal(t, dna1.String(), matches[0].DNA, "the most similar first")
+		assert.Equal(t, text, matches[0].Content)
+		assert.InDelta(t, 1, matches[0].Similarity, 1e-6)
+		assert.Equal(t, dna2.String(), matches[1].DNA)
+		assert.True(t, matches[1].Similarity >= 0.5 && matches[1].Similarity < 1)
+	}
+
+	matches, err = c.FindSimilarContent(text, content.Jaccard{N: 2}, 0.5, 1)
+	require.NoError(t, err)
+	assert.Len(t, matches, 1, "limit")
+
+	matches, err = c.FindSimilarContent(text, nil, 1.01, 10)
+	require.NoError(t, err)
+	assert.Empty(t, matches, "threshold")
+}
+
+func TestFindSimilarParaphrase(t *testing.T) {
+	// the paraphrase sample of content.TestSimilarityAccuracy, whose simhash distance is 22 and
+	// keywords differ.
+	const (
+		text = `鲁迅的著作是将一种文化中所包含的技术结构、价值和精神状态完全或部分地引入另一种文化的文献记载。这种文化引入包括四部分内容：变更需要、变更榜样、变更思想、变更理由。
+	那么，康、梁、谭、严等后期改良派开始产生了一整套的资产阶级性质的社会政治理论和哲学观点作为变法思想的巩固的理论基础，显示了对“传统”的更为彻底的批判和对西方社会文化的更为彻底的肯定。
+	追求民族的独立与平等的意识深藏于鲁迅日本时期的文化理论中。`
+		paraphrase = `梁启超的著作是将一种文化中所包含的技术、结构、价值和精神状态完全或部分地引入另一种文化的文献记载。这种文化引入包括四部分内容：变更需要、变更榜样、变更思想、变更理由。
+	应该充分估计到，开始产生了一整套的资产阶级性质的社会政治理论和哲学观点作为变法思想的巩固的理论基础，是这一阶段改良派思想最重要的发展和最卓著的成就。
+	如果说追求中国与西方平等的观念深藏于梁启超的思想中，那么它也会深藏于每一个近代中国人的——从最保守的到最激进的——文化理论中。`
+	)
+	c, err := NewClient(fakeChain{}, store.NewMemStore("test"))
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.CreateAccount("wb-1", "")
+	require.NoError(t, err)
+	dna, err := c.Post("wb-1", 1, []byte(paraphrase), ContentPost)
+	require.NoError(t, err)
+	_, err = c.Post("wb-1", 2, []byte("区块链技术是一种分布式账本技术，可以用于数字资产的登记。"), ContentPost)
+	require.NoError(t, err)
+
+	posts, err := c.LookupNearDuplicatePosts(text, 3, 10)
+	require.NoError(t, err)
+	assert.Empty(t, posts, "not a near-duplicate")
+
+	for _, sim := range []content.Similarity{content.BagOfWords{}, content.LCS{}} {
+		matches, err := c.FindSimilarContent(text, sim, 0.6, 10)
+		require.NoError(t, err)
+		if assert.Len(t, matches, 1, "%T", sim) {
+			assert.Equal(t, dna.String(), matches[0].DNA)
+			assert.True(t, matches[0].Similarity >= 0.6, "%T: %f", sim, matches[0].Similarity)
+		}
+	}
+}
+
+func TestNormalizedPosts(t *testing.T) {
+	const (
+		text = "区块链技术是一种分布式账本技术，可以用于数字资产的登记。"
+		copy = "@区块链日报 區塊鏈技術是一種分布式賬本技術,可以用於數字\u200b資產的登記. https://t.cn/A6xyz"
+	)
+	c, err := NewClient(fakeChain{}, store.NewMemStore("test"))
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.CreateAccount("wb-1", "")
+	require.NoError(t, err)
+	_, err = c.CreateAccount("wb-2", "")
+	require.NoError(t, err)
+	dna1, err := c.Post("wb-1", 1, []byte(text), ContentPost)
+	require.NoError(t, err)
+	dna2, err := c.Post("wb-2", 1, []byte(copy), ContentPost)
+	require.NoError(t, err)
+
+	post1, err := c.LookupPostByDNA(dna1)
+	require.NoError(t, err)
+	post2, err := c.LookupPostByDNA(dna2)
+	require.NoError(t, err)
+	assert.NotEqual(t, post1.Digest, post2.Digest, "exact digests")
+	assert.Equal(t, content.NormalizedDigest(content.Normalize(text)), post1.NormalizedDigest)
+	assert.Equal(t, post1.NormalizedDigest, post2.NormalizedDigest, "normalized digests")
+	assert.Equal(t, post1.Keywords, post2.Keywords)
+	assert.Equal(t, content.LangChinese, post1.Language)
+	assert.Equal(t, post1.SimHash, post2.SimHash)
+
+	s, err := c.CheckSimilar(dna1, dna2)
+	require.NoError(t, err)
+	assert.InDelta(t, 1, s, 1e-6)
+
+	posts, err := c.LookupNearDuplicatePosts(copy, 0, 10)
+	require.NoError(t, err)
+	assert.Len(t, posts, 2)
+
+	// without normalization the copy is another text.
+	raw, err := NewClient(fakeChain{}, store.NewMemStore("test"), SetNormalization(nil))
+	require.NoError(t, err)
+	defer raw.Close()
+	_, err = raw.CreateAccount("wb-1", "")
+	require.NoError(t, err)
+	dna, err := raw.Post("wb-1", 1, []byte(copy), ContentPost)
+	require.NoError(t, err)
+	post, err := raw.LookupPostByDNA(dna)
+	require.NoError(t, err)
+	assert.Equal(t, content.NormalizedDigest(copy), post.NormalizedDigest)
+	assert.NotEqual(t, post1.SimHash, post.SimHash)
+}
+
+// testImage encodes an image of w x h with a disc at (cx, cy) on a gradient.
+func testImage(t *testing.T, w, h int, cx, cy float64, jpg bool) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			fx, fy := float64(x)/float64(w), float64(y)/float64(h)
+			c := color.RGBA{R: uint8(255 * fx), G: uint8(255 * fy), B: 128, A: 255}
+			if (fx-cx)*(fx-cx)+(fy-cy)*(fy-cy) < 0.04 {
+				c = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	var buf bytes.Buffer
+	if jpg {
+		require.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 50}))
+	} else {
+		require.NoError(t, png.Encode(&buf, img))
+	}
+	return buf.Bytes()
+}
+
+// fakeKeyframes returns the keyframes of the videos by their contents.
+type fakeKeyframes map[string][][]byte
+
+func (f fakeKeyframes) Keyframes(video []byte) ([]image.Image, error) {
+	var frames []image.Image
+	for _, data := range f[string(video)] {
+		img, err := content.DecodeImage(data)
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, img)
+	}
+	return frames, nil
+}
+
+func TestMediaPosts(t *testing.T) {
+	frame1 := testImage(t, 64, 48, 0.3, 0.3, false)
+	frame2 := testImage(t, 64, 48, 0.7, 0.6, false)
+	frame3 := testImage(t, 64, 48, 0.5, 0.2, false)
+	videos := fakeKeyframes{
+		"video":          {frame1, frame2},
+		"video-reencode": {testImage(t, 128, 96, 0.3, 0.3, true), testImage(t, 128, 96, 0.7, 0.6, true)},
+		"video-other":    {frame3},
+	}
+	c, err := NewClient(fakeChain{}, store.NewMemStore("test"), SetKeyframeExtractor(videos), SetEncryption(EncryptForAuthor))
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.CreateAccount("wb-1", "")
+	require.NoError(t, err)
+	image1, err := c.Post("wb-1", 1, testImage(t, 320, 240, 0.3, 0.3, false), ContentImage)
+	require.NoError(t, err)
+	image2, err := c.Post("wb-1", 2, testImage(t, 160, 120, 0.3, 0.3, true), ContentImage)
+	require.NoError(t, err)
+	image3, err := c.Post("wb-1", 3, testImage(t, 320, 240, 0.7, 0.6, false), ContentImage)
+	require.NoError(t, err)
+	video1, err := c.Post("wb-1", 4, []byte("video"), ContentVideo)
+	require.NoError(t, err)
+	video2, err := c.Post("wb-1", 5, []byte("video-reencode"), ContentVideo)
+	require.NoError(t, err)
+	video3, err := c.Post("wb-1", 6, []byte("video-other"), ContentVideo)
+	require.NoError(t, err)
+	text, err := c.Post("wb-1", 7, []byte("hello world"), ContentPost)
+	require.NoError(t, err)
+
+	// encrypted media posts are compared by the hashes of the plain content.
+	s, err := c.CheckSimilar(image1, image2)
+	require.NoError(t, err)
+	assert.True(t, s > 0.9, "resized and re-encoded image %f", s)
+	s, err = c.CheckSimilar(image1, image3)
+	require.NoError(t, err)
+	assert.Equal(t, float64(0), s, "different image")
+	s, err = c.CheckSimilar(video1, video2)
+	require.NoError(t, err)
+	assert.True(t, s > 0.9, "re-encoded video %f", s)
+	s, err = c.CheckSimilar(video1, video3)
+	require.NoError(t, err)
+	assert.Equal(t, float64(0), s, "different video")
+	s, err = c.CheckSimilar(image1, text)
+	require.NoError(t, err)
+	assert.Equal(t, float64(0), s, "image and text")
+
+	matches, err := c.FindSimilarMedia(testImage(t, 200, 150, 0.3, 0.3, true), ContentImage, 0.9, 10)
+	require.NoError(t, err)
+	var dnas []string
+	for _, m := range matches {
+		dnas = append(dnas, m.DNA)
+	}
+	assert.Contains(t, dnas, image1.String())
+	assert.Contains(t, dnas, image2.String())
+	assert.NotContains(t, dnas, image3.String())
+
+	matches, err = c.FindSimilarMedia([]byte("video"), ContentVideo, 0.9, 10)
+	require.NoError(t, err)
+	dnas = nil
+	for _, m := range matches {
+		dnas = append(dnas, m.DNA)
+	}
+	assert.Contains(t, dnas, video1.String())
+	assert.Contains(t, dnas, video2.String())
+	assert.NotContains(t, dnas, video3.String())
+
+	// a video is found by any of its keyframes.
+	matches, err = c.FindSimilarMedia(testImage(t, 200, 150, 0.7, 0.6, true), ContentImage, 0.5, 10)
+	require.NoError(t, err)
+	dnas = nil
+	for _, m := range matches {
+		dnas = append(dnas, m.DNA)
+	}
+	assert.Contains(t, dnas, image3.String())
+	assert.Contains(t, dnas, video1.String(), "second keyframe")
+	assert.NotContains(t, dnas, image1.String())
+
+	// media posts are not fingerprinted as texts.
+	post, err := c.LookupPostByDNA(video1)
+	require.NoError(t, err)
+	assert.Empty(t, post.Keywords)
+	assert.Zero(t, post.SimHash)
+
+	// retracted posts are not found, so they are not registrations of the originality either.
+	require.NoError(t, c.RetractPost("wb-1", 1))
+	matches, err = c.FindSimilarMedia(testImage(t, 200, 150, 0.3, 0.3, true), ContentImage, 0.9, 10)
+	require.NoError(t, err)
+	dnas = nil
+	for _, m := range matches {
+		dnas = append(dnas, m.DNA)
+	}
+	assert.Contains(t, dnas, image2.String())
+	assert.NotContains(t, dnas, image1.String(), "retracted image")
+
+	_, err = c.FindSimilarMedia([]byte("hello world"), ContentPost, 0.9, 10)
+	assert.Equal(t, ErrNotMedia, err)
+	_, err = c.FindSimilarMedia([]byte("not an image"), ContentImage, 0.9, 10)
+	assert.Error(t, err)
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"github.com/weibocom/ipc/model"
+	"github.com/weibocom/ipc/store"
+)
+
+// ReindexPosts fingerprints the posts saved before they are fingerprinted, and rebuilds the
+// indexes after the options of the store are changed, e.g. store.SetSimHashBands.
+// Retracted posts have no content, and media posts which can not be decoded, e.g. videos
+// without a keyframe extractor, are skipped. Encrypted posts are decrypted with the key of
+// their key owner.
+func (c *client) ReindexPosts(cursor string, limit int) (int, string, error) {
+	after, err := store.ParseCursor(cursor)
+	if err != nil {
+		return 0, "", err
+	}
+	posts, next, err := c.store.GetPostsAfter(after, limit)
+	if err != nil {
+		return 0, "", err
+	}
+
+	n := 0
+	for _, p := range posts {
+		if p.Retracted {
+			continue
+		}
+		var wif string
+		if p.WrappedKey != "" {
+			owner, err := c.store.LoadAccount(p.KeyOwner)
+			if err != nil {
+				return n, "", err
+			}
+			wif = owner.WIF
+		}
+		data, err := c.openContent(p, wif)
+		if err != nil {
+			return n, "", err
+		}
+
+		fp := &model.Post{DNA: p.DNA, Author: p.Author, MSGID: p.MSGID, ContentType: p.ContentType}
+		if err := c.fingerprint(fp, data); err != nil {
+			continue
+		}
+		if err := c.store.ReindexPost(fp); err != nil {
+			return n, "", err
+		}
+		n++
+	}
+	return n, next.String(), nil
+}
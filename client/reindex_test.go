@@ -0,0 +1,80 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weibocom/ipc/content"
+	"github.com/weibocom/ipc/content/ipfstest"
+	"github.com/weibocom/ipc/model"
+	"github.com/weibocom/ipc/store"
+)
+
+func TestReindexPosts(t *testing.T) {
+	const (
+		text = "The quick brown fox jumps over the lazy dog, and runs away into the forest before the hunter comes back."
+		edit = "The quick brown fox jumps over the lazy dog, and then runs away into the forest before the hunter comes back."
+	)
+	ipfs := content.NewIPFSClientWithShell(ipfstest.NewShell())
+	s := store.NewMemStore("test")
+	c, err := NewClient(fakeChain{}, s, SetBlobStore(StoreInIPFS, ipfs), SetEncryption(EncryptForAuthor))
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.CreateAccount("wb-1", "")
+	require.NoError(t, err)
+	encrypted, err := c.Post("wb-1", 1, []byte(edit), ContentPost)
+	require.NoError(t, err)
+	_, err = c.Post("wb-1", 2, []byte("retracted"), ContentPost)
+	require.NoError(t, err)
+	require.NoError(t, c.RetractPost("wb-1", 2))
+
+	// a post saved before it is fingerprinted by the client, the store can only fingerprint the address.
+	addr, err := ipfs.Put([]byte(text))
+	require.NoError(t, err)
+	old := &model.Post{DNA: "dna-old", Author: "wb-1", MSGID: 3, Content: addr, StoreType: StoreInIPFS.Value(), CreatedAt: time.Now()}
+	require.NoError(t, s.SavePost(old))
+	posts, err := c.LookupNearDuplicatePosts(text, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, posts, "not fingerprinted")
+
+	var (
+		total  int
+		cursor string
+	)
+	for i := 0; ; i++ {
+		n, next, err := c.ReindexPosts(cursor, 1)
+		require.NoError(t, err)
+		total += n
+		if next == "" {
+			break
+		}
+		require.True(t, i < 3, "pages end")
+		cursor = next
+	}
+	assert.Equal(t, 2, total, "retracted posts are skipped")
+
+	posts, err = c.LookupNearDuplicatePosts(text, 0, 10)
+	require.NoError(t, err)
+	if assert.Len(t, posts, 1, "fingerprinted by the content") {
+		assert.Equal(t, "dna-old", posts[0].DNA)
+		assert.Equal(t, text, posts[0].Content)
+	}
+	p, err := s.LoadPost(model.DNA("dna-old"))
+	require.NoError(t, err)
+	assert.Equal(t, store.ExtractKeywords(text), p.Keywords)
+	assert.Equal(t, addr, p.Content, "the address is kept")
+	posts, err = c.SearchPosts(&store.SearchQuery{Text: "hunter"}, 0, 10)
+	require.NoError(t, err)
+	if assert.Len(t, posts, 1, "the terms of the content are indexed") {
+		assert.Equal(t, "dna-old", posts[0].DNA)
+	}
+
+	posts, err = c.LookupNearDuplicatePosts(edit, 0, 10)
+	require.NoError(t, err)
+	if assert.Len(t, posts, 1, "encrypted posts are decrypted by the key of the owner") {
+		assert.Equal(t, encrypted.String(), posts[0].DNA)
+	}
+}
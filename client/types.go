@@ -8,6 +8,7 @@ type ContentType uint8
 const (
 	ContentPost ContentType = iota + 1
 	ContentVideo
+	ContentImage
 )
 
 func (ct ContentType) Value() uint8 {
@@ -28,8 +29,27 @@ const (
 	StoreInDB StoreType = iota + 1
 	StoreInRedis
 	StoreInIPFS
+	StoreInFS
 )
 
 func (st StoreType) Value() uint8 {
 	return uint8(st)
 }
+
+// Inline returns true if the content is saved in the post itself,
+// otherwise the post keeps the address of the content in a blob store.
+func (st StoreType) Inline() bool {
+	return st == 0 || st == StoreInDB || st == StoreInRedis
+}
+
+// EncryptionMode is how contents of new posts are encrypted.
+type EncryptionMode uint8
+
+const (
+	EncryptNone EncryptionMode = iota
+	// EncryptForAuthor encrypts contents with keys wrapped by the author's key.
+	EncryptForAuthor
+	// EncryptForCompany encrypts contents with keys wrapped by the key of the company account,
+	// which is the account named by the company of the author, e.g. wb for wb-1.
+	EncryptForCompany
+)
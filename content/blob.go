@@ -0,0 +1,91 @@
+package content
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// ErrBlobNotFound is returned by BlobStore.Get if there is no blob of the address.
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore stores content blobs by their content address.
+type BlobStore interface {
+	// Put stores data and returns its address.
+	Put(data []byte) (string, error)
+	// Get returns the data of the address.
+	Get(addr string) ([]byte, error)
+}
+
+// FSBlobStore stores blobs as files in a local directory.
+// The address of a blob is the hex encoded sha256 of its data, and the blob is saved
+// as <dir>/<first 2 chars of address>/<address>.
+type FSBlobStore struct {
+	dir string
+}
+
+var _ BlobStore = &FSBlobStore{}
+
+// NewFSBlobStore creates a FSBlobStore in dir, which is created if not exist.
+func NewFSBlobStore(dir string) (*FSBlobStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FSBlobStore{dir: dir}, nil
+}
+
+func (s *FSBlobStore) path(addr string) (string, error) {
+	// the address is used as the file name, only accept sha256 in hex.
+	if b, err := hex.DecodeString(addr); err != nil || len(b) != sha256.Size {
+		return "", ErrBlobNotFound
+	}
+	return filepath.Join(s.dir, addr[:2], addr), nil
+}
+
+func (s *FSBlobStore) Put(data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	addr := hex.EncodeToString(sum[:])
+	p, _ := s.path(addr)
+
+	// the same address has the same data.
+	if _, err := os.Stat(p); err == nil {
+		return addr, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return "", err
+	}
+
+	// write to a temp file and rename it, so that readers never see partial blobs.
+	f, err := ioutil.TempFile(filepath.Dir(p), addr+".tmp")
+	if err != nil {
+		return "", err
+	}
+	if _, err = f.Write(data); err == nil {
+		err = f.Close()
+	} else {
+		f.Close()
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), p)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return addr, nil
+}
+
+func (s *FSBlobStore) Get(addr string) ([]byte, error) {
+	p, err := s.path(addr)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(p)
+	if os.IsNotExist(err) {
+		return nil, ErrBlobNotFound
+	}
+	return data, err
+}
@@ -0,0 +1,61 @@
+package content_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weibocom/ipc/content"
+	"github.com/weibocom/ipc/content/ipfstest"
+)
+
+func testBlobStore(t *testing.T, bs content.BlobStore) {
+	addr, err := bs.Put([]byte("hello world"))
+	require.NoError(t, err, "put")
+
+	data, err := bs.Get(addr)
+	require.NoError(t, err, "get")
+	assert.Equal(t, "hello world", string(data))
+
+	addr2, err := bs.Put([]byte("hello world"))
+	require.NoError(t, err, "put again")
+	assert.Equal(t, addr, addr2, "address of the same content")
+
+	addr3, err := bs.Put([]byte("hello ipc"))
+	require.NoError(t, err, "put")
+	assert.NotEqual(t, addr, addr3, "address of other content")
+}
+
+func TestFSBlobStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blobs")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	bs, err := content.NewFSBlobStore(dir)
+	require.NoError(t, err)
+	testBlobStore(t, bs)
+
+	_, err = bs.Get("0000000000000000000000000000000000000000000000000000000000000000")
+	assert.Equal(t, content.ErrBlobNotFound, err, "missing blob")
+	_, err = bs.Get("../../etc/passwd")
+	assert.Equal(t, content.ErrBlobNotFound, err, "invalid address")
+}
+
+func TestIPFSMock(t *testing.T) {
+	sh := ipfstest.NewShell()
+	bs := content.NewIPFSClientWithShell(sh)
+	testBlobStore(t, bs)
+	assert.Equal(t, 2, sh.Len())
+
+	hash, err := bs.AddContent("hello world")
+	require.NoError(t, err)
+	assert.Equal(t, "Qm", hash[:2], "hash looks like ipfs")
+	c, err := bs.GetContent(hash)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", c)
+
+	_, err = bs.Get("QmNotExist")
+	assert.Equal(t, content.ErrBlobNotFound, err, "missing blob")
+}
@@ -1,17 +1,28 @@
 package content
 
 import (
+	"bytes"
+	"io"
 	"io/ioutil"
 	"strings"
 
 	shell "github.com/ipfs/go-ipfs-api"
 )
 
+// IPFSShell is the part of the ipfs api used by IPFSClient.
+// It is implemented by *shell.Shell, and by ipfstest.Shell in tests.
+type IPFSShell interface {
+	Add(r io.Reader) (string, error)
+	Cat(path string) (io.ReadCloser, error)
+}
+
 type IPFSClient struct {
 	url    string
-	client *shell.Shell
+	client IPFSShell
 }
 
+var _ BlobStore = &IPFSClient{}
+
 func NewIPFSClient(u string) *IPFSClient {
 	s := &IPFSClient{
 		url: u,
@@ -23,21 +34,36 @@ func NewIPFSClient(u string) *IPFSClient {
 	return s
 }
 
+// NewIPFSClientWithShell creates an IPFSClient with the shell, e.g. a mock in tests.
+func NewIPFSClientWithShell(sh IPFSShell) *IPFSClient {
+	return &IPFSClient{client: sh}
+}
+
 func (c *IPFSClient) AddContent(content string) (string, error) {
 	return c.client.Add(strings.NewReader(content))
 }
 
 func (c *IPFSClient) GetContent(hash string) (string, error) {
-	r, err := c.client.Cat(hash)
+	buf, err := c.Get(hash)
 	if err != nil {
 		return "", err
 	}
-	defer r.Close()
 
-	buf, err := ioutil.ReadAll(r)
+	return string(buf), nil
+}
+
+// Put adds data to ipfs and returns its hash.
+func (c *IPFSClient) Put(data []byte) (string, error) {
+	return c.client.Add(bytes.NewReader(data))
+}
+
+// Get returns the data of the hash.
+func (c *IPFSClient) Get(hash string) ([]byte, error) {
+	r, err := c.client.Cat(hash)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	defer r.Close()
 
-	return string(buf), nil
+	return ioutil.ReadAll(r)
 }
@@ -1,9 +1,19 @@
 package content
 
-import "testing"
+import (
+	"os"
+	"testing"
+)
 
+// TestIPFS runs against the ipfs node of IPC_TEST_IPFS, e.g. IPC_TEST_IPFS=10.235.64.47:5001.
+// Other tests use the mock in ipfstest.
 func TestIPFS(t *testing.T) {
-	client := NewIPFSClient("10.235.64.47:5001")
+	addr := os.Getenv("IPC_TEST_IPFS")
+	if addr == "" {
+		t.Skip("IPC_TEST_IPFS is not set")
+	}
+
+	client := NewIPFSClient(addr)
 	hash, err := client.AddContent("hello world")
 	if err != nil {
 		t.Fatalf("failed to add content: %v", err)
@@ -0,0 +1,61 @@
+// Package ipfstest provides an in-memory ipfs shell for tests, so that they do not need a live ipfs node.
+package ipfstest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/weibocom/ipc/content"
+)
+
+// Shell is an in-memory content.IPFSShell.
+// The hashes are base58 encoded sha2-256 multihashes of the data, which look like
+// the ones of ipfs (Qm...) but are not the same, as ipfs hashes the unixfs dag of the data.
+type Shell struct {
+	mu    sync.RWMutex
+	blobs map[string][]byte
+}
+
+var _ content.IPFSShell = &Shell{}
+
+// NewShell creates an empty Shell.
+func NewShell() *Shell {
+	return &Shell{blobs: make(map[string][]byte)}
+}
+
+func (s *Shell) Add(r io.Reader) (string, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	// multihash: sha2-256 code, digest length, digest.
+	hash := base58.Encode(append([]byte{0x12, 0x20}, sum[:]...))
+
+	s.mu.Lock()
+	s.blobs[hash] = data
+	s.mu.Unlock()
+	return hash, nil
+}
+
+func (s *Shell) Cat(path string) (io.ReadCloser, error) {
+	s.mu.RLock()
+	data, ok := s.blobs[path]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, content.ErrBlobNotFound
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Len returns the number of added blobs.
+func (s *Shell) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.blobs)
+}
@@ -0,0 +1,206 @@
+package content
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// the languages detected by DetectLanguage, in ISO 639-1 codes.
+const (
+	LangUnknown  = ""
+	LangChinese  = "zh"
+	LangJapanese = "ja"
+	LangKorean   = "ko"
+	LangEnglish  = "en"
+	LangFrench   = "fr"
+	LangGerman   = "de"
+	LangSpanish  = "es"
+)
+
+// DetectLanguage detects the language of s by the scripts of its letters. Texts of Latin
+// scripts are told apart by their stop words, and are English if none is found. It returns
+// LangUnknown if s has no letters of the scripts above.
+func DetectLanguage(s string) string {
+	var han, kana, hangul, latin int
+	inLatin := false
+	for _, r := range s {
+		isLatin := false
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Latin, r):
+			isLatin = true
+			// a Latin word is about as much text as a CJK character.
+			if !inLatin {
+				latin++
+			}
+		}
+		inLatin = isLatin
+	}
+
+	cjk := han + kana + hangul
+	switch {
+	case cjk == 0 && latin == 0:
+		return LangUnknown
+	case latin > cjk:
+		return detectLatin(s)
+	case kana > 0 && kana*5 >= han+kana:
+		return LangJapanese
+	case hangul > han+kana:
+		return LangKorean
+	}
+	return LangChinese
+}
+
+// detectLatin returns the Latin language with the most stop words in s.
+func detectLatin(s string) string {
+	counts := make(map[string]int)
+	for _, w := range words(s) {
+		for lang, set := range stopWords {
+			if set[w] {
+				counts[lang]++
+			}
+		}
+	}
+
+	lang, max := LangEnglish, counts[LangEnglish]
+	for _, l := range []string{LangFrench, LangGerman, LangSpanish} {
+		if counts[l] > max {
+			lang, max = l, counts[l]
+		}
+	}
+	return lang
+}
+
+// words returns the lowercase words of the letters and digits of s.
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+}
+
+// Tokenizer splits a text into the terms of keywords.
+type Tokenizer interface {
+	Tokenize(s string) []string
+}
+
+// WordTokenizer splits texts into words by spaces and punctuations, and the runs of CJK
+// characters in them into bigrams. Words are lowercased, and stemmed by Stem if it is set.
+// Stop words, numbers and single letters are not terms.
+type WordTokenizer struct {
+	StopWords map[string]bool
+	Stem      func(word string) string
+}
+
+func (t WordTokenizer) Tokenize(s string) []string {
+	var terms []string
+	for _, w := range words(s) {
+		for _, run := range splitCJK(w) {
+			if isCJK([]rune(run)[0]) {
+				terms = append(terms, bigrams(run)...)
+				continue
+			}
+			run = strings.Trim(run, "'")
+			if utf8.RuneCountInString(run) < 2 || isNumber(run) || t.StopWords[run] {
+				continue
+			}
+			if t.Stem != nil {
+				run = t.Stem(run)
+			}
+			terms = append(terms, run)
+		}
+	}
+	return terms
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+func isNumber(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitCJK splits w into the runs of CJK characters and the others.
+func splitCJK(w string) []string {
+	var runs []string
+	start := 0
+	var last bool
+	for i, r := range w {
+		c := isCJK(r)
+		if i > 0 && c != last {
+			runs = append(runs, w[start:i])
+			start = i
+		}
+		last = c
+	}
+	return append(runs, w[start:])
+}
+
+// bigrams returns the bigrams of the characters of run, or run itself if it is a single
+// Chinese character, e.g. the kanji of a Japanese word.
+func bigrams(run string) []string {
+	runes := []rune(run)
+	if len(runes) == 1 {
+		if unicode.Is(unicode.Han, runes[0]) {
+			return []string{run}
+		}
+		return nil
+	}
+	terms := make([]string, len(runes)-1)
+	for i := range terms {
+		terms[i] = string(runes[i : i+2])
+	}
+	return terms
+}
+
+// tokenizers are the tokenizers of the languages other than Chinese, which is segmented by
+// jieba. Only English words are stemmed.
+var tokenizers = map[string]Tokenizer{
+	LangEnglish:  WordTokenizer{StopWords: stopWords[LangEnglish], Stem: PorterStem},
+	LangFrench:   WordTokenizer{StopWords: stopWords[LangFrench]},
+	LangGerman:   WordTokenizer{StopWords: stopWords[LangGerman]},
+	LangSpanish:  WordTokenizer{StopWords: stopWords[LangSpanish]},
+	LangJapanese: WordTokenizer{StopWords: stopWords[LangEnglish]},
+	LangKorean:   WordTokenizer{StopWords: stopWords[LangEnglish]},
+}
+
+// ExtractLanguage extracts the keywords of s in lang. Chinese and unknown languages are
+// extracted by the DefaultSegmenter, and others are the most frequent terms of their
+// Tokenizer, the first ones first.
+func ExtractLanguage(s string, lang string, topk int) []string {
+	t, ok := tokenizers[lang]
+	if !ok {
+		return DefaultSegmenter().Extract(s, topk)
+	}
+	return topTerms(t.Tokenize(s), topk)
+}
+
+func topTerms(terms []string, topk int) []string {
+	counts := make(map[string]int)
+	var unique []string
+	for _, t := range terms {
+		if counts[t] == 0 {
+			unique = append(unique, t)
+		}
+		counts[t]++
+	}
+	sort.SliceStable(unique, func(i, j int) bool {
+		return counts[unique[i]] > counts[unique[j]]
+	})
+	if len(unique) > topk {
+		unique = unique[:topk]
+	}
+	return unique
+}
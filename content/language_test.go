@@ -0,0 +1,51 @@
+package content
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	for text, lang := range map[string]string{
+		"区块链技术是一种分布式账本技术":                         LangChinese,
+		"我用iPhone拍的照片":                            LangChinese,
+		"ブロックチェーンは分散型台帳の技術です":                     LangJapanese,
+		"블록체인은 분산 원장 기술입니다":                       LangKorean,
+		"The blockchain is a distributed ledger":  LangEnglish,
+		"I met 张三 in Beijing":                     LangEnglish,
+		"La chaîne de blocs est une technologie":  LangFrench,
+		"Die Blockchain ist eine Technologie und": LangGerman,
+		"La cadena de bloques es una tecnología":  LangSpanish,
+		"Blockchain copyright":                    LangEnglish,
+		"2018-06-01 12:00 😀":                      LangUnknown,
+		"":                                        LangUnknown,
+	} {
+		assert.Equal(t, lang, DetectLanguage(text), text)
+	}
+}
+
+func TestWordTokenizer(t *testing.T) {
+	en := tokenizers[LangEnglish]
+	assert.Equal(t, []string{"blockchain", "record", "copyright", "post"},
+		en.Tokenize("The Blockchain records the copyrights of posts in 2018, 42 of them."))
+	assert.Equal(t, []string{"weibo", "区块", "块链", "blockchain"}, en.Tokenize("Weibo区块链 blockchain"))
+
+	ja := tokenizers[LangJapanese]
+	assert.Equal(t, []string{"ブロ", "ロッ", "ック", "本", "ipfs"}, ja.Tokenize("ブロック、本 IPFS"))
+}
+
+func TestExtractLanguage(t *testing.T) {
+	text := "Blockchains record copyrights. The blockchain records the copyright of every post, " +
+		"and a post is verified by the blockchain."
+	assert.Equal(t, []string{"blockchain", "record", "copyright"}, Extract(text, 3))
+
+	ja := "ブロックチェーンで著作権を登録する。ブロックチェーンは改ざんできない。"
+	keywords := Extract(ja, 3)
+	assert.Len(t, keywords, 3)
+	assert.Contains(t, keywords, "ブロ")
+
+	// Chinese is segmented by jieba as before.
+	zh := "区块链技术是一种分布式账本技术"
+	assert.Equal(t, DefaultSegmenter().Extract(zh, 3), Extract(zh, 3))
+}
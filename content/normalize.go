@@ -0,0 +1,172 @@
+package content
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Normalizer rewrites a text into a canonical form, so that trivially modified copies
+// of a text are the same after normalization.
+type Normalizer func(s string) string
+
+// Pipeline applies the normalizers in order.
+type Pipeline []Normalizer
+
+// DefaultPipeline is the pipeline used by Normalize.
+var DefaultPipeline = Pipeline{
+	RemoveZeroWidth,
+	FoldWidth,
+	RemoveURLs,
+	RemoveMentions,
+	RemoveEmoji,
+	ToSimplified,
+	FoldCase,
+	CollapseSpace,
+}
+
+// Normalize normalizes s by the DefaultPipeline.
+func Normalize(s string) string {
+	return DefaultPipeline.Normalize(s)
+}
+
+// Normalize applies the normalizers of p to s, a nil p returns s.
+func (p Pipeline) Normalize(s string) string {
+	for _, n := range p {
+		s = n(s)
+	}
+	return s
+}
+
+// NormalizedDigest returns the hex sha256 of the normalized text. Unlike the digest of a post
+// it is not signed and does not include the author, so the copies of a text by different
+// authors have the same normalized digest.
+func NormalizedDigest(normalized string) string {
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// RemoveZeroWidth removes the invisible format characters, e.g. the zero width space U+200B
+// and the byte order mark U+FEFF.
+func RemoveZeroWidth(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Cf, r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// cjkPunctuations are the CJK punctuations folded to ASCII by FoldWidth.
+var cjkPunctuations = map[rune]rune{
+	'。': '.', '、': ',', '“': '"', '”': '"', '‘': '\'', '’': '\'',
+	'「': '"', '」': '"', '『': '"', '』': '"', '《': '<', '》': '>',
+	'〈': '<', '〉': '>', '【': '[', '】': ']', '〔': '[', '〕': ']',
+	'—': '-', '…': '.', '·': '.', '～': '~',
+}
+
+// FoldWidth folds the full-width forms of ASCII and the CJK punctuations to ASCII,
+// e.g. "，" to "," and "ＡＢＣ" to "ABC".
+func FoldWidth(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '　':
+			return ' '
+		case r >= '！' && r <= '～':
+			return r - 0xfee0
+		}
+		if p, ok := cjkPunctuations[r]; ok {
+			return p
+		}
+		return r
+	}, s)
+}
+
+var (
+	urlPattern = regexp.MustCompile(`(?i)(?:https?://|www\.)[0-9A-Za-z\-._~:/?#\[\]@!$&'()*+,;=%]+|\bt\.cn/[0-9A-Za-z]+`)
+	// mentionPattern does not match the @ of emails, which follows the ASCII local part.
+	mentionPattern = regexp.MustCompile(`(^|[^0-9A-Za-z_.+-])@[\p{L}\p{N}_-]+`)
+	// emoticonPattern matches the emoticons of weibo, e.g. [爱你].
+	emoticonPattern = regexp.MustCompile(`\[\p{Han}{1,6}\]`)
+)
+
+// RemoveURLs removes the links, including the short links of weibo.
+func RemoveURLs(s string) string {
+	return urlPattern.ReplaceAllString(s, " ")
+}
+
+// RemoveMentions removes the @mentions, but not the emails.
+func RemoveMentions(s string) string {
+	return mentionPattern.ReplaceAllString(s, "$1 ")
+}
+
+// RemoveEmoji removes the emoji and the emoticons of weibo.
+func RemoveEmoji(s string) string {
+	s = emoticonPattern.ReplaceAllString(s, " ")
+	return strings.Map(func(r rune) rune {
+		if isEmoji(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1f000 && r <= 0x1faff: // emoticons, pictographs, transport and others
+		return true
+	case r >= 0x2600 && r <= 0x27bf: // miscellaneous symbols and dingbats
+		return true
+	case r >= 0x1f1e6 && r <= 0x1f1ff: // regional indicators of flags
+		return true
+	case r == 0xfe0f || r == 0xfe0e: // variation selectors
+		return true
+	}
+	return false
+}
+
+// ToSimplified converts the traditional Chinese characters to simplified ones. Only the
+// characters of a single simplified form are converted, see t2s.
+func ToSimplified(s string) string {
+	return strings.Map(func(r rune) rune {
+		if v, ok := t2s[r]; ok {
+			return v
+		}
+		return r
+	}, s)
+}
+
+// FoldCase lowercases the letters.
+func FoldCase(s string) string {
+	return strings.ToLower(s)
+}
+
+// CollapseSpace replaces the spaces between words with a single space, and trims the spaces
+// around the text. Spaces between Chinese characters are removed.
+func CollapseSpace(s string) string {
+	fields := strings.Fields(s)
+	var b bytes.Buffer
+	for i, f := range fields {
+		if i > 0 && !isHanBoundary(fields[i-1], f) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(f)
+	}
+	return b.String()
+}
+
+// isHanBoundary returns true if the space between a and b is between Chinese characters
+// or punctuations, which are not separated by spaces.
+func isHanBoundary(a, b string) bool {
+	last, _ := utf8.DecodeLastRuneInString(a)
+	first, _ := utf8.DecodeRuneInString(b)
+	return !isWordRune(last) || !isWordRune(first) || unicode.Is(unicode.Han, last) || unicode.Is(unicode.Han, first)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
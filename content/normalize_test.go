@@ -0,0 +1,58 @@
+package content
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizers(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		fn   Normalizer
+		in   string
+		out  string
+	}{
+		{"zero width", RemoveZeroWidth, "区\u200b块\ufeff链\u200d", "区块链"},
+		{"width", FoldWidth, "ＡＢＣ　１２３，你好！“引号”。", `ABC 123,你好!"引号".`},
+		{"urls", RemoveURLs, "看这里https://example.com/a?b=1和http://t.cn/A6xyz", "看这里 和 "},
+		{"short urls", RemoveURLs, "原文t.cn/A6xyz转发", "原文 转发"},
+		{"mentions", RemoveMentions, "@张三 你好@李四_1", "  你好 "},
+		{"emails", RemoveMentions, "联系 a@example.com", "联系 a@example.com"},
+		{"emoji", RemoveEmoji, "好开心😀[哈哈]👍🏻❤️", "好开心 "},
+		{"simplified", ToSimplified, "區塊鏈與數字資產", "区块链与数字资产"},
+		{"case", FoldCase, "Hello World", "hello world"},
+		{"spaces", CollapseSpace, "  hello   world \n 区块 链 , 技术 ", "hello world区块链,技术"},
+	} {
+		assert.Equal(t, c.out, c.fn(c.in), c.name)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	text := "区块链技术是一种分布式账本技术，可以用于数字资产的登记。"
+	copies := []string{
+		"區塊鏈技術是一種分布式賬本技術，可以用於數字資產的登記。",
+		"区块\u200b链技术是一种分布式账本技术,可以用于数字资产的登记.",
+		"@区块链日报 区块链技术是一种 分布式账本技术，可以用于数字资产的登记。[赞]👍 https://t.cn/A6xyz",
+	}
+	assert.Equal(t, "区块链技术是一种分布式账本技术,可以用于数字资产的登记.", Normalize(text))
+	for _, c := range copies {
+		assert.Equal(t, Normalize(text), Normalize(c), c)
+		assert.Equal(t, NormalizedDigest(Normalize(text)), NormalizedDigest(Normalize(c)), c)
+	}
+	assert.NotEqual(t, NormalizedDigest(Normalize(text)), NormalizedDigest(Normalize("区块链技术")))
+
+	assert.Equal(t, "Hello  World", Pipeline(nil).Normalize("Hello  World"), "nil pipeline")
+	assert.Equal(t, "hello  world", Pipeline{FoldCase}.Normalize("Hello  World"))
+}
+
+func TestT2S(t *testing.T) {
+	assert.True(t, len(t2s) > 500)
+	for k, v := range t2s {
+		assert.NotEqual(t, k, v, string(k))
+		_, ok := t2s[v]
+		assert.False(t, ok, "%c of %c is traditional", v, k)
+		assert.True(t, utf8.ValidRune(v))
+	}
+}
@@ -0,0 +1,193 @@
+package content
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	// image formats decoded by DecodeImage.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// MediaMatchDistance is the max Hamming distance of the pHashes of the same image,
+// which is resized or re-encoded.
+const MediaMatchDistance = 10
+
+// MediaHashBands is the number of bands which the pHashes are indexed by, so that the ones
+// within MediaMatchDistance have at least one equal band, see SimHashBands.
+const MediaHashBands = MediaMatchDistance + 1
+
+// DecodeImage decodes a gif, jpeg or png image.
+func DecodeImage(data []byte) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// AverageHash (aHash) sets the bits of the pixels of the 8x8 grayscale image brighter
+// than the mean. It is fast, but sensitive to the changes of brightness.
+func AverageHash(img image.Image) uint64 {
+	pixels := grayscale(img, 8, 8)
+	var mean float64
+	for _, p := range pixels {
+		mean += p
+	}
+	mean /= float64(len(pixels))
+
+	var h uint64
+	for i, p := range pixels {
+		if p > mean {
+			h |= 1 << uint(i)
+		}
+	}
+	return h
+}
+
+// DifferenceHash (dHash) sets the bits of the pixels of the 9x8 grayscale image darker
+// than the pixel on their right, which are the gradients of the image.
+func DifferenceHash(img image.Image) uint64 {
+	pixels := grayscale(img, 9, 8)
+	var h uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if pixels[y*9+x] < pixels[y*9+x+1] {
+				h |= 1 << uint(y*8+x)
+			}
+		}
+	}
+	return h
+}
+
+// PerceptualHash (pHash) sets the bits of the 8x8 lowest frequencies of the DCT of the
+// 32x32 grayscale image larger than their median. It is the most robust to resizing,
+// re-encoding and changes of brightness, and is the hash saved for media posts.
+func PerceptualHash(img image.Image) uint64 {
+	const n, k = 32, 8
+	pixels := grayscale(img, n, n)
+
+	// DCT-II of the rows and then of the columns, only the k lowest frequencies are needed.
+	var cos [k][n]float64
+	for u := 0; u < k; u++ {
+		for x := 0; x < n; x++ {
+			cos[u][x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / (2 * n))
+		}
+	}
+	var rows [n][k]float64
+	for y := 0; y < n; y++ {
+		for u := 0; u < k; u++ {
+			for x := 0; x < n; x++ {
+				rows[y][u] += pixels[y*n+x] * cos[u][x]
+			}
+		}
+	}
+	coeffs := make([]float64, k*k)
+	for v := 0; v < k; v++ {
+		for u := 0; u < k; u++ {
+			for y := 0; y < n; y++ {
+				coeffs[v*k+u] += rows[y][u] * cos[v][y]
+			}
+		}
+	}
+
+	// the DC coefficient is the mean brightness, which is excluded from the median.
+	sorted := append([]float64(nil), coeffs[1:]...)
+	sort.Float64s(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+
+	var h uint64
+	for i, c := range coeffs {
+		if c > median {
+			h |= 1 << uint(i)
+		}
+	}
+	return h
+}
+
+// grayscale resizes img to w x h by the average of the pixels of each cell, and returns
+// the luminance of the pixels in rows.
+func grayscale(img image.Image, w, h int) []float64 {
+	b := img.Bounds()
+	pixels := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		y0 := b.Min.Y + y*b.Dy()/h
+		y1 := b.Min.Y + (y+1)*b.Dy()/h
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < w; x++ {
+			x0 := b.Min.X + x*b.Dx()/w
+			x1 := b.Min.X + (x+1)*b.Dx()/w
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var sum float64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					r, g, b, _ := img.At(sx, sy).RGBA()
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+				}
+			}
+			pixels[y*w+x] = sum / float64((y1-y0)*(x1-x0)) / 0xffff
+		}
+	}
+	return pixels
+}
+
+// MediaSimilarity returns the similarity of two media by their pHashes in [0, 1]. Every
+// hash is matched to the nearest one of the other media within MediaMatchDistance, and
+// the similarity is the mean of the similarity of the matches of both media, so a video
+// is only similar to another one if most of its keyframes are.
+func MediaSimilarity(a, b []uint64) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	return (matchHashes(a, b) + matchHashes(b, a)) / 2
+}
+
+func matchHashes(a, b []uint64) float64 {
+	var sum float64
+	for _, x := range a {
+		best := MediaMatchDistance + 1
+		for _, y := range b {
+			if d := HammingDistance(x, y); d < best {
+				best = d
+			}
+		}
+		if best <= MediaMatchDistance {
+			sum += 1 - float64(best)/64
+		}
+	}
+	return sum / float64(len(a))
+}
+
+// FormatHashes formats hashes as comma separated hex.
+func FormatHashes(hashes []uint64) string {
+	s := make([]string, len(hashes))
+	for i, h := range hashes {
+		s[i] = fmt.Sprintf("%016x", h)
+	}
+	return strings.Join(s, ",")
+}
+
+// ParseHashes parses the hashes formatted by FormatHashes.
+func ParseHashes(s string) ([]uint64, error) {
+	if s == "" {
+		return nil, nil
+	}
+	fields := strings.Split(s, ",")
+	hashes := make([]uint64, len(fields))
+	for i, f := range fields {
+		h, err := strconv.ParseUint(f, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		hashes[i] = h
+	}
+	return hashes, nil
+}
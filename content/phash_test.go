@@ -0,0 +1,122 @@
+package content
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testImage draws a gradient and a disc at (cx, cy) of an image of w x h.
+func testImage(w, h int, cx, cy float64) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			fx, fy := float64(x)/float64(w), float64(y)/float64(h)
+			c := color.RGBA{R: uint8(255 * fx), G: uint8(255 * fy), B: 128, A: 255}
+			if (fx-cx)*(fx-cx)+(fy-cy)*(fy-cy) < 0.04 {
+				c = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// resize scales img to w x h by the nearest pixels.
+func resize(img image.Image, w, h int) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dst.Set(x, y, img.At(b.Min.X+x*b.Dx()/w, b.Min.Y+y*b.Dy()/h))
+		}
+	}
+	return dst
+}
+
+func reencode(t *testing.T, img image.Image) image.Image {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 40}))
+	decoded, err := DecodeImage(buf.Bytes())
+	require.NoError(t, err)
+	return decoded
+}
+
+func TestImageHashes(t *testing.T) {
+	img := testImage(320, 240, 0.3, 0.3)
+	copies := []image.Image{resize(img, 160, 120), resize(img, 500, 375), reencode(t, img)}
+	other := testImage(320, 240, 0.7, 0.6)
+
+	for _, hash := range []struct {
+		name string
+		fn   func(image.Image) uint64
+	}{
+		{"aHash", AverageHash},
+		{"dHash", DifferenceHash},
+		{"pHash", PerceptualHash},
+	} {
+		h := hash.fn(img)
+		assert.Equal(t, h, hash.fn(img), "%s: deterministic", hash.name)
+		for _, c := range copies {
+			assert.True(t, HammingDistance(h, hash.fn(c)) <= MediaMatchDistance, "%s: copy %d", hash.name, HammingDistance(h, hash.fn(c)))
+		}
+		assert.True(t, HammingDistance(h, hash.fn(other)) > MediaMatchDistance, "%s: other %d", hash.name, HammingDistance(h, hash.fn(other)))
+	}
+}
+
+func TestMediaSimilarity(t *testing.T) {
+	a := PerceptualHash(testImage(320, 240, 0.3, 0.3))
+	b := PerceptualHash(testImage(320, 240, 0.7, 0.6))
+	c := PerceptualHash(testImage(320, 240, 0.5, 0.2))
+
+	assert.Equal(t, float64(1), MediaSimilarity([]uint64{a}, []uint64{a}))
+	assert.Equal(t, float64(0), MediaSimilarity([]uint64{a}, []uint64{b}))
+	assert.Equal(t, float64(0), MediaSimilarity(nil, []uint64{a}))
+	assert.Equal(t, float64(1), MediaSimilarity([]uint64{a, b}, []uint64{b, a}), "order of keyframes")
+	assert.InDelta(t, 0.75, MediaSimilarity([]uint64{a, b}, []uint64{a}), 1e-6, "half of keyframes")
+	assert.InDelta(t, (2.0/3+1)/2, MediaSimilarity([]uint64{a, b, c}, []uint64{a, b}), 1e-6, "2 of 3 and 2 of 2 keyframes")
+	assert.InDelta(t, 1-1.0/64, MediaSimilarity([]uint64{a}, []uint64{a ^ 1}), 1e-6)
+}
+
+func TestFormatHashes(t *testing.T) {
+	hashes := []uint64{0, 0x0123456789abcdef, ^uint64(0)}
+	s := FormatHashes(hashes)
+	assert.Equal(t, "0000000000000000,0123456789abcdef,ffffffffffffffff", s)
+	parsed, err := ParseHashes(s)
+	require.NoError(t, err)
+	assert.Equal(t, hashes, parsed)
+
+	parsed, err = ParseHashes("")
+	assert.NoError(t, err)
+	assert.Empty(t, parsed)
+	_, err = ParseHashes("xyz")
+	assert.Error(t, err)
+}
+
+type fakeExtractor []image.Image
+
+func (f fakeExtractor) Keyframes(video []byte) ([]image.Image, error) {
+	var buf bytes.Buffer
+	for _, img := range f {
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+	}
+	return decodePNGs(&buf)
+}
+
+func TestVideoHashes(t *testing.T) {
+	frames := fakeExtractor{testImage(64, 48, 0.3, 0.3), testImage(64, 48, 0.7, 0.6)}
+	hashes, err := VideoHashes(nil, frames)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{PerceptualHash(frames[0]), PerceptualHash(frames[1])}, hashes, "keyframes decoded one after another")
+
+	_, err = VideoHashes(nil, fakeExtractor{})
+	assert.Equal(t, ErrNoKeyframe, err)
+}
@@ -0,0 +1,174 @@
+package content
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	// MinPassage is the min number of letters and digits of a common passage.
+	MinPassage = 8
+	// SentenceThreshold is the min similarity of aligned sentences.
+	SentenceThreshold = 0.5
+)
+
+// Span is a part of the source text matching a part of the destination text.
+// Offsets are in characters (not bytes) of the original texts, and ends are exclusive.
+type Span struct {
+	SrcStart int     `json:"src_start"`
+	SrcEnd   int     `json:"src_end"`
+	DstStart int     `json:"dst_start"`
+	DstEnd   int     `json:"dst_end"`
+	Score    float64 `json:"score"`
+}
+
+// Report shows which passages of two texts overlap.
+type Report struct {
+	// Sentences are the sentences of the source text aligned to the most similar
+	// sentence of the destination text, scored by the similarity.
+	Sentences []Span `json:"sentences"`
+	// Passages are the common passages of at least MinPassage letters and digits, scored 1.
+	// Punctuations and spaces are ignored, so a passage may cross sentences.
+	Passages []Span `json:"passages"`
+	// SrcCoverage and DstCoverage are the ratios of letters and digits covered by the passages.
+	SrcCoverage float64 `json:"src_coverage"`
+	DstCoverage float64 `json:"dst_coverage"`
+}
+
+// NewReport compares src with dst. Sentences are compared by sim, and a nil sim is Jaccard
+// of character bigrams.
+func NewReport(src, dst string, sim Similarity) *Report {
+	if sim == nil {
+		sim = Jaccard{N: 2}
+	}
+	r := &Report{
+		Sentences: alignSentences(src, dst, sim),
+		Passages:  []Span{},
+	}
+
+	sr, sp := indexedRunes(src)
+	dr, dp := indexedRunes(dst)
+	if len(sr) == 0 || len(dr) == 0 {
+		return r
+	}
+
+	// greedy string tiling: take the longest common passage at each position of src,
+	// and every character of dst is matched once at most.
+	grams := make(map[string][]int)
+	for j := 0; j+MinPassage <= len(dr); j++ {
+		k := string(dr[j : j+MinPassage])
+		grams[k] = append(grams[k], j)
+	}
+	used := make([]bool, len(dr))
+	srcCovered, dstCovered := 0, 0
+	for i := 0; i+MinPassage <= len(sr); {
+		best, bestLen := -1, 0
+		for _, j := range grams[string(sr[i:i+MinPassage])] {
+			n := 0
+			for i+n < len(sr) && j+n < len(dr) && sr[i+n] == dr[j+n] && !used[j+n] {
+				n++
+			}
+			if n > bestLen {
+				best, bestLen = j, n
+			}
+		}
+		if bestLen < MinPassage {
+			i++
+			continue
+		}
+
+		for n := 0; n < bestLen; n++ {
+			used[best+n] = true
+		}
+		r.Passages = append(r.Passages, Span{
+			SrcStart: sp[i],
+			SrcEnd:   sp[i+bestLen-1] + 1,
+			DstStart: dp[best],
+			DstEnd:   dp[best+bestLen-1] + 1,
+			Score:    1,
+		})
+		srcCovered += bestLen
+		dstCovered += bestLen
+		i += bestLen
+	}
+	r.SrcCoverage = float64(srcCovered) / float64(len(sr))
+	r.DstCoverage = float64(dstCovered) / float64(len(dr))
+	return r
+}
+
+// indexedRunes returns the lowercased letters and digits of s, and their offsets in s.
+func indexedRunes(s string) ([]rune, []int) {
+	var runes []rune
+	var offsets []int
+	i := 0
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			runes = append(runes, unicode.ToLower(r))
+			offsets = append(offsets, i)
+		}
+		i++
+	}
+	return runes, offsets
+}
+
+// sentence is a sentence of a text and its offsets in characters.
+type sentence struct {
+	text       string
+	start, end int
+}
+
+func isSentenceEnd(r rune) bool {
+	return strings.ContainsRune("。！？；!?;\n", r)
+}
+
+// sentences splits s by the ends of sentences, spaces around the sentences are trimmed.
+func sentences(s string) []sentence {
+	runes := []rune(s)
+	var result []sentence
+	start := 0
+	for i := 0; i <= len(runes); i++ {
+		if i < len(runes) && !isSentenceEnd(runes[i]) {
+			continue
+		}
+		end := i
+		if i < len(runes) && runes[i] != '\n' {
+			end++
+		}
+		for start < end && unicode.IsSpace(runes[start]) {
+			start++
+		}
+		for end > start && unicode.IsSpace(runes[end-1]) {
+			end--
+		}
+		if text := string(runes[start:end]); len(normalizedRunes(text)) > 0 {
+			result = append(result, sentence{text: text, start: start, end: end})
+		}
+		start = i + 1
+	}
+	return result
+}
+
+// alignSentences aligns every sentence of src to the most similar sentence of dst.
+func alignSentences(src, dst string, sim Similarity) []Span {
+	ds := sentences(dst)
+	spans := []Span{}
+	for _, s := range sentences(src) {
+		best, score := -1, 0.0
+		for j, d := range ds {
+			if v := sim.Compare(s.text, d.text); v > score {
+				best, score = j, v
+			}
+		}
+		if best < 0 || score < SentenceThreshold {
+			continue
+		}
+		spans = append(spans, Span{
+			SrcStart: s.start,
+			SrcEnd:   s.end,
+			DstStart: ds[best].start,
+			DstEnd:   ds[best].end,
+			Score:    score,
+		})
+	}
+	return spans
+}
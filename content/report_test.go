@@ -0,0 +1,64 @@
+package content
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func substr(s string, start, end int) string {
+	return string([]rune(s)[start:end])
+}
+
+func TestReport(t *testing.T) {
+	src := "你好。鲁迅的著作是将一种文化引入另一种文化。今天天气不错"
+	dst := "梁启超说：鲁迅的著作是将一种文化引入另一种文化！明天下雨"
+
+	r := NewReport(src, dst, nil)
+	if assert.Len(t, r.Passages, 1) {
+		p := r.Passages[0]
+		assert.Equal(t, Span{SrcStart: 3, SrcEnd: 21, DstStart: 5, DstEnd: 23, Score: 1}, p)
+		assert.Equal(t, "鲁迅的著作是将一种文化引入另一种文化", substr(src, p.SrcStart, p.SrcEnd))
+		assert.Equal(t, "鲁迅的著作是将一种文化引入另一种文化", substr(dst, p.DstStart, p.DstEnd))
+	}
+	assert.InDelta(t, 18.0/26, r.SrcCoverage, 1e-6)
+	assert.InDelta(t, 18.0/26, r.DstCoverage, 1e-6)
+
+	if assert.Len(t, r.Sentences, 1) {
+		s := r.Sentences[0]
+		assert.Equal(t, "鲁迅的著作是将一种文化引入另一种文化。", substr(src, s.SrcStart, s.SrcEnd))
+		assert.Equal(t, "梁启超说：鲁迅的著作是将一种文化引入另一种文化！", substr(dst, s.DstStart, s.DstEnd))
+		assert.True(t, s.Score >= SentenceThreshold && s.Score < 1)
+	}
+}
+
+func TestReportPassages(t *testing.T) {
+	r := NewReport(simhashText, simhashEdit, LCS{})
+	var covered int
+	for _, p := range r.Passages {
+		assert.Equal(t, normalizedRunes(substr(simhashText, p.SrcStart, p.SrcEnd)),
+			normalizedRunes(substr(simhashEdit, p.DstStart, p.DstEnd)), "passages are the same text")
+		covered += len(normalizedRunes(substr(simhashText, p.SrcStart, p.SrcEnd)))
+	}
+	assert.InDelta(t, float64(covered)/float64(len(normalizedRunes(simhashText))), r.SrcCoverage, 1e-6)
+	assert.True(t, r.SrcCoverage > 0.9, "a few words are replaced")
+	assert.Len(t, r.Sentences, 2, "sentences are aligned")
+
+	r = NewReport(simhashText, simhashOther, nil)
+	assert.Empty(t, r.Passages)
+	assert.Empty(t, r.Sentences)
+	assert.Equal(t, float64(0), r.SrcCoverage)
+
+	r = NewReport("", simhashText, nil)
+	assert.Empty(t, r.Passages)
+	assert.Equal(t, float64(0), r.DstCoverage)
+}
+
+func TestSentences(t *testing.T) {
+	s := sentences(" 你好！ hello world.\n\n再见；")
+	if assert.Len(t, s, 3) {
+		assert.Equal(t, sentence{text: "你好！", start: 1, end: 4}, s[0])
+		assert.Equal(t, sentence{text: "hello world.", start: 5, end: 17}, s[1])
+		assert.Equal(t, sentence{text: "再见；", start: 19, end: 22}, s[2])
+	}
+}
@@ -0,0 +1,59 @@
+package content
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+)
+
+// DataKeySize is the size of the keys of sealed contents (AES-256).
+const DataKeySize = 32
+
+// ErrInvalidSealed is returned by Open if the sealed content is broken or the key is wrong.
+var ErrInvalidSealed = errors.New("invalid sealed content")
+
+// Seal encrypts content with a random data key using AES-256-GCM.
+// It returns the nonce followed by the ciphertext, and the data key.
+func Seal(content []byte) (sealed []byte, key []byte, err error) {
+	key = make([]byte, DataKeySize)
+	if _, err = io.ReadFull(rand.Reader, key); err != nil {
+		return nil, nil, err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, nil, err
+	}
+	return gcm.Seal(nonce, nonce, content, nil), key, nil
+}
+
+// Open decrypts the content sealed by Seal with its data key.
+func Open(sealed []byte, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrInvalidSealed
+	}
+
+	content, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrInvalidSealed
+	}
+	return content, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,28 @@
+package content
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeal(t *testing.T) {
+	sealed, key, err := Seal([]byte("hello world"))
+	require.NoError(t, err)
+	assert.Len(t, key, DataKeySize)
+	assert.NotContains(t, string(sealed), "hello world")
+
+	data, err := Open(sealed, key)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(data))
+
+	_, other, err := Seal([]byte("hello world"))
+	require.NoError(t, err)
+	_, err = Open(sealed, other)
+	assert.Equal(t, ErrInvalidSealed, err, "wrong key")
+
+	sealed[len(sealed)-1] ^= 1
+	_, err = Open(sealed, key)
+	assert.Equal(t, ErrInvalidSealed, err, "tampered content")
+}
@@ -0,0 +1,366 @@
+package content
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+var (
+	// ErrEmptyQuery is returned by ParseQuery if the query has no terms to search.
+	ErrEmptyQuery = errors.New("empty query")
+	// ErrUnclosedQuote is returned by ParseQuery if a phrase of the query is not closed.
+	ErrUnclosedQuote = errors.New("unclosed quote in query")
+)
+
+// SearchTokens splits s into the tokens of full-text search in order. s is normalized first,
+// Chinese is segmented by the DefaultSegmenter, Japanese and Korean are split into bigrams,
+// and English words are stemmed. Stop words are kept, so that phrases match exactly.
+func SearchTokens(s string) []string {
+	var tokens []string
+	for _, w := range words(Normalize(s)) {
+		for _, run := range splitScripts(w) {
+			r := []rune(run)[0]
+			switch {
+			case unicode.Is(unicode.Han, r):
+				for _, t := range DefaultSegmenter().Cut(run) {
+					if t = strings.TrimSpace(t); t != "" {
+						tokens = append(tokens, t)
+					}
+				}
+			case isCJK(r):
+				if len([]rune(run)) == 1 {
+					tokens = append(tokens, run)
+					continue
+				}
+				tokens = append(tokens, bigrams(run)...)
+			default:
+				if run = strings.Trim(run, "'"); run != "" {
+					tokens = append(tokens, PorterStem(run))
+				}
+			}
+		}
+	}
+	return tokens
+}
+
+// splitScripts splits w into the runs of Chinese characters, the runs of kana and hangul,
+// and the runs of the others.
+func splitScripts(w string) []string {
+	script := func(r rune) int {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			return 1
+		case isCJK(r):
+			return 2
+		}
+		return 0
+	}
+
+	var runs []string
+	start, last := 0, 0
+	for i, r := range w {
+		s := script(r)
+		if i > 0 && s != last {
+			runs = append(runs, w[start:i])
+			start = i
+		}
+		last = s
+	}
+	return append(runs, w[start:])
+}
+
+// TermPositions returns the positions of each token in tokens, in ascending order.
+func TermPositions(tokens []string) map[string][]int {
+	positions := make(map[string][]int)
+	for i, t := range tokens {
+		positions[t] = append(positions[t], i)
+	}
+	return positions
+}
+
+// Query is a parsed full-text query, see ParseQuery.
+type Query struct {
+	// groups are ORed, and the clauses of a group are ANDed.
+	groups [][]clause
+}
+
+// clause is a term or a phrase of a query, which is excluded if negate is true.
+type clause struct {
+	tokens []string
+	negate bool
+}
+
+// ParseQuery parses a full-text query. Terms separated by spaces must all match, and OR
+// matches either side of it, e.g. `区块链 版权 OR 数字资产` matches the texts of both 区块链
+// and 版权, or of 数字资产. A term prefixed with - or NOT is excluded. Quoted terms are
+// phrases, which match the tokens in order, and so is a term of several tokens.
+func ParseQuery(s string) (*Query, error) {
+	var (
+		groups [][]clause
+		group  []clause
+		negate bool
+	)
+	endGroup := func() {
+		// a group of only exclusions matches almost everything, which is dropped.
+		for _, c := range group {
+			if !c.negate {
+				groups = append(groups, group)
+				break
+			}
+		}
+		group = nil
+	}
+
+	rest := strings.TrimSpace(s)
+	for rest != "" {
+		var text string
+		quoted := false
+		if strings.HasPrefix(rest, "-") {
+			negate = true
+			rest = rest[1:]
+		}
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				return nil, ErrUnclosedQuote
+			}
+			text, rest = rest[1:end+1], rest[end+2:]
+			quoted = true
+		} else {
+			end := strings.IndexFunc(rest, func(r rune) bool { return unicode.IsSpace(r) || r == '"' })
+			if end < 0 {
+				end = len(rest)
+			}
+			text, rest = rest[:end], rest[end:]
+		}
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+
+		switch {
+		case quoted:
+		case text == "OR":
+			endGroup()
+			negate = false
+			continue
+		case text == "AND":
+			continue
+		case text == "NOT":
+			negate = true
+			continue
+		}
+		if tokens := SearchTokens(text); len(tokens) > 0 {
+			group = append(group, clause{tokens: tokens, negate: negate})
+		}
+		negate = false
+	}
+	endGroup()
+
+	if len(groups) == 0 {
+		return nil, ErrEmptyQuery
+	}
+	return &Query{groups: groups}, nil
+}
+
+// Terms returns the distinct tokens which a text must have to match each group of the
+// query, which are the candidates of a search. The tokens of exclusions are not included.
+func (q *Query) Terms() [][]string {
+	terms := make([][]string, len(q.groups))
+	for i, g := range q.groups {
+		seen := make(map[string]bool)
+		for _, c := range g {
+			if c.negate {
+				continue
+			}
+			for _, t := range c.tokens {
+				if !seen[t] {
+					seen[t] = true
+					terms[i] = append(terms[i], t)
+				}
+			}
+		}
+	}
+	return terms
+}
+
+// Tokens returns the distinct tokens of the query, including the ones of exclusions, which
+// are needed by Match.
+func (q *Query) Tokens() []string {
+	seen := make(map[string]bool)
+	var tokens []string
+	for _, g := range q.groups {
+		for _, c := range g {
+			for _, t := range c.tokens {
+				if !seen[t] {
+					seen[t] = true
+					tokens = append(tokens, t)
+				}
+			}
+		}
+	}
+	return tokens
+}
+
+// Match returns true if a text of the token positions matches the query. positions needs
+// only the tokens of the query.
+func (q *Query) Match(positions map[string][]int) bool {
+	for _, g := range q.groups {
+		matched := true
+		for _, c := range g {
+			if matchPhrase(positions, c.tokens) == c.negate {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPhrase returns true if tokens are in positions one after another.
+func matchPhrase(positions map[string][]int, tokens []string) bool {
+	for _, start := range positions[tokens[0]] {
+		found := true
+		for i, t := range tokens[1:] {
+			ps := positions[t]
+			j := sort.SearchInts(ps, start+i+1)
+			if j == len(ps) || ps[j] != start+i+1 {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchIndex is an in-memory inverted index of token positions for full-text search.
+// It is safe for concurrent use.
+type SearchIndex struct {
+	mu       sync.RWMutex
+	postings map[string]map[string][]int // token -> id -> positions
+	docs     map[string][]string         // id -> distinct tokens
+}
+
+// NewSearchIndex creates an empty SearchIndex.
+func NewSearchIndex() *SearchIndex {
+	return &SearchIndex{
+		postings: make(map[string]map[string][]int),
+		docs:     make(map[string][]string),
+	}
+}
+
+// Add indexes the tokens of id, replacing the ones indexed before.
+func (x *SearchIndex) Add(id string, tokens []string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.remove(id)
+	if len(tokens) == 0 {
+		return
+	}
+
+	positions := TermPositions(tokens)
+	distinct := make([]string, 0, len(positions))
+	for t, ps := range positions {
+		ids := x.postings[t]
+		if ids == nil {
+			ids = make(map[string][]int)
+			x.postings[t] = ids
+		}
+		ids[id] = ps
+		distinct = append(distinct, t)
+	}
+	x.docs[id] = distinct
+}
+
+// Remove removes id from the index.
+func (x *SearchIndex) Remove(id string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.remove(id)
+}
+
+func (x *SearchIndex) remove(id string) {
+	for _, t := range x.docs[id] {
+		delete(x.postings[t], id)
+		if len(x.postings[t]) == 0 {
+			delete(x.postings, t)
+		}
+	}
+	delete(x.docs, id)
+}
+
+// Search returns the unordered ids which match q.
+func (x *SearchIndex) Search(q *Query) []string {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	matched := make(map[string]bool)
+	for _, terms := range q.Terms() {
+		for id := range x.postings[terms[0]] {
+			if matched[id] || !x.hasAll(id, terms[1:]) {
+				continue
+			}
+			if q.Match(x.positions(id, q.Tokens())) {
+				matched[id] = true
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(matched))
+	for id := range matched {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (x *SearchIndex) hasAll(id string, terms []string) bool {
+	for _, t := range terms {
+		if _, ok := x.postings[t][id]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (x *SearchIndex) positions(id string, tokens []string) map[string][]int {
+	positions := make(map[string][]int, len(tokens))
+	for _, t := range tokens {
+		if ps, ok := x.postings[t][id]; ok {
+			positions[t] = ps
+		}
+	}
+	return positions
+}
+
+// Shared returns the number of tokens of the ids which have any of tokens, each distinct token
+// is counted once.
+func (x *SearchIndex) Shared(tokens []string) map[string]int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	shared := make(map[string]int)
+	seen := make(map[string]bool, len(tokens))
+	for _, t := range tokens {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		for id := range x.postings[t] {
+			shared[id]++
+		}
+	}
+	return shared
+}
+
+// Len returns the number of ids in the index.
+func (x *SearchIndex) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.docs)
+}
@@ -0,0 +1,90 @@
+package content
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchTokens(t *testing.T) {
+	assert.Equal(t, []string{"the", "quick", "fox", "jump", "over", "the", "dog"},
+		SearchTokens("The quick fox jumps over the @someone dog https://example.com"))
+	assert.Equal(t, []string{"こん", "んに", "にち", "ちは"}, SearchTokens("こんにちは"))
+	assert.Equal(t, SearchTokens("区块链技术"), SearchTokens("區塊鏈技術"))
+	assert.Empty(t, SearchTokens(" ，。!"))
+}
+
+func TestParseQuery(t *testing.T) {
+	for _, s := range []string{"", "  ", "-fox", "NOT fox OR -dog", "，。"} {
+		_, err := ParseQuery(s)
+		assert.Equal(t, ErrEmptyQuery, err, s)
+	}
+	_, err := ParseQuery(`"quick fox`)
+	assert.Equal(t, ErrUnclosedQuote, err)
+
+	q, err := ParseQuery(`quick "brown fox" -lazy OR dogs NOT cats`)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"quick", "brown", "fox"}, {"dog"}}, q.Terms())
+	assert.Equal(t, []string{"quick", "brown", "fox", "lazi", "dog", "cat"}, q.Tokens())
+}
+
+func TestQueryMatch(t *testing.T) {
+	text := TermPositions(SearchTokens("The quick brown fox jumps over the lazy dog"))
+	for _, c := range []struct {
+		query string
+		match bool
+	}{
+		{"fox", true},
+		{"FOXES", true},
+		{"quick dog", true},
+		{"quick cat", false},
+		{`"brown fox"`, true},
+		{`"fox brown"`, false},
+		{`"quick fox"`, false},
+		{"brown-fox", true},
+		{"fox -lazy", false},
+		{"fox NOT cat", true},
+		{`fox -"lazy cat"`, true},
+		{"cat OR dog", true},
+		{"cat OR bird", false},
+		{"cat fox OR dog -quick OR jumping", true},
+	} {
+		q, err := ParseQuery(c.query)
+		require.NoError(t, err, c.query)
+		assert.Equal(t, c.match, q.Match(text), c.query)
+	}
+}
+
+func TestSearchIndex(t *testing.T) {
+	x := NewSearchIndex()
+	x.Add("a", SearchTokens("The quick brown fox"))
+	x.Add("b", SearchTokens("The lazy brown dog"))
+	x.Add("c", SearchTokens("区块链技术用于版权登记"))
+	assert.Equal(t, 3, x.Len())
+
+	search := func(s string) []string {
+		q, err := ParseQuery(s)
+		require.NoError(t, err, s)
+		ids := x.Search(q)
+		sort.Strings(ids)
+		return ids
+	}
+	assert.Equal(t, []string{"a", "b"}, search("brown"))
+	assert.Equal(t, []string{"a"}, search(`"quick brown"`))
+	assert.Equal(t, []string{"b"}, search("brown -fox"))
+	assert.Equal(t, []string{"a", "c"}, search("fox OR 版权"))
+	assert.Equal(t, []string{"c"}, search("區塊鏈"))
+	assert.Empty(t, search("cat"))
+	assert.Equal(t, map[string]int{"a": 3, "b": 1}, x.Shared(SearchTokens("quick brown brown foxes")), "shared tokens")
+
+	x.Add("a", SearchTokens("A lazy cat"))
+	assert.Equal(t, []string{"b"}, search("brown"))
+	assert.Equal(t, []string{"a"}, search("cat"))
+	x.Remove("b")
+	assert.Equal(t, []string{"a"}, search("lazy"))
+	x.Add("a", nil)
+	assert.Empty(t, search("lazy"))
+	assert.Equal(t, 1, x.Len())
+}
@@ -0,0 +1,220 @@
+package content
+
+import (
+	"os"
+	"path"
+	"sort"
+	"sync"
+
+	"github.com/yanyiwu/gojieba"
+)
+
+// the file names of the dictionaries of jieba, which are the ones of gojieba.
+const (
+	jiebaDict     = "jieba.dict.utf8"
+	jiebaHMMModel = "hmm_model.utf8"
+	jiebaUserDict = "user.dict.utf8"
+	jiebaIDF      = "idf.utf8"
+	jiebaStopWord = "stop_words.utf8"
+)
+
+// Segmenter segments texts and extracts keywords by jieba. It is safe for concurrent use,
+// and the user dictionary can be reloaded while it is in use.
+type Segmenter struct {
+	dir string
+
+	mu        sync.RWMutex
+	jieba     *gojieba.Jieba
+	userWords map[string]bool
+	stopWords map[string]bool
+}
+
+// NewSegmenter loads the dictionaries of jieba in dir, e.g. jieba.dict.utf8 and user.dict.utf8,
+// see https://github.com/yanyiwu/gojieba/tree/master/dict. An empty dir loads the dictionaries
+// shipped with gojieba.
+func NewSegmenter(dir string) (*Segmenter, error) {
+	s := &Segmenter{
+		dir:       dir,
+		userWords: make(map[string]bool),
+		stopWords: make(map[string]bool),
+	}
+	jieba, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	s.jieba = jieba
+	return s, nil
+}
+
+// load creates a jieba of the dictionaries. jieba aborts the process if a dictionary does
+// not exist, so they are checked before.
+func (s *Segmenter) load() (*gojieba.Jieba, error) {
+	if s.dir == "" {
+		return gojieba.NewJieba(), nil
+	}
+
+	var paths []string
+	for _, name := range []string{jiebaDict, jiebaHMMModel, jiebaUserDict, jiebaIDF, jiebaStopWord} {
+		p := path.Join(s.dir, name)
+		if _, err := os.Stat(p); err != nil {
+			return nil, err
+		}
+		paths = append(paths, p)
+	}
+	return gojieba.NewJieba(paths...), nil
+}
+
+// ReloadUserDict reloads the dictionaries to pick up the changes of the user dictionary.
+// The words added by AddUserWord are kept. Segmentations in progress finish with the old
+// dictionaries, and the new ones are used after.
+func (s *Segmenter) ReloadUserDict() error {
+	jieba, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	for w := range s.userWords {
+		jieba.AddWord(w)
+	}
+	old := s.jieba
+	s.jieba = jieba
+	s.mu.Unlock()
+
+	// it is not used by anyone once the lock is released.
+	old.Free()
+	return nil
+}
+
+// Close frees the dictionaries, the segmenter must not be used after.
+func (s *Segmenter) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.jieba != nil {
+		s.jieba.Free()
+		s.jieba = nil
+	}
+}
+
+// Cut segments text into words, including stop words and punctuations.
+func (s *Segmenter) Cut(text string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.jieba.Cut(text, true)
+}
+
+// Extract returns the top keywords of text by TF-IDF, stop words are excluded.
+func (s *Segmenter) Extract(text string, topk int) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	words := s.jieba.Extract(text, topk+len(s.stopWords))
+	keywords := words[:0]
+	for _, w := range words {
+		if !s.stopWords[w] && len(keywords) < topk {
+			keywords = append(keywords, w)
+		}
+	}
+	return keywords
+}
+
+// ExtractWithWeight returns the top keywords of text with their TF-IDF weights, stop words
+// are excluded.
+func (s *Segmenter) ExtractWithWeight(text string, topk int) []gojieba.WordWeight {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	words := s.jieba.ExtractWithWeight(text, topk+len(s.stopWords))
+	keywords := words[:0]
+	for _, w := range words {
+		if !s.stopWords[w.Word] && len(keywords) < topk {
+			keywords = append(keywords, w)
+		}
+	}
+	return keywords
+}
+
+// AddUserWord adds a word of the domain vocabulary, which is not split by segmentation.
+func (s *Segmenter) AddUserWord(word string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jieba.AddWord(word)
+	s.userWords[word] = true
+}
+
+// RemoveUserWord removes a word added by AddUserWord.
+func (s *Segmenter) RemoveUserWord(word string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jieba.RemoveWord(word)
+	delete(s.userWords, word)
+}
+
+// UserWords returns the sorted words added by AddUserWord.
+func (s *Segmenter) UserWords() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sortedKeys(s.userWords)
+}
+
+// AddStopWord adds a word excluded from keywords, besides the ones of the stop words dictionary.
+func (s *Segmenter) AddStopWord(word string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopWords[word] = true
+}
+
+// RemoveStopWord removes a word added by AddStopWord.
+func (s *Segmenter) RemoveStopWord(word string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.stopWords, word)
+}
+
+// StopWords returns the sorted words added by AddStopWord.
+func (s *Segmenter) StopWords() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sortedKeys(s.stopWords)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var (
+	defaultMu        sync.Mutex
+	defaultSegmenter *Segmenter
+)
+
+// DefaultSegmenter returns the segmenter used by Extract and the similarities. It loads the
+// dictionaries shipped with gojieba on first use, unless SetDefaultSegmenter is called before.
+func DefaultSegmenter() *Segmenter {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultSegmenter == nil {
+		// it never fails without a dict dir.
+		defaultSegmenter, _ = NewSegmenter("")
+	}
+	return defaultSegmenter
+}
+
+func segmenterOrDefault(s *Segmenter) *Segmenter {
+	if s == nil {
+		return DefaultSegmenter()
+	}
+	return s
+}
+
+// SetDefaultSegmenter replaces the default segmenter. The previous one is not closed,
+// as it may be still in use.
+func SetDefaultSegmenter(s *Segmenter) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultSegmenter = s
+}
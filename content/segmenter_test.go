@@ -0,0 +1,90 @@
+package content
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSegmenter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jieba")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = NewSegmenter(dir)
+	assert.True(t, os.IsNotExist(err), "missing dicts")
+	_, err = NewSegmenter(path.Join(dir, "none"))
+	assert.True(t, os.IsNotExist(err), "missing dir")
+
+	seg, err := NewSegmenter("")
+	require.NoError(t, err)
+	defer seg.Close()
+	assert.NotEmpty(t, seg.Cut("区块链技术"))
+}
+
+func TestSegmenterWords(t *testing.T) {
+	seg, err := NewSegmenter("")
+	require.NoError(t, err)
+	defer seg.Close()
+
+	text := "blockchain copyright blockchain weibo copyright blockchain"
+	keywords := seg.Extract(text, 2)
+	require.Len(t, keywords, 2)
+
+	seg.AddStopWord(keywords[0])
+	filtered := seg.Extract(text, 2)
+	assert.Len(t, filtered, 2, "topk words besides the stop words")
+	assert.NotContains(t, filtered, keywords[0])
+	for _, w := range seg.ExtractWithWeight(text, 10) {
+		assert.NotEqual(t, keywords[0], w.Word)
+	}
+	assert.Equal(t, []string{keywords[0]}, seg.StopWords())
+	seg.RemoveStopWord(keywords[0])
+	assert.Equal(t, keywords, seg.Extract(text, 2))
+	assert.Empty(t, seg.StopWords())
+
+	seg.AddUserWord("微博区块链")
+	seg.AddUserWord("内容确权")
+	assert.Equal(t, []string{"内容确权", "微博区块链"}, seg.UserWords())
+	require.NoError(t, seg.ReloadUserDict())
+	assert.Equal(t, []string{"内容确权", "微博区块链"}, seg.UserWords(), "user words are kept after reload")
+	seg.RemoveUserWord("内容确权")
+	assert.Equal(t, []string{"微博区块链"}, seg.UserWords())
+}
+
+func TestSegmenterConcurrency(t *testing.T) {
+	seg, err := NewSegmenter("")
+	require.NoError(t, err)
+	defer seg.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				switch j % 5 {
+				case 0:
+					seg.AddUserWord(fmt.Sprintf("词%d", i))
+				case 1:
+					seg.AddStopWord(fmt.Sprintf("stop%d", i))
+				case 2:
+					assert.NoError(t, seg.ReloadUserDict())
+				default:
+					seg.Cut("区块链技术是一种分布式账本技术")
+					seg.Extract("blockchain copyright weibo", 2)
+					TFIDF{Segmenter: seg}.Compare("区块链技术", "区块链")
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Len(t, seg.UserWords(), 8)
+	assert.Len(t, seg.StopWords(), 8)
+}
@@ -0,0 +1,223 @@
+package content
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"sort"
+	"sync"
+	"unicode"
+)
+
+// SimHashShingle is the number of characters of the shingles which are the features of SimHash.
+// Shingles of characters work for Chinese texts without segmentation.
+const SimHashShingle = 3
+
+// SimHash returns the 64 bits fingerprint of s. Near-duplicate texts have fingerprints
+// with a small Hamming distance, see HammingDistance. Punctuations and spaces are ignored,
+// and the fingerprint of a text without letters or digits is 0.
+func SimHash(s string) uint64 {
+	var v [64]int
+	n := 0
+	for _, f := range shingles(s, SimHashShingle) {
+		h := hashFeature(f)
+		for i := uint(0); i < 64; i++ {
+			if h&(1<<i) != 0 {
+				v[i]++
+			} else {
+				v[i]--
+			}
+		}
+		n++
+	}
+	if n == 0 {
+		return 0
+	}
+
+	var h uint64
+	for i := uint(0); i < 64; i++ {
+		if v[i] > 0 {
+			h |= 1 << i
+		}
+	}
+	return h
+}
+
+// normalizedRunes returns the lowercased letters and digits of s.
+func normalizedRunes(s string) []rune {
+	var runes []rune
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			runes = append(runes, unicode.ToLower(r))
+		}
+	}
+	return runes
+}
+
+// shingles returns the overlapping k characters of the letters and digits of s.
+// A text shorter than k is a single shingle.
+func shingles(s string, k int) []string {
+	runes := normalizedRunes(s)
+	if len(runes) == 0 {
+		return nil
+	}
+	if len(runes) <= k {
+		return []string{string(runes)}
+	}
+
+	result := make([]string, 0, len(runes)-k+1)
+	for i := 0; i+k <= len(runes); i++ {
+		result = append(result, string(runes[i:i+k]))
+	}
+	return result
+}
+
+// hashFeature hashes f with fnv-1a, and mixes the bits since fnv of short strings
+// does not spread over all bits.
+func hashFeature(f string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(f))
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// HammingDistance returns the number of different bits of a and b.
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// SimHashSimilarity returns the similarity of the fingerprints a and b in [0, 1].
+func SimHashSimilarity(a, b uint64) float64 {
+	return 1 - float64(HammingDistance(a, b))/64
+}
+
+// SimHashBands splits h into n bands of 64/n bits from the lowest bits, n must be in [1, 64].
+// If n is not a divisor of 64, the first 64%n bands have one more bit. Fingerprints within
+// a Hamming distance of n-1 have at least one equal band, so the bands are the keys to
+// lookup near-duplicates.
+func SimHashBands(h uint64, n int) []uint64 {
+	bands := make([]uint64, n)
+	var shift uint
+	for i := range bands {
+		width := uint(64 / n)
+		if i < 64%n {
+			width++
+		}
+		mask := ^uint64(0)
+		if width < 64 {
+			mask = uint64(1)<<width - 1
+		}
+		bands[i] = (h >> shift) & mask
+		shift += width
+	}
+	return bands
+}
+
+// SimHashMatch is a fingerprint found by SimHashIndex.Query.
+type SimHashMatch struct {
+	ID       string
+	Distance int
+}
+
+// SimHashIndex indexes fingerprints by their bands, so that a query only compares
+// the fingerprints which have an equal band, instead of all of them.
+// It is safe for concurrent use.
+type SimHashIndex struct {
+	bands int
+
+	mu      sync.RWMutex
+	hashes  map[string]uint64
+	buckets []map[uint64]map[string]struct{}
+}
+
+// NewSimHashIndex creates an index of n bands, see SimHashBands.
+// Queries find all fingerprints within a Hamming distance of n-1.
+func NewSimHashIndex(n int) *SimHashIndex {
+	x := &SimHashIndex{
+		bands:   n,
+		hashes:  make(map[string]uint64),
+		buckets: make([]map[uint64]map[string]struct{}, n),
+	}
+	for i := range x.buckets {
+		x.buckets[i] = make(map[uint64]map[string]struct{})
+	}
+	return x
+}
+
+// Add indexes the fingerprint h of id, and replaces the one added before.
+func (x *SimHashIndex) Add(id string, h uint64) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.remove(id)
+	x.hashes[id] = h
+	for i, b := range SimHashBands(h, x.bands) {
+		ids := x.buckets[i][b]
+		if ids == nil {
+			ids = make(map[string]struct{})
+			x.buckets[i][b] = ids
+		}
+		ids[id] = struct{}{}
+	}
+}
+
+// Remove removes the fingerprint of id.
+func (x *SimHashIndex) Remove(id string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.remove(id)
+}
+
+func (x *SimHashIndex) remove(id string) {
+	h, ok := x.hashes[id]
+	if !ok {
+		return
+	}
+	delete(x.hashes, id)
+	for i, b := range SimHashBands(h, x.bands) {
+		delete(x.buckets[i][b], id)
+		if len(x.buckets[i][b]) == 0 {
+			delete(x.buckets[i], b)
+		}
+	}
+}
+
+// Query returns the fingerprints within maxDistance of h, ordered by distance and then by id.
+// Fingerprints farther than the number of bands minus 1 may be missed.
+func (x *SimHashIndex) Query(h uint64, maxDistance int) []SimHashMatch {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var matches []SimHashMatch
+	for i, b := range SimHashBands(h, x.bands) {
+		for id := range x.buckets[i][b] {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			if d := HammingDistance(h, x.hashes[id]); d <= maxDistance {
+				matches = append(matches, SimHashMatch{ID: id, Distance: d})
+			}
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		return matches[i].ID < matches[j].ID
+	})
+	return matches
+}
+
+// Len returns the number of indexed fingerprints.
+func (x *SimHashIndex) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.hashes)
+}
@@ -0,0 +1,90 @@
+package content
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	simhashText = `鲁迅的著作是将一种文化中所包含的技术结构、价值和精神状态完全或部分地引入另一种文化的文献记载。这种文化引入包括四部分内容：变更需要、变更榜样、变更思想、变更理由。`
+	// the same text with different punctuations.
+	simhashCopy = `鲁迅的著作是将一种文化中所包含的技术、结构、价值和精神状态完全或部分地引入另一种文化的文献记载。这种文化引入包括四部分内容：变更需要、变更榜样、变更思想、变更理由！`
+	// the text with a few words replaced.
+	simhashEdit  = `梁启超的著作是将一种文化中所包含的技术结构、价值和精神状态完全或部分地引入另一种文化的文献记载。这种文化引入包括四部分内容：变更需要、变更榜样、变更思想、变更原因。`
+	simhashOther = `北京时间31日早间消息，彭博社援引不具名知情人士的话称，美国亿万富翁沃伦-巴菲特今年较早时曾提出向优步科技公司注资30亿美元。`
+)
+
+func TestSimHash(t *testing.T) {
+	h := SimHash(simhashText)
+	assert.Equal(t, h, SimHash(simhashText), "deterministic")
+	assert.Equal(t, 0, HammingDistance(h, SimHash(simhashCopy)), "punctuations are ignored")
+	assert.True(t, HammingDistance(h, SimHash(simhashEdit)) <= 3, "near-duplicate")
+	assert.True(t, HammingDistance(h, SimHash(simhashOther)) > 10, "different text")
+	assert.True(t, SimHashSimilarity(h, SimHash(simhashEdit)) > SimHashSimilarity(h, SimHash(simhashOther)))
+
+	assert.Equal(t, uint64(0), SimHash(""))
+	assert.Equal(t, uint64(0), SimHash(" ，。"), "no letters or digits")
+	assert.NotEqual(t, uint64(0), SimHash("ab"), "text shorter than a shingle")
+}
+
+func TestSimHashBands(t *testing.T) {
+	bands := SimHashBands(0x0123456789abcdef, 4)
+	assert.Equal(t, []uint64{0xcdef, 0x89ab, 0x4567, 0x0123}, bands)
+	assert.Equal(t, []uint64{0x0123456789abcdef}, SimHashBands(0x0123456789abcdef, 1))
+	assert.Equal(t, []uint64{1<<22 - 1, 1<<21 - 1, 1<<21 - 1}, SimHashBands(^uint64(0), 3), "the first band has one more bit")
+
+	// 10 bits in different bands of 11 leave one band equal.
+	h := uint64(0x0123456789abcdef)
+	other := h
+	for i := uint(0); i < MediaMatchDistance; i++ {
+		other ^= 1 << (i * 6)
+	}
+	equal := 0
+	b := SimHashBands(other, MediaHashBands)
+	for i, v := range SimHashBands(h, MediaHashBands) {
+		if b[i] == v {
+			equal++
+		}
+	}
+	assert.Equal(t, 1, equal)
+}
+
+func TestSimHashIndex(t *testing.T) {
+	x := NewSimHashIndex(4)
+	x.Add("text", SimHash(simhashText))
+	x.Add("copy", SimHash(simhashCopy))
+	x.Add("edit", SimHash(simhashEdit))
+	x.Add("other", SimHash(simhashOther))
+	assert.Equal(t, 4, x.Len())
+
+	matches := x.Query(SimHash(simhashText), 3)
+	if assert.Len(t, matches, 3) {
+		assert.Equal(t, SimHashMatch{ID: "copy", Distance: 0}, matches[0], "ordered by distance and id")
+		assert.Equal(t, SimHashMatch{ID: "text", Distance: 0}, matches[1])
+		assert.Equal(t, "edit", matches[2].ID)
+	}
+	assert.Len(t, x.Query(SimHash(simhashText), 0), 2, "threshold")
+
+	x.Remove("copy")
+	x.Add("text", SimHash(simhashOther))
+	matches = x.Query(SimHash(simhashText), 3)
+	if assert.Len(t, matches, 1) {
+		assert.Equal(t, "edit", matches[0].ID)
+	}
+	assert.Equal(t, 3, x.Len())
+}
+
+func BenchmarkSimHashIndexQuery(b *testing.B) {
+	x := NewSimHashIndex(4)
+	for i := 0; i < 100000; i++ {
+		x.Add(fmt.Sprint(i), hashFeature(fmt.Sprint(i)))
+	}
+	h := SimHash(simhashText)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x.Query(h, 3)
+	}
+}
@@ -1,19 +1,252 @@
 package content
 
 import (
+	"math"
+	"sort"
 	"strings"
 
 	"github.com/rfguri/bowsim"
-	"github.com/yanyiwu/gojieba"
 )
 
-func Similarity(a, b string) float64 {
-	if x == nil {
-		x = gojieba.NewJieba()
+// Similarity measures how similar two texts are.
+type Similarity interface {
+	// Compare returns the similarity of a and b in [0, 1].
+	Compare(a, b string) float64
+}
+
+// DefaultSimilarity is the name of the Similarity used by Compare.
+const DefaultSimilarity = "bow"
+
+// DefaultMaxRunes is the max number of letters and digits of each text compared by LCS and
+// Levenshtein if their MaxRunes is not set.
+const DefaultMaxRunes = 5000
+
+// RuneLimited is a Similarity which compares at most RuneLimit letters and digits of each text,
+// and ignores the rest. Callers may reject longer texts instead, see ExceedsRuneLimit.
+type RuneLimited interface {
+	Similarity
+	RuneLimit() int
+}
+
+var similarities = map[string]Similarity{
+	"bow":         BagOfWords{},
+	"tfidf":       TFIDF{},
+	"jaccard":     Jaccard{N: 2},
+	"lcs":         LCS{},
+	"levenshtein": Levenshtein{},
+}
+
+// SimilarityByName returns the Similarity of name, an empty name is DefaultSimilarity.
+func SimilarityByName(name string) (Similarity, bool) {
+	if name == "" {
+		name = DefaultSimilarity
 	}
+	s, ok := similarities[name]
+	return s, ok
+}
+
+// SimilarityNames returns the sorted names of the similarities.
+func SimilarityNames() []string {
+	names := make([]string, 0, len(similarities))
+	for name := range similarities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Compare returns the similarity of a and b by DefaultSimilarity.
+func Compare(a, b string) float64 {
+	return BagOfWords{}.Compare(a, b)
+}
+
+// BagOfWords is the similarity of the words of the texts segmented by jieba.
+type BagOfWords struct {
+	// Segmenter segments the texts, the DefaultSegmenter if it is nil.
+	Segmenter *Segmenter
+}
 
-	words1 := x.Cut(a, true)
-	words2 := x.Cut(b, true)
+func (bow BagOfWords) Compare(a, b string) float64 {
+	seg := segmenterOrDefault(bow.Segmenter)
+	words1 := seg.Cut(a)
+	words2 := seg.Cut(b)
 
 	return bowsim.Get(strings.Join(words1, " "), strings.Join(words2, " "))
 }
+
+// tfidfWords is the max number of words of a text weighted by TFIDF.
+const tfidfWords = 1000
+
+// TFIDF is the cosine similarity of the words of the texts weighted by TF-IDF,
+// with the IDF dictionary of jieba. Stop words and single characters are ignored.
+type TFIDF struct {
+	// Segmenter segments the texts, the DefaultSegmenter if it is nil.
+	Segmenter *Segmenter
+}
+
+func (t TFIDF) Compare(a, b string) float64 {
+	seg := segmenterOrDefault(t.Segmenter)
+	va := make(map[string]float64)
+	for _, w := range seg.ExtractWithWeight(a, tfidfWords) {
+		va[w.Word] = w.Weight
+	}
+	vb := make(map[string]float64)
+	for _, w := range seg.ExtractWithWeight(b, tfidfWords) {
+		vb[w.Word] = w.Weight
+	}
+	return cosine(va, vb)
+}
+
+func cosine(a, b map[string]float64) float64 {
+	var dot, na, nb float64
+	for k, v := range a {
+		dot += v * b[k]
+		na += v * v
+	}
+	for _, v := range b {
+		nb += v * v
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return math.Min(dot/math.Sqrt(na*nb), 1)
+}
+
+// Jaccard is the Jaccard index of the character n-grams of the texts, see SimHash
+// for the characters compared. It works for Chinese texts without segmentation.
+type Jaccard struct {
+	N int
+}
+
+func (j Jaccard) Compare(a, b string) float64 {
+	set := make(map[string]bool)
+	for _, s := range shingles(a, j.N) {
+		set[s] = true
+	}
+
+	inter := 0
+	union := len(set)
+	seen := make(map[string]bool)
+	for _, s := range shingles(b, j.N) {
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+		if set[s] {
+			inter++
+		} else {
+			union++
+		}
+	}
+	if union == 0 {
+		return 0
+	}
+	return float64(inter) / float64(union)
+}
+
+// LCS is the ratio of the longest common subsequence of the characters of the texts
+// to their average length. It is quadratic in the length of the texts, so that the texts
+// are truncated to RuneLimit.
+type LCS struct {
+	// MaxRunes is the max number of characters compared, DefaultMaxRunes if it is 0.
+	MaxRunes int
+}
+
+// RuneLimit returns the max number of characters of each text which are compared.
+func (l LCS) RuneLimit() int {
+	return runeLimit(l.MaxRunes)
+}
+
+func (l LCS) Compare(a, b string) float64 {
+	ra, rb := limitRunes(normalizedRunes(a), l.RuneLimit()), limitRunes(normalizedRunes(b), l.RuneLimit())
+	if len(ra)+len(rb) == 0 {
+		return 0
+	}
+
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			switch {
+			case ra[i-1] == rb[j-1]:
+				cur[j] = prev[j-1] + 1
+			case prev[j] > cur[j-1]:
+				cur[j] = prev[j]
+			default:
+				cur[j] = cur[j-1]
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return 2 * float64(prev[len(rb)]) / float64(len(ra)+len(rb))
+}
+
+// Levenshtein is 1 minus the edit distance of the characters of the texts divided by
+// the length of the longer one. It is quadratic in the length of the texts, and suits
+// short texts like titles. The texts are truncated to RuneLimit.
+type Levenshtein struct {
+	// MaxRunes is the max number of characters compared, DefaultMaxRunes if it is 0.
+	MaxRunes int
+}
+
+// RuneLimit returns the max number of characters of each text which are compared.
+func (l Levenshtein) RuneLimit() int {
+	return runeLimit(l.MaxRunes)
+}
+
+func (l Levenshtein) Compare(a, b string) float64 {
+	ra, rb := limitRunes(normalizedRunes(a), l.RuneLimit()), limitRunes(normalizedRunes(b), l.RuneLimit())
+	n := len(ra)
+	if len(rb) > n {
+		n = len(rb)
+	}
+	if n == 0 {
+		return 0
+	}
+
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			d := prev[j-1]
+			if ra[i-1] != rb[j-1] {
+				d++
+			}
+			if prev[j]+1 < d {
+				d = prev[j] + 1
+			}
+			if cur[j-1]+1 < d {
+				d = cur[j-1] + 1
+			}
+			cur[j] = d
+		}
+		prev, cur = cur, prev
+	}
+	return 1 - float64(prev[len(rb)])/float64(n)
+}
+
+func runeLimit(max int) int {
+	if max <= 0 {
+		return DefaultMaxRunes
+	}
+	return max
+}
+
+// limitRunes returns the first max runes of r.
+func limitRunes(r []rune, max int) []rune {
+	if len(r) > max {
+		return r[:max]
+	}
+	return r
+}
+
+// ExceedsRuneLimit returns true if sim is RuneLimited and text has more letters and digits
+// than its RuneLimit.
+func ExceedsRuneLimit(sim Similarity, text string) bool {
+	l, ok := sim.(RuneLimited)
+	return ok && len(normalizedRunes(text)) > l.RuneLimit()
+}
@@ -1,25 +1,102 @@
 package content
 
 import (
+	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
+var similarSamples = []struct {
+	a, b string
+}{
+	{`hello world`, `hello blockchain`},
+	{`把别人的结论当做自己的结论，乃抄袭也。`, `把别人的结论当做自己的已知，乃引用也。`},
+	{`鲁迅的著作是将一种文化中所包含的技术结构、价值和精神状态完全或部分地引入另一种文化的文献记载。这种文化引入包括四部分内容：变更需要、变更榜样、变更思想、变更理由。
+	那么，康、梁、谭、严等后期改良派开始产生了一整套的资产阶级性质的社会政治理论和哲学观点作为变法思想的巩固的理论基础，显示了对“传统”的更为彻底的批判和对西方社会文化的更为彻底的肯定。
+	追求民族的独立与平等的意识深藏于鲁迅日本时期的文化理论中。`,
+		`梁启超的著作是将一种文化中所包含的技术、结构、价值和精神状态完全或部分地引入另一种文化的文献记载。这种文化引入包括四部分内容：变更需要、变更榜样、变更思想、变更理由。
+	应该充分估计到，开始产生了一整套的资产阶级性质的社会政治理论和哲学观点作为变法思想的巩固的理论基础，是这一阶段改良派思想最重要的发展和最卓著的成就。
+	如果说追求中国与西方平等的观念深藏于梁启超的思想中，那么它也会深藏于每一个近代中国人的——从最保守的到最激进的——文化理论中。`},
+}
+
 func TestSimilarity(t *testing.T) {
-	a := `hello world`
-	b := `hello blockchain`
+	for _, name := range SimilarityNames() {
+		s, _ := SimilarityByName(name)
+		for _, sample := range similarSamples {
+			t.Logf("%s a and b: %f", name, s.Compare(sample.a, sample.b))
+		}
+	}
+}
 
-	t.Logf("a and b: %f", Similarity(a, b))
+func TestSimilarityByName(t *testing.T) {
+	s, ok := SimilarityByName("")
+	assert.True(t, ok)
+	assert.Equal(t, BagOfWords{}, s, "default")
+	s, ok = SimilarityByName("jaccard")
+	assert.True(t, ok)
+	assert.Equal(t, Jaccard{N: 2}, s)
+	_, ok = SimilarityByName("unknown")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"bow", "jaccard", "lcs", "levenshtein", "tfidf"}, SimilarityNames())
+}
 
-	a = `把别人的结论当做自己的结论，乃抄袭也。`
-	b = `把别人的结论当做自己的已知，乃引用也。`
+func TestSimilarityAccuracy(t *testing.T) {
+	paraphrase := similarSamples[2]
+	for _, name := range SimilarityNames() {
+		s, _ := SimilarityByName(name)
 
-	t.Logf("a and b: %f", Similarity(a, b))
+		assert.InDelta(t, 1, s.Compare(simhashText, simhashText), 1e-6, "%s: same text", name)
+		for _, sample := range similarSamples {
+			v := s.Compare(sample.a, sample.b)
+			assert.True(t, v > 0 && v < 1, "%s: %f in (0, 1)", name, v)
+			assert.InDelta(t, v, s.Compare(sample.b, sample.a), 1e-6, "%s: symmetric", name)
+		}
 
-	a = `鲁迅的著作是将一种文化中所包含的技术结构、价值和精神状态完全或部分地引入另一种文化的文献记载。这种文化引入包括四部分内容：变更需要、变更榜样、变更思想、变更理由。
-	那么，康、梁、谭、严等后期改良派开始产生了一整套的资产阶级性质的社会政治理论和哲学观点作为变法思想的巩固的理论基础，显示了对“传统”的更为彻底的批判和对西方社会文化的更为彻底的肯定。
-	追求民族的独立与平等的意识深藏于鲁迅日本时期的文化理论中。`
-	b = `梁启超的著作是将一种文化中所包含的技术、结构、价值和精神状态完全或部分地引入另一种文化的文献记载。这种文化引入包括四部分内容：变更需要、变更榜样、变更思想、变更理由。
-	应该充分估计到，开始产生了一整套的资产阶级性质的社会政治理论和哲学观点作为变法思想的巩固的理论基础，是这一阶段改良派思想最重要的发展和最卓著的成就。
-	如果说追求中国与西方平等的观念深藏于梁启超的思想中，那么它也会深藏于每一个近代中国人的——从最保守的到最激进的——文化理论中。`
-	t.Logf("a and b: %f", Similarity(a, b))
+		copied := s.Compare(simhashText, simhashCopy)
+		edited := s.Compare(simhashText, simhashEdit)
+		other := s.Compare(simhashText, simhashOther)
+		assert.True(t, copied >= edited, "%s: copy %f >= edit %f", name, copied, edited)
+		assert.True(t, edited > other, "%s: edit %f > other %f", name, edited, other)
+		assert.True(t, s.Compare(paraphrase.a, paraphrase.b) > s.Compare(paraphrase.a, simhashOther), "%s: paraphrase", name)
+	}
+}
+
+func TestEditSimilarity(t *testing.T) {
+	assert.InDelta(t, 2*5.0/20, LCS{}.Compare("hello world", "hello chain"), 1e-6, "lcs of helloworld and hellochain")
+	assert.InDelta(t, 1-5.0/10, Levenshtein{}.Compare("hello world", "hello chain"), 1e-6)
+	assert.InDelta(t, 1-3.0/7, Levenshtein{}.Compare("kitten", "sitting"), 1e-6)
+	assert.InDelta(t, 1-1.0/3, Levenshtein{}.Compare("鲁迅说", "鲁迅讲"), 1e-6)
+	assert.InDelta(t, 1.0/3, Jaccard{N: 2}.Compare("鲁迅说", "鲁迅讲"), 1e-6, "{鲁迅} of {鲁迅, 迅说, 迅讲}")
+
+	for _, s := range []Similarity{Jaccard{N: 2}, LCS{}, Levenshtein{}} {
+		assert.Equal(t, float64(0), s.Compare("", ""), "nothing to compare")
+		assert.Equal(t, float64(0), s.Compare("abc", ""))
+	}
+}
+
+func TestRuneLimit(t *testing.T) {
+	assert.Equal(t, DefaultMaxRunes, LCS{}.RuneLimit())
+	assert.Equal(t, 5, Levenshtein{MaxRunes: 5}.RuneLimit())
+
+	// only hello is compared.
+	assert.InDelta(t, 1, LCS{MaxRunes: 5}.Compare("hello world", "hello chain"), 1e-6)
+	assert.InDelta(t, 1, Levenshtein{MaxRunes: 5}.Compare("hello world", "hello, chain"), 1e-6, "punctuations are not counted")
+
+	assert.True(t, ExceedsRuneLimit(LCS{MaxRunes: 5}, "hello world"))
+	assert.False(t, ExceedsRuneLimit(LCS{MaxRunes: 10}, "hello world"), "spaces are not counted")
+	assert.False(t, ExceedsRuneLimit(BagOfWords{}, strings.Repeat("hello ", DefaultMaxRunes)), "not limited")
+	assert.True(t, ExceedsRuneLimit(Levenshtein{}, strings.Repeat("hello ", DefaultMaxRunes)))
+}
+
+func BenchmarkSimilarity(b *testing.B) {
+	sample := similarSamples[2]
+	for _, name := range SimilarityNames() {
+		s, _ := SimilarityByName(name)
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				s.Compare(sample.a, sample.b)
+			}
+		})
+	}
 }
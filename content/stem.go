@@ -0,0 +1,210 @@
+package content
+
+// PorterStem returns the stem of a lowercase English word by the Porter stemming algorithm,
+// see https://tartarus.org/martin/PorterStemmer/def.txt. Words of other than a-z are
+// returned as they are.
+func PorterStem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+
+	s := &stemmer{b: []byte(word)}
+	s.step1ab()
+	s.step1c()
+	s.replaceSuffix(step2Suffixes)
+	s.replaceSuffix(step3Suffixes)
+	s.step4()
+	s.step5()
+	return string(s.b)
+}
+
+type stemmer struct {
+	b []byte
+}
+
+// cons returns true if b[i] is a consonant.
+func (s *stemmer) cons(i int) bool {
+	switch s.b[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !s.cons(i-1)
+	}
+	return true
+}
+
+// measure returns m of the stem b[:n], which is [C](VC){m}[V].
+func (s *stemmer) measure(n int) int {
+	m, i := 0, 0
+	for i < n && s.cons(i) {
+		i++
+	}
+	for i < n {
+		for i < n && !s.cons(i) {
+			i++
+		}
+		if i == n {
+			break
+		}
+		m++
+		for i < n && s.cons(i) {
+			i++
+		}
+	}
+	return m
+}
+
+// hasVowel returns true if the stem b[:n] contains a vowel.
+func (s *stemmer) hasVowel(n int) bool {
+	for i := 0; i < n; i++ {
+		if !s.cons(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// doubleCons returns true if the stem b[:n] ends with a double consonant.
+func (s *stemmer) doubleCons(n int) bool {
+	return n >= 2 && s.b[n-1] == s.b[n-2] && s.cons(n-1)
+}
+
+// cvc returns true if the stem b[:n] ends with consonant-vowel-consonant, and the last
+// consonant is not w, x or y, e.g. hop but not snow.
+func (s *stemmer) cvc(n int) bool {
+	if n < 3 || !s.cons(n-1) || s.cons(n-2) || !s.cons(n-3) {
+		return false
+	}
+	c := s.b[n-1]
+	return c != 'w' && c != 'x' && c != 'y'
+}
+
+func (s *stemmer) ends(suffix string) bool {
+	return len(s.b) >= len(suffix) && string(s.b[len(s.b)-len(suffix):]) == suffix
+}
+
+// stem returns the length of b without suffix.
+func (s *stemmer) stem(suffix string) int {
+	return len(s.b) - len(suffix)
+}
+
+func (s *stemmer) replace(suffix, repl string) {
+	s.b = append(s.b[:s.stem(suffix)], repl...)
+}
+
+func (s *stemmer) step1ab() {
+	switch {
+	case s.ends("sses"):
+		s.replace("sses", "ss")
+	case s.ends("ies"):
+		s.replace("ies", "i")
+	case s.ends("ss"):
+	case s.ends("s"):
+		s.replace("s", "")
+	}
+
+	if s.ends("eed") {
+		if s.measure(s.stem("eed")) > 0 {
+			s.replace("eed", "ee")
+		}
+		return
+	}
+	removed := false
+	for _, suffix := range []string{"ed", "ing"} {
+		if s.ends(suffix) && s.hasVowel(s.stem(suffix)) {
+			s.replace(suffix, "")
+			removed = true
+			break
+		}
+	}
+	if !removed {
+		return
+	}
+
+	n := len(s.b)
+	switch {
+	case s.ends("at"), s.ends("bl"), s.ends("iz"):
+		s.b = append(s.b, 'e')
+	case s.doubleCons(n):
+		if c := s.b[n-1]; c != 'l' && c != 's' && c != 'z' {
+			s.b = s.b[:n-1]
+		}
+	case s.measure(n) == 1 && s.cvc(n):
+		s.b = append(s.b, 'e')
+	}
+}
+
+func (s *stemmer) step1c() {
+	if s.ends("y") && s.hasVowel(s.stem("y")) {
+		s.b[len(s.b)-1] = 'i'
+	}
+}
+
+// the suffixes of the steps, only the longest one a word ends with is removed or replaced.
+var (
+	step2Suffixes = [][2]string{
+		{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+		{"izer", "ize"}, {"abli", "able"}, {"alli", "al"}, {"entli", "ent"},
+		{"eli", "e"}, {"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"},
+		{"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"},
+		{"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+	}
+	step3Suffixes = [][2]string{
+		{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+		{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+	}
+	step4Suffixes = []string{
+		"al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement", "ment", "ent",
+		"ion", "ou", "ism", "ate", "iti", "ous", "ive", "ize",
+	}
+)
+
+// replaceSuffix replaces the longest suffix of b in suffixes if the measure of the stem is
+// larger than 0.
+func (s *stemmer) replaceSuffix(suffixes [][2]string) {
+	var match [2]string
+	for _, r := range suffixes {
+		if s.ends(r[0]) && len(r[0]) > len(match[0]) {
+			match = r
+		}
+	}
+	if match[0] != "" && s.measure(s.stem(match[0])) > 0 {
+		s.replace(match[0], match[1])
+	}
+}
+
+func (s *stemmer) step4() {
+	var match string
+	for _, suffix := range step4Suffixes {
+		if s.ends(suffix) && len(suffix) > len(match) {
+			match = suffix
+		}
+	}
+	if match == "" {
+		return
+	}
+	n := s.stem(match)
+	if match == "ion" && (n == 0 || (s.b[n-1] != 's' && s.b[n-1] != 't')) {
+		return
+	}
+	if s.measure(n) > 1 {
+		s.b = s.b[:n]
+	}
+}
+
+func (s *stemmer) step5() {
+	if s.ends("e") {
+		n := s.stem("e")
+		if m := s.measure(n); m > 1 || (m == 1 && !s.cvc(n)) {
+			s.b = s.b[:n]
+		}
+	}
+	if n := len(s.b); s.measure(n) > 1 && s.doubleCons(n) && s.b[n-1] == 'l' {
+		s.b = s.b[:n-1]
+	}
+}
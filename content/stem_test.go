@@ -0,0 +1,45 @@
+package content
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPorterStem(t *testing.T) {
+	for word, stem := range map[string]string{
+		"caresses":        "caress",
+		"ponies":          "poni",
+		"cats":            "cat",
+		"feed":            "feed",
+		"agreed":          "agre",
+		"plastered":       "plaster",
+		"motoring":        "motor",
+		"sing":            "sing",
+		"conflated":       "conflat",
+		"troubled":        "troubl",
+		"sized":           "size",
+		"hopping":         "hop",
+		"falling":         "fall",
+		"hissing":         "hiss",
+		"filing":          "file",
+		"happy":           "happi",
+		"sky":             "sky",
+		"relational":      "relat",
+		"conditional":     "condit",
+		"rational":        "ration",
+		"generalizations": "gener",
+		"oscillators":     "oscil",
+		"connection":      "connect",
+		"connections":     "connect",
+		"connected":       "connect",
+		"adjustment":      "adjust",
+		"controlling":     "control",
+		"rolling":         "roll",
+		"is":              "is",
+		"café":            "café",
+		"ipc2018":         "ipc2018",
+	} {
+		assert.Equal(t, stem, PorterStem(word), word)
+	}
+}
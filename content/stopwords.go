@@ -0,0 +1,34 @@
+package content
+
+import "strings"
+
+// stopWords are the stop words of the languages tokenized by words, which are also used to
+// tell the Latin languages apart.
+var stopWords = map[string]map[string]bool{
+	LangEnglish: wordSet(`a about above after again against all am an and any are as at be because
+		been before being below between both but by can could did do does doing down during each
+		few for from further had has have having he her here hers herself him himself his how i
+		if in into is it it's its itself just me more most my myself no nor not now of off on once
+		only or other our ours ourselves out over own same she should so some such than that the
+		their theirs them themselves then there these they this those through to too under until
+		up very was we were what when where which while who whom why will with would you your
+		yours yourself yourselves`),
+	LangFrench: wordSet(`au aux avec ce ces cette dans de des du elle elles en est et être eux il
+		ils je la le les leur leurs lui ma mais me mes moi mon ne nos notre nous on ou où par pas
+		pour qu que qui sa se ses son sont sur ta te tes toi ton tu un une vos votre vous`),
+	LangGerman: wordSet(`aber als am an auch auf aus bei bin bis bist das dass dem den der des
+		die dies diese dieser du durch ein eine einem einen einer eines er es für hat hatte ich
+		ihr im in ist ja kein mit nach nicht noch nur oder sein sich sie sind so über um und uns
+		von vor war was weil wenn wie wir wird zu zum zur`),
+	LangSpanish: wordSet(`al como con de del el ella ellas ellos en era es esta este esto fue ha
+		hay la las le les lo los más me mi muy ni no nos o para pero por que se si sin sobre su
+		sus también te tu un una uno y ya yo`),
+}
+
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(words) {
+		set[w] = true
+	}
+	return set
+}
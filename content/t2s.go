@@ -0,0 +1,63 @@
+package content
+
+import "strings"
+
+// t2s maps the traditional Chinese characters to the simplified ones. It only has the common
+// characters of a single simplified form, e.g. 著 is not mapped as it is 着 or 著 by the word.
+var t2s = make(map[rune]rune)
+
+// t2sPairs are the traditional and the simplified characters separated by spaces.
+const t2sPairs = "" +
+	"萬万 與与 醜丑 專专 業业 叢丛 東东 絲丝 兩两 嚴严 喪丧 個个 豐丰 臨临 為为 麗丽 舉举 義义 烏乌 樂乐 " +
+	"喬乔 習习 鄉乡 書书 買买 亂乱 爭争 於于 虧亏 雲云 亞亚 產产 畝亩 親亲 褻亵 億亿 僅仅 從从 倉仓 儀仪 " +
+	"們们 價价 眾众 優优 會会 傘伞 偉伟 傳传 傷伤 倫伦 偽伪 體体 餘余 傭佣 俠侠 偵侦 側侧 僑侨 債债 傾倾 " +
+	"償偿 儲储 兒儿 兌兑 黨党 蘭兰 關关 興兴 養养 獸兽 岡冈 冊册 寫写 軍军 農农 馮冯 衝冲 決决 況况 凍冻 " +
+	"淨净 涼凉 減减 幾几 鳳凤 憑凭 凱凯 擊击 劃划 劉刘 則则 剛刚 創创 刪删 別别 劑剂 劍剑 劇剧 勸劝 辦办 " +
+	"務务 動动 勵励 勁劲 勞劳 勢势 勳勋 勻匀 區区 醫医 華华 協协 單单 賣卖 盧卢 衛卫 卻却 廠厂 廳厅 曆历 " +
+	"歷历 厲厉 壓压 厭厌 廁厕 廂厢 廈厦 廚厨 縣县 參参 雙双 發发 髮发 變变 敘叙 疊叠 葉叶 號号 嘆叹 嚇吓 " +
+	"呂吕 嗎吗 噸吨 聽听 啟启 吳吴 員员 嗚呜 詠咏 嚨咙 響响 啞哑 嘩哗 喚唤 噴喷 囑嘱 團团 園园 圍围 國国 " +
+	"圖图 圓圆 聖圣 場场 壞坏 塊块 堅坚 壇坛 壩坝 墳坟 墜坠 壟垄 壘垒 墾垦 墊垫 塹堑 墮堕 壯壮 聲声 殼壳 " +
+	"壺壶 處处 備备 復复 複复 夠够 夥伙 頭头 誇夸 夾夹 奪夺 奮奋 獎奖 奧奥 妝妆 婦妇 媽妈 嫵妩 嬌娇 孫孙 " +
+	"學学 寧宁 寶宝 實实 寵宠 審审 憲宪 宮宫 對对 尋寻 導导 將将 爾尔 塵尘 嘗尝 堯尧 尷尴 屍尸 盡尽 儘尽 " +
+	"層层 屬属 屢屡 歲岁 豈岂 島岛 嶺岭 嶽岳 崗岗 峽峡 巒峦 幣币 帥帅 師师 帳帐 帶带 幫帮 幹干 幟帜 廣广 " +
+	"莊庄 慶庆 廬庐 庫库 應应 廟庙 龐庞 廢废 開开 異异 棄弃 張张 彌弥 彎弯 彈弹 強强 歸归 當当 錄录 彥彦 " +
+	"徹彻 徑径 後后 憶忆 懷怀 態态 總总 戀恋 懇恳 惡恶 惱恼 悅悦 悵怅 惻恻 慘惨 慚惭 慣惯 憤愤 願愿 懶懒 " +
+	"憂忧 戲戏 戰战 戶户 撲扑 執执 擴扩 掃扫 揚扬 擾扰 撫抚 搶抢 護护 報报 擔担 擬拟 攏拢 揀拣 擁拥 攔拦 " +
+	"擰拧 撥拨 擇择 掛挂 摯挚 攣挛 撓挠 擋挡 擠挤 揮挥 撈捞 損损 撿捡 換换 搗捣 據据 擄掳 摑掴 擲掷 撣掸 " +
+	"摻掺 攙搀 擱搁 摟搂 攪搅 攜携 攝摄 擺摆 搖摇 擯摈 攤摊 撐撑 攢攒 擼撸 斂敛 數数 齋斋 斬斩 斷断 無无 " +
+	"舊旧 時时 曠旷 曇昙 晝昼 顯显 晉晋 曬晒 曉晓 暫暂 曖暧 術术 朧胧 機机 殺杀 雜杂 權权 條条 來来 楊杨 " +
+	"極极 構构 樞枢 棗枣 櫃柜 檸柠 標标 棧栈 欄栏 樹树 樣样 橋桥 檢检 檜桧 樁桩 夢梦 槍枪 楓枫 梟枭 棟栋 " +
+	"欞棂 樓楼 櫻樱 橫横 檔档 歡欢 歐欧 殲歼 殘残 殯殡 毀毁 氣气 氫氢 漢汉 湯汤 溝沟 沒没 灃沣 漚沤 滬沪 " +
+	"瀋沈 淺浅 漿浆 澆浇 濁浊 測测 濟济 瀏浏 渾浑 濃浓 濤涛 澇涝 漣涟 渦涡 漲涨 澀涩 淵渊 漁渔 滲渗 溫温 " +
+	"灣湾 濕湿 潰溃 濺溅 滿满 濾滤 灤滦 濫滥 濱滨 灘滩 潛潜 澤泽 灑洒 漸渐 燈灯 靈灵 災灾 爐炉 點点 煉炼 " +
+	"爍烁 爛烂 熱热 煙烟 營营 燒烧 燙烫 燭烛 燼烬 愛爱 爺爷 牽牵 犧牺 狀状 猶犹 狹狭 獅狮 獨独 獄狱 猙狰 " +
+	"獵猎 貓猫 獻献 獲获 瑪玛 環环 現现 璽玺 瓏珑 瑣琐 瓊琼 甕瓮 畫画 暢畅 療疗 瘋疯 瘡疮 癢痒 瘍疡 癮瘾 " +
+	"癱瘫 癡痴 皚皑 盞盏 鹽盐 監监 蓋盖 盤盘 睜睁 瞞瞒 矚瞩 礦矿 碼码 磚砖 礎础 碩硕 確确 禮礼 禍祸 禪禅 " +
+	"離离 禿秃 種种 積积 稱称 穩稳 穀谷 窮穷 竊窃 窩窝 竅窍 竄窜 豎竖 競竞 筆笔 筍笋 築筑 簡简 籌筹 簽签 " +
+	"籃篮 類类 糧粮 緊紧 糾纠 紅红 約约 級级 紀纪 純纯 紗纱 納纳 紛纷 紙纸 紋纹 紡纺 紐纽 線线 練练 組组 " +
+	"細细 織织 終终 紹绍 經经 綁绑 結结 絕绝 給给 絡络 統统 絹绢 繼继 績绩 緒绪 續续 維维 綿绵 綱纲 網网 " +
+	"綠绿 緣缘 編编 緩缓 緯纬 縮缩 縱纵 罰罚 罷罢 羅罗 羨羡 翹翘 聞闻 聯联 聰聪 職职 肅肃 腸肠 膚肤 腎肾 " +
+	"腫肿 脹胀 脅胁 膽胆 勝胜 臟脏 髒脏 腦脑 腳脚 臉脸 臘腊 膩腻 艦舰 艙舱 艱艰 藝艺 節节 蘇苏 蘋苹 範范 " +
+	"莖茎 薦荐 莢荚 蕩荡 榮荣 葷荤 藥药 蓮莲 蕭萧 薩萨 蘿萝 螢萤 藍蓝 蘆芦 蝦虾 蟲虫 雖虽 蠶蚕 蠻蛮 螞蚂 " +
+	"蟻蚁 蠟蜡 補补 襯衬 襪袜 裝装 襲袭 見见 觀观 規规 覓觅 視视 覽览 覺觉 觸触 計计 訂订 認认 譏讥 討讨 " +
+	"讓让 訓训 議议 訊讯 記记 講讲 諱讳 謳讴 許许 訛讹 論论 訟讼 設设 訪访 證证 評评 識识 詐诈 訴诉 診诊 " +
+	"詞词 譯译 試试 詩诗 誠诚 話话 誕诞 詢询 該该 詳详 語语 誤误 說说 誰谁 課课 調调 談谈 請请 諒谅 諸诸 " +
+	"諾诺 謀谋 謊谎 謎谜 謙谦 謝谢 謠谣 謹谨 譜谱 讀读 讚赞 豬猪 貝贝 貞贞 負负 貢贡 財财 責责 賢贤 敗败 " +
+	"賬账 貨货 質质 販贩 貪贪 貧贫 購购 貯贮 貫贯 貼贴 貴贵 貸贷 費费 賀贺 資资 賊贼 賄贿 賓宾 賜赐 賞赏 " +
+	"賠赔 賴赖 賺赚 賽赛 贈赠 贊赞 贏赢 趕赶 趙赵 躍跃 蹤踪 踐践 車车 軌轨 軒轩 軟软 轉转 輪轮 輕轻 載载 " +
+	"較较 輔辅 輛辆 輝辉 輩辈 輸输 轎轿 轟轰 辭辞 邊边 遼辽 達达 遷迁 過过 邁迈 運运 還还 這这 進进 遠远 " +
+	"違违 連连 遲迟 適适 選选 遺遗 遞递 週周 鄧邓 鄭郑 鄰邻 醬酱 釀酿 釋释 裏里 裡里 鑒鉴 針针 釘钉 釣钓 " +
+	"鈕钮 鈔钞 鈣钙 鈴铃 鉛铅 鉤钩 銀银 銅铜 銘铭 鋁铝 鋒锋 鋪铺 鋼钢 錢钱 錦锦 錫锡 錯错 鍋锅 鍵键 鏈链 " +
+	"鎖锁 鎮镇 鏡镜 鐘钟 鍾钟 鐵铁 鑰钥 鑽钻 長长 門门 閃闪 閉闭 問问 閑闲 間间 閱阅 闊阔 闆板 闖闯 隊队 " +
+	"陽阳 陰阴 陣阵 階阶 際际 陸陆 陳陈 險险 隨随 隱隐 難难 雞鸡 電电 霧雾 靜静 韓韩 頁页 頂顶 項项 順顺 " +
+	"須须 預预 頑顽 頓顿 頒颁 領领 頻频 題题 額额 顏颜 顧顾 風风 飛飞 飯饭 飲饮 飽饱 飼饲 餅饼 館馆 饑饥 " +
+	"餓饿 饒饶 馬马 駐驻 駕驾 驗验 騎骑 騙骗 驚惊 驅驱 骯肮 鬥斗 鬧闹 魚鱼 魯鲁 鮮鲜 鯨鲸 鳥鸟 鳴鸣 鴨鸭 " +
+	"鵝鹅 鷹鹰 鹼碱 麥麦 黃黄 齊齐 齒齿 龍龙 龜龟 麼么 麵面 準准 製制 傑杰 僕仆 彙汇 匯汇 係系 繫系 鬆松 " +
+	"臺台 檯台 颱台 隻只 嚮向 纔才 佈布 痠酸 並并 齣出"
+
+func init() {
+	for _, p := range strings.Fields(t2sPairs) {
+		r := []rune(p)
+		t2s[r[0]] = r[1]
+	}
+}
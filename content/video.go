@@ -0,0 +1,87 @@
+package content
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"os/exec"
+	"strconv"
+)
+
+// ErrNoKeyframe is returned if no keyframe is extracted from a video.
+var ErrNoKeyframe = errors.New("no keyframe in the video")
+
+// KeyframeExtractor extracts the keyframes of videos.
+type KeyframeExtractor interface {
+	Keyframes(video []byte) ([]image.Image, error)
+}
+
+// FFmpeg extracts the keyframes (I-frames) of videos by the ffmpeg command.
+type FFmpeg struct {
+	// Path is the path of ffmpeg, the one in PATH if it is empty.
+	Path string
+	// MaxFrames is the max number of keyframes, all of them if it is 0.
+	MaxFrames int
+}
+
+func (f FFmpeg) Keyframes(video []byte) ([]image.Image, error) {
+	path := f.Path
+	if path == "" {
+		path = "ffmpeg"
+	}
+	args := []string{"-loglevel", "error", "-i", "pipe:0",
+		"-vf", "select=eq(pict_type\\,I)", "-vsync", "vfr"}
+	if f.MaxFrames > 0 {
+		args = append(args, "-frames:v", strconv.Itoa(f.MaxFrames))
+	}
+	args = append(args, "-f", "image2pipe", "-vcodec", "png", "pipe:1")
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = bytes.NewReader(video)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			return nil, errors.New(stderr.String())
+		}
+		return nil, err
+	}
+	return decodePNGs(&stdout)
+}
+
+// decodePNGs decodes the png images written one after another to r.
+func decodePNGs(r io.Reader) ([]image.Image, error) {
+	br := bufio.NewReader(r)
+	var images []image.Image
+	for {
+		if _, err := br.Peek(1); err == io.EOF {
+			return images, nil
+		}
+		img, err := png.Decode(br)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, img)
+	}
+}
+
+// VideoHashes returns the pHashes of the keyframes of video.
+func VideoHashes(video []byte, extractor KeyframeExtractor) ([]uint64, error) {
+	frames, err := extractor.Keyframes(video)
+	if err != nil {
+		return nil, err
+	}
+	if len(frames) == 0 {
+		return nil, ErrNoKeyframe
+	}
+
+	hashes := make([]uint64, len(frames))
+	for i, frame := range frames {
+		hashes[i] = PerceptualHash(frame)
+	}
+	return hashes, nil
+}
@@ -39,3 +39,8 @@ func GenerateKey() (*PrivateKey, error) {
 	}
 	return (*PrivateKey)((*btcec.PrivateKey)(key)), nil
 }
+
+// Decrypt decrypts data encrypted by PublicKey.Encrypt of the public key.
+func (p *PrivateKey) Decrypt(data []byte) ([]byte, error) {
+	return btcec.Decrypt(p.pk(), data)
+}
@@ -57,3 +57,8 @@ func ParsePubKeyBase58(pubkeyStr string) ([]byte, int) {
 	b := base58.Decode(pubkeyStr)
 	return b[:btcec.PubKeyBytesLenCompressed], len(b)
 }
+
+// Encrypt encrypts data with ECIES, which can only be decrypted by the private key.
+func (p *PublicKey) Encrypt(data []byte) ([]byte, error) {
+	return btcec.Encrypt(p.pk(), data)
+}
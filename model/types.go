@@ -8,6 +8,7 @@ type Account struct {
 	Name      string    `gorm:"COLUMN:name;PRIMARY_KEY;TYPE:VARCHAR(64);NOT NULL" json:"name,omitempty"`
 	Company   string    `gorm:"COLUMN:company;TYPE:VARCHAR(64);NOT NULL" json:"company,omitempty"`
 	WIF       string    `gorm:"COLUMN:wif;TYPE:VARCHAR(128);NOT NULL" json:"wif,omitempty"`
+	PublicKey string    `gorm:"COLUMN:public_key;TYPE:VARCHAR(128);index:idx_public_key" json:"public_key,omitempty"`
 	CreatedAt time.Time `gorm:"COLUMN:created_at;" json:"created_at,omitempty"`
 }
 
@@ -33,13 +34,44 @@ type Member struct {
 type Content []byte
 
 type Post struct {
-	MSGID       int64     `gorm:"COLUMN:mid;NOT NULL" json:"mid,omitempty"`
-	DNA         string    `gorm:"COLUMN:dna;index:idx_dna;TYPE:VARCHAR(255);NOT NULL" json:"dna,omitempty"`
-	Author      string    `gorm:"COLUMN:author;TYPE:VARCHAR(64);NOT NULL;index:idx_author" json:"author,omitempty"`
-	Content     string    `gorm:"COLUMN:content;TYPE:TEXT;NOT NULL" json:"content,omitempty"`
-	ContentType uint8     `gorm:"COLUMN:content_type;TYPE:TINYINT" json:"content_type,omitempty"`
-	StoreType   uint8     `gorm:"COLUMN:store_type;TYPE:TINYINT" json:"store_type,omitempty"`
-	Keywords    string    `gorm:"COLUMN:keywords;TYPE:VARCHAR(256);index:idx_keywords" json:"keywords,omitempty"`
-	Digest      string    `gorm:"COLUMN:digest;TYPE:VARCHAR(64);NOT NULL" json:"digest,omitempty"`
-	CreatedAt   time.Time `gorm:"COLUMN:created_at;NOT NULL" json:"created_at,omitempty"`
+	MSGID       int64  `gorm:"COLUMN:mid;NOT NULL;unique_index:uix_posts_author_mid" json:"mid,omitempty"`
+	DNA         string `gorm:"COLUMN:dna;unique_index:uix_posts_dna;TYPE:VARCHAR(255);NOT NULL" json:"dna,omitempty"`
+	Author      string `gorm:"COLUMN:author;TYPE:VARCHAR(64);NOT NULL;index:idx_author;unique_index:uix_posts_author_mid" json:"author,omitempty"`
+	Content     string `gorm:"COLUMN:content;TYPE:TEXT;NOT NULL" json:"content,omitempty"`
+	ContentType uint8  `gorm:"COLUMN:content_type" json:"content_type,omitempty"`
+	StoreType   uint8  `gorm:"COLUMN:store_type" json:"store_type,omitempty"`
+	KeyOwner    string `gorm:"COLUMN:key_owner;TYPE:VARCHAR(64)" json:"key_owner,omitempty"`
+	WrappedKey  string `gorm:"COLUMN:wrapped_key;TYPE:VARCHAR(512)" json:"wrapped_key,omitempty"`
+	Keywords    string `gorm:"COLUMN:keywords;TYPE:VARCHAR(256);index:idx_keywords" json:"keywords,omitempty"`
+	// Language is the language of the content detected by content.DetectLanguage.
+	Language    string `gorm:"COLUMN:language;TYPE:VARCHAR(8)" json:"language,omitempty"`
+	SimHash     int64  `gorm:"COLUMN:simhash;NOT NULL;DEFAULT:0" json:"simhash,omitempty"`
+	MediaHashes string `gorm:"COLUMN:media_hashes;TYPE:TEXT" json:"media_hashes,omitempty"`
+	Digest      string `gorm:"COLUMN:digest;TYPE:VARCHAR(64);NOT NULL" json:"digest,omitempty"`
+	// NormalizedDigest is the digest of the normalized content, see content.Normalize.
+	NormalizedDigest string     `gorm:"COLUMN:normalized_digest;TYPE:VARCHAR(64);index:idx_posts_normalized_digest" json:"normalized_digest,omitempty"`
+	Version          int        `gorm:"COLUMN:version;NOT NULL;DEFAULT:1" json:"version,omitempty"`
+	Retracted        bool       `gorm:"COLUMN:retracted;NOT NULL;DEFAULT:false" json:"retracted,omitempty"`
+	RetractedAt      *time.Time `gorm:"COLUMN:retracted_at" json:"retracted_at,omitempty"`
+	// TxID, BlockNum and BlockTime are the transaction and the block which anchor the post on chain,
+	// see chain.Anchorer. They are empty if the post is not anchored.
+	TxID      string     `gorm:"COLUMN:tx_id;TYPE:VARCHAR(64)" json:"tx_id,omitempty"`
+	BlockNum  uint32     `gorm:"COLUMN:block_num;NOT NULL;DEFAULT:0" json:"block_num,omitempty"`
+	BlockTime *time.Time `gorm:"COLUMN:block_time" json:"block_time,omitempty"`
+	CreatedAt time.Time  `gorm:"COLUMN:created_at;NOT NULL" json:"created_at,omitempty"`
+	// Terms are the tokens of the content for full-text search, see content.SearchTokens. They are
+	// not saved with the post, but indexed by the store. If they are nil, the store extracts them
+	// from the content, so they are set by the client if the content is kept in a blob store.
+	Terms []string `gorm:"-" json:"-"`
+}
+
+// PostVersion is a previous version of a post, which is kept when the post is updated.
+// The dna and digest of it are still the proof of the previous content.
+type PostVersion struct {
+	DNA       string    `gorm:"COLUMN:dna;PRIMARY_KEY;TYPE:VARCHAR(255);NOT NULL" json:"dna,omitempty"`
+	Author    string    `gorm:"COLUMN:author;TYPE:VARCHAR(64);NOT NULL;unique_index:uix_post_versions_author_mid_version" json:"author,omitempty"`
+	MSGID     int64     `gorm:"COLUMN:mid;NOT NULL;unique_index:uix_post_versions_author_mid_version" json:"mid,omitempty"`
+	Version   int       `gorm:"COLUMN:version;NOT NULL;unique_index:uix_post_versions_author_mid_version" json:"version,omitempty"`
+	Digest    string    `gorm:"COLUMN:digest;TYPE:VARCHAR(64);NOT NULL" json:"digest,omitempty"`
+	CreatedAt time.Time `gorm:"COLUMN:created_at;NOT NULL" json:"created_at,omitempty"`
 }
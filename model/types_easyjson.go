@@ -4,6 +4,7 @@ package model
 
 import (
 	json "encoding/json"
+	time "time"
 
 	easyjson "github.com/mailru/easyjson"
 	jlexer "github.com/mailru/easyjson/jlexer"
@@ -47,10 +48,56 @@ func easyjson6601e8cdDecodeGithubComWeibocomIpcModel(in *jlexer.Lexer, out *Post
 			out.Content = string(in.String())
 		case "content_type":
 			out.ContentType = uint8(in.Uint8())
+		case "store_type":
+			out.StoreType = uint8(in.Uint8())
+		case "key_owner":
+			out.KeyOwner = string(in.String())
+		case "wrapped_key":
+			out.WrappedKey = string(in.String())
 		case "keywords":
 			out.Keywords = string(in.String())
+		case "language":
+			out.Language = string(in.String())
+		case "simhash":
+			out.SimHash = int64(in.Int64())
+		case "media_hashes":
+			out.MediaHashes = string(in.String())
 		case "digest":
 			out.Digest = string(in.String())
+		case "normalized_digest":
+			out.NormalizedDigest = string(in.String())
+		case "version":
+			out.Version = int(in.Int())
+		case "retracted":
+			out.Retracted = bool(in.Bool())
+		case "retracted_at":
+			if in.IsNull() {
+				in.Skip()
+				out.RetractedAt = nil
+			} else {
+				if out.RetractedAt == nil {
+					out.RetractedAt = new(time.Time)
+				}
+				if data := in.Raw(); in.Ok() {
+					in.AddError((*out.RetractedAt).UnmarshalJSON(data))
+				}
+			}
+		case "tx_id":
+			out.TxID = string(in.String())
+		case "block_num":
+			out.BlockNum = uint32(in.Uint32())
+		case "block_time":
+			if in.IsNull() {
+				in.Skip()
+				out.BlockTime = nil
+			} else {
+				if out.BlockTime == nil {
+					out.BlockTime = new(time.Time)
+				}
+				if data := in.Raw(); in.Ok() {
+					in.AddError((*out.BlockTime).UnmarshalJSON(data))
+				}
+			}
 		case "created_at":
 			if data := in.Raw(); in.Ok() {
 				in.AddError((out.CreatedAt).UnmarshalJSON(data))
@@ -119,6 +166,36 @@ func easyjson6601e8cdEncodeGithubComWeibocomIpcModel(out *jwriter.Writer, in Pos
 		}
 		out.Uint8(uint8(in.ContentType))
 	}
+	if in.StoreType != 0 {
+		const prefix string = ",\"store_type\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Uint8(uint8(in.StoreType))
+	}
+	if in.KeyOwner != "" {
+		const prefix string = ",\"key_owner\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.KeyOwner))
+	}
+	if in.WrappedKey != "" {
+		const prefix string = ",\"wrapped_key\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.WrappedKey))
+	}
 	if in.Keywords != "" {
 		const prefix string = ",\"keywords\":"
 		if first {
@@ -129,6 +206,36 @@ func easyjson6601e8cdEncodeGithubComWeibocomIpcModel(out *jwriter.Writer, in Pos
 		}
 		out.String(string(in.Keywords))
 	}
+	if in.Language != "" {
+		const prefix string = ",\"language\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Language))
+	}
+	if in.SimHash != 0 {
+		const prefix string = ",\"simhash\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Int64(int64(in.SimHash))
+	}
+	if in.MediaHashes != "" {
+		const prefix string = ",\"media_hashes\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.MediaHashes))
+	}
 	if in.Digest != "" {
 		const prefix string = ",\"digest\":"
 		if first {
@@ -139,6 +246,76 @@ func easyjson6601e8cdEncodeGithubComWeibocomIpcModel(out *jwriter.Writer, in Pos
 		}
 		out.String(string(in.Digest))
 	}
+	if in.NormalizedDigest != "" {
+		const prefix string = ",\"normalized_digest\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.NormalizedDigest))
+	}
+	if in.Version != 0 {
+		const prefix string = ",\"version\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Int(int(in.Version))
+	}
+	if in.Retracted {
+		const prefix string = ",\"retracted\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Bool(bool(in.Retracted))
+	}
+	if in.RetractedAt != nil {
+		const prefix string = ",\"retracted_at\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Raw((*in.RetractedAt).MarshalJSON())
+	}
+	if in.TxID != "" {
+		const prefix string = ",\"tx_id\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.TxID))
+	}
+	if in.BlockNum != 0 {
+		const prefix string = ",\"block_num\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Uint32(uint32(in.BlockNum))
+	}
+	if in.BlockTime != nil {
+		const prefix string = ",\"block_time\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Raw((*in.BlockTime).MarshalJSON())
+	}
 	if true {
 		const prefix string = ",\"created_at\":"
 		if first {
@@ -333,6 +510,8 @@ func easyjson6601e8cdDecodeGithubComWeibocomIpcModel2(in *jlexer.Lexer, out *Acc
 			out.Company = string(in.String())
 		case "wif":
 			out.WIF = string(in.String())
+		case "public_key":
+			out.PublicKey = string(in.String())
 		case "created_at":
 			if data := in.Raw(); in.Ok() {
 				in.AddError((out.CreatedAt).UnmarshalJSON(data))
@@ -381,6 +560,16 @@ func easyjson6601e8cdEncodeGithubComWeibocomIpcModel2(out *jwriter.Writer, in Ac
 		}
 		out.String(string(in.WIF))
 	}
+	if in.PublicKey != "" {
+		const prefix string = ",\"public_key\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.PublicKey))
+	}
 	if true {
 		const prefix string = ",\"created_at\":"
 		if first {
@@ -417,3 +606,136 @@ func (v *Account) UnmarshalJSON(data []byte) error {
 func (v *Account) UnmarshalEasyJSON(l *jlexer.Lexer) {
 	easyjson6601e8cdDecodeGithubComWeibocomIpcModel2(l, v)
 }
+func easyjson6601e8cdDecodeGithubComWeibocomIpcModel3(in *jlexer.Lexer, out *PostVersion) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "dna":
+			out.DNA = string(in.String())
+		case "author":
+			out.Author = string(in.String())
+		case "mid":
+			out.MSGID = int64(in.Int64())
+		case "version":
+			out.Version = int(in.Int())
+		case "digest":
+			out.Digest = string(in.String())
+		case "created_at":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.CreatedAt).UnmarshalJSON(data))
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson6601e8cdEncodeGithubComWeibocomIpcModel3(out *jwriter.Writer, in PostVersion) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	if in.DNA != "" {
+		const prefix string = ",\"dna\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.DNA))
+	}
+	if in.Author != "" {
+		const prefix string = ",\"author\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Author))
+	}
+	if in.MSGID != 0 {
+		const prefix string = ",\"mid\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Int64(int64(in.MSGID))
+	}
+	if in.Version != 0 {
+		const prefix string = ",\"version\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Int(int(in.Version))
+	}
+	if in.Digest != "" {
+		const prefix string = ",\"digest\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Digest))
+	}
+	if true {
+		const prefix string = ",\"created_at\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Raw((in.CreatedAt).MarshalJSON())
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v PostVersion) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson6601e8cdEncodeGithubComWeibocomIpcModel3(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v PostVersion) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson6601e8cdEncodeGithubComWeibocomIpcModel3(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *PostVersion) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson6601e8cdDecodeGithubComWeibocomIpcModel3(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *PostVersion) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6601e8cdDecodeGithubComWeibocomIpcModel3(l, v)
+}
@@ -10,10 +10,17 @@ import (
 	"unsafe"
 )
 
+// digestSize is the size of the digests read by libsecp256k1.
+const digestSize = 32
+
 type secp256k1 struct {
 }
 
 func (s *secp256k1) Sign(privKeys [][]byte, digest []byte) ([][]byte, error) {
+	if len(digest) != digestSize {
+		return nil, ErrInvalidDigest
+	}
+
 	// Sign.
 	cDigest := C.CBytes(digest)
 	defer C.free(cDigest)
@@ -50,36 +57,15 @@ func (s *secp256k1) Sign(privKeys [][]byte, digest []byte) ([][]byte, error) {
 }
 
 func (s *secp256k1) Verify(pubKeys [][]byte, digest []byte, sigs [][]byte) (bool, error) {
-	cDigest := C.CBytes(digest)
-	defer C.free(cDigest)
-
-	// Make sure to free memory.
-	cSigs := make([]unsafe.Pointer, 0, len(sigs))
-	defer func() {
-		for _, cSig := range cSigs {
-			C.free(cSig)
-		}
-	}()
-
 	// Collect verified public keys.
 	pubKeysFound := make([][]byte, len(pubKeys))
 	for i, sig := range sigs {
-		recoverParameter := sig[0] - 27 - 4
-		sig = sig[1:]
-
-		cSig := C.CBytes(sig)
-		cSigs = append(cSigs, cSig)
-
-		var publicKey [33]byte
-
-		code := C.verify_recoverable_signature(
-			(*C.uchar)(cDigest),
-			(*C.uchar)(cSig),
-			(C.int)(recoverParameter),
-			(*C.uchar)(&publicKey[0]),
-		)
-		if code == 1 {
-			pubKeysFound[i] = publicKey[:]
+		if i >= len(pubKeysFound) {
+			break
+		}
+		publicKey, err := s.Recover(digest, sig)
+		if err == nil {
+			pubKeysFound[i] = publicKey
 		}
 	}
 
@@ -90,3 +76,37 @@ func (s *secp256k1) Verify(pubKeys [][]byte, digest []byte, sigs [][]byte) (bool
 	}
 	return true, nil
 }
+
+// Recover returns the compressed public key (33 bytes) which produced the
+// compact signature sig over digest.
+func (s *secp256k1) Recover(digest []byte, sig []byte) ([]byte, error) {
+	if len(digest) != digestSize {
+		return nil, ErrInvalidDigest
+	}
+	if len(sig) != 65 {
+		return nil, ErrInvalidSignature
+	}
+	if sig[0] < 27+4 || sig[0] > 27+4+3 {
+		return nil, ErrInvalidSignature
+	}
+	recoverParameter := sig[0] - 27 - 4
+
+	cDigest := C.CBytes(digest)
+	defer C.free(cDigest)
+
+	cSig := C.CBytes(sig[1:])
+	defer C.free(cSig)
+
+	var publicKey [33]byte
+
+	code := C.verify_recoverable_signature(
+		(*C.uchar)(cDigest),
+		(*C.uchar)(cSig),
+		(C.int)(recoverParameter),
+		(*C.uchar)(&publicKey[0]),
+	)
+	if code != 1 {
+		return nil, ErrInvalidSignature
+	}
+	return publicKey[:], nil
+}
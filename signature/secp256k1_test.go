@@ -24,6 +24,29 @@ func TestSecp256k1(t *testing.T) {
 	assert.True(t, pass, "verify signature")
 }
 
+func TestSecp256k1Deterministic(t *testing.T) {
+	s := &secp256k1{}
+	w, err := keys.DecodeWIF("5JWHY5DxTF6qN5grTtChDCYBmWHfY9zaSsw4CxEKN5eZpH9iBma")
+	require.NoError(t, err, "decode wif")
+	digest := sha256.Sum256([]byte("hello world"))
+
+	sigs, err := s.Sign([][]byte{w.PrivateKey().Serialize()}, digest[:])
+	require.NoError(t, err, "sign digest")
+	// a deeper stack leaves other data after the nonce counter.
+	var sign func(depth int) [][]byte
+	sign = func(depth int) [][]byte {
+		if depth > 0 {
+			return sign(depth - 1)
+		}
+		again, err := s.Sign([][]byte{w.PrivateKey().Serialize()}, digest[:])
+		require.NoError(t, err, "sign digest again")
+		return again
+	}
+	for _, depth := range []int{0, 10} {
+		assert.Equal(t, sigs, sign(depth), "same signature of the same digest and key")
+	}
+}
+
 func TestSecp256k1WIF(t *testing.T) {
 	// test by witness
 	s := &secp256k1{}
@@ -64,3 +87,32 @@ func TestSecp256k1PublicKey(t *testing.T) {
 	require.NoError(t, err, "verify digest")
 	assert.True(t, pass, "verify signature")
 }
+
+func TestRecover(t *testing.T) {
+	wifStr := "5JzpcbsNCu6Hpad1TYmudH4rj1A22SW9Zhb1ofBGHRZSp5poqAX"
+	w, err := keys.DecodeWIF(wifStr)
+	require.NoError(t, err, "decode wif:%s", wifStr)
+
+	digestArray := sha256.Sum256([]byte("hello world"))
+	digest := digestArray[:]
+	sigs, err := NewSignature().Sign([][]byte{w.PrivateKey().Serialize()}, digest)
+	require.NoError(t, err, "sign digest")
+
+	pubKey, err := Recover(digest, sigs[0])
+	require.NoError(t, err, "recover public key")
+	assert.Equal(t, w.PublicKey().String(), pubKey.String(), "recovered public key")
+
+	otherDigest := sha256.Sum256([]byte("hello blockchain"))
+	pubKey, err = Recover(otherDigest[:], sigs[0])
+	if err == nil {
+		assert.NotEqual(t, w.PublicKey().String(), pubKey.String(), "recover with another digest")
+	}
+
+	_, err = Recover(digest, sigs[0][1:])
+	assert.Equal(t, ErrInvalidSignature, err, "recover from truncated signature")
+
+	for _, d := range [][]byte{nil, digest[:31], append(digest, 0)} {
+		_, err = Recover(d, sigs[0])
+		assert.Equal(t, ErrInvalidDigest, err, "recover from %d-byte digest", len(d))
+	}
+}
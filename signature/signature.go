@@ -1,10 +1,38 @@
 package signature
 
+import (
+	"errors"
+
+	"github.com/weibocom/ipc/keys"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrInvalidDigest is returned if the digest is not a 32-byte sha256 hash.
+	ErrInvalidDigest = errors.New("invalid digest")
+)
+
 type Signature interface {
 	Sign(privKeys [][]byte, digest []byte) ([][]byte, error)
 	Verify(pubKeys [][]byte, digest []byte, sigs [][]byte) (bool, error)
+	Recover(digest []byte, sig []byte) ([]byte, error)
 }
 
 func NewSignature() Signature {
 	return &secp256k1{}
 }
+
+// Recover returns the public key whose private key produced sig over digest.
+// sig is the 65-byte compact signature produced by Sign.
+func Recover(digest []byte, sig []byte) (*keys.PublicKey, error) {
+	raw, err := NewSignature().Recover(digest, sig)
+	if err != nil {
+		return nil, err
+	}
+
+	var pubKey keys.PublicKey
+	if err := pubKey.FromBytes(raw); err != nil {
+		return nil, err
+	}
+	return &pubKey, nil
+}
@@ -35,11 +35,17 @@ int sign_transaction(
 ) {
 	secp256k1_context* ctx = secp256k1_context_create(SECP256K1_CONTEXT_SIGN);
 
-	int ndata = 1;
+	// The nonce function reads 32 bytes of extra data, so the counter is zero-padded
+	// to keep the signatures of the same digest and key deterministic.
+	unsigned char ndata[32];
+	int counter = 1;
 
 	while (1) {
+		memset(ndata, 0, sizeof(ndata));
+		memcpy(ndata, &counter, sizeof(counter));
+
 		// Sign the transaction.
-		if (!sign(ctx, digest, privkey, &ndata, signature, recid)) {
+		if (!sign(ctx, digest, privkey, ndata, signature, recid)) {
 			secp256k1_context_destroy(ctx);
 			return 0;
 		}
@@ -51,7 +57,7 @@ int sign_transaction(
 			break;
 		}
 
-		ndata++;
+		counter++;
 	}
 
 	secp256k1_context_destroy(ctx);
@@ -112,6 +112,15 @@ func (api *API) GetBlockHeaderRaw(blockNum uint32) (*json.RawMessage, error) {
 	return call.Raw(api.caller, APIID+".get_block_header", []uint32{blockNum})
 }
 
+func (api *API) GetBlockHeader(blockNum uint32) (*BlockHeader, error) {
+	var resp BlockHeader
+	if err := api.caller.Call(APIID+".get_block_header", []uint32{blockNum}, &resp); err != nil {
+		return nil, err
+	}
+	resp.Number = blockNum
+	return &resp, nil
+}
+
 func (api *API) GetBlockRaw(blockNum uint32) (*json.RawMessage, error) {
 	return call.Raw(api.caller, APIID+".get_block", []uint32{blockNum})
 }
@@ -238,6 +247,14 @@ func (api *API) GetAccountsRaw(accountNames []string) (*json.RawMessage, error)
 	return call.Raw(api.caller, APIID+".get_accounts", [][]string{accountNames})
 }
 
+func (api *API) GetAccounts(accountNames []string) ([]*Account, error) {
+	var resp []*Account
+	if err := api.caller.Call(APIID+".get_accounts", [][]string{accountNames}, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
 // XXX: Not sure about params.
 //func (api *API) GetAccountReferenceRaw(id string) (*json.RawMessage, error) {
 //	return call.Raw(api.caller, APIID + ".get_account_reference", []string{id})
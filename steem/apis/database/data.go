@@ -45,6 +45,28 @@ type DynamicGlobalProperties struct {
 	MaxVirtualBandwidth      *types.Int              `json:"max_virtual_bandwidth"`
 }
 
+type Account struct {
+	ID                *types.ID               `json:"id"`
+	Name              string                  `json:"name"`
+	Owner             *types.Authority        `json:"owner"`
+	Active            *types.Authority        `json:"active"`
+	Posting           *types.Authority        `json:"posting"`
+	MemoKey           string                  `json:"memo_key"`
+	JSONMetadata      string                  `json:"json_metadata"`
+	Created           *types.TimePointSeconds `json:"created"`
+	LastOwnerUpdate   *types.TimePointSeconds `json:"last_owner_update"`
+	LastAccountUpdate *types.TimePointSeconds `json:"last_account_update"`
+}
+
+type BlockHeader struct {
+	Number                uint32                  `json:"-"`
+	Timestamp             *types.TimePointSeconds `json:"timestamp"`
+	Witness               string                  `json:"witness"`
+	TransactionMerkleRoot string                  `json:"transaction_merkle_root"`
+	Previous              string                  `json:"previous"`
+	Extensions            [][]interface{}         `json:"extensions"`
+}
+
 type Block struct {
 	Number                uint32                  `json:"-"`
 	Timestamp             *types.TimePointSeconds `json:"timestamp"`
@@ -17,6 +17,19 @@ func RefBlockNum(blockNumber types.UInt32) types.UInt16 {
 	return types.UInt16(blockNumber)
 }
 
+// BlockNum returns the number of the block with the given ID.
+// The first 4 bytes of a block ID are the big endian encoded block number.
+func BlockNum(blockID string) (types.UInt32, error) {
+	rawBlockID, err := hex.DecodeString(blockID)
+	if err != nil {
+		return 0, errors.Wrapf(err, "networkbroadcast: failed to decode block ID: %v", blockID)
+	}
+	if len(rawBlockID) < 4 {
+		return 0, errors.Errorf("networkbroadcast: invalid block ID: %v", blockID)
+	}
+	return types.UInt32(binary.BigEndian.Uint32(rawBlockID[:4])), nil
+}
+
 func RefBlockPrefix(blockID string) (types.UInt32, error) {
 	// Block ID is hex-encoded.
 	rawBlockID, err := hex.DecodeString(blockID)
@@ -0,0 +1,23 @@
+package steem
+
+import (
+	"testing"
+
+	"github.com/weibocom/ipc/steem/types"
+)
+
+func TestBlockNum(t *testing.T) {
+	blockID := "00c9e1f9a8d8ae8ffa4f4b2e1f0e8b7ecd4a0c7b"
+
+	num, err := BlockNum(blockID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if num != types.UInt32(13230585) {
+		t.Errorf("expected block num 13230585, got %v", num)
+	}
+
+	if _, err := BlockNum("00c9"); err == nil {
+		t.Error("expected error for short block id")
+	}
+}
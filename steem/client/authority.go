@@ -0,0 +1,87 @@
+package client
+
+import (
+	"github.com/pkg/errors"
+	"github.com/weibocom/ipc/steem/transactions"
+	"github.com/weibocom/ipc/steem/types"
+)
+
+// AuthorityType represents which authority of an account is checked.
+type AuthorityType string
+
+const (
+	OwnerAuthority   AuthorityType = "owner"
+	ActiveAuthority  AuthorityType = "active"
+	PostingAuthority AuthorityType = "posting"
+)
+
+// GetAuthorities returns the authorities of the given type of the named accounts.
+func (c *Client) GetAuthorities(names []string, authType AuthorityType) (map[string]*types.Authority, error) {
+	accounts, err := c.Database.GetAccounts(names)
+	if err != nil {
+		return nil, err
+	}
+
+	auths := make(map[string]*types.Authority, len(accounts))
+	for _, acc := range accounts {
+		var auth *types.Authority
+		switch authType {
+		case OwnerAuthority:
+			auth = acc.Owner
+		case ActiveAuthority:
+			auth = acc.Active
+		case PostingAuthority:
+			auth = acc.Posting
+		default:
+			return nil, errors.Errorf("unknown authority type: %v", authType)
+		}
+		if auth != nil {
+			auths[acc.Name] = auth
+		}
+	}
+	return auths, nil
+}
+
+// GetAuthority returns the authority of the given type of the named account.
+func (c *Client) GetAuthority(name string, authType AuthorityType) (*types.Authority, error) {
+	auths, err := c.GetAuthorities([]string{name}, authType)
+	if err != nil {
+		return nil, err
+	}
+	auth, ok := auths[name]
+	if !ok {
+		return nil, errors.Errorf("account not found: %v", name)
+	}
+	return auth, nil
+}
+
+// AuthorityFetcher resolves account_auths through database_api.get_accounts.
+// Like steemd, account authorities of owner and active authorities are
+// satisfied by the active authority of those accounts, and posting by posting.
+func (c *Client) AuthorityFetcher(authType AuthorityType) transactions.AuthorityFetcher {
+	if authType == OwnerAuthority {
+		authType = ActiveAuthority
+	}
+	return func(names []string) (map[string]*types.Authority, error) {
+		return c.GetAuthorities(names, authType)
+	}
+}
+
+// SignWithAuthority signs stx with the keys required by the given authority of account,
+// and reports whether the authority is satisfied by all signatures collected so far.
+func (c *Client) SignWithAuthority(stx *transactions.SignedTransaction, account string, authType AuthorityType, privateKeys [][]byte, chainID string) (bool, error) {
+	auth, err := c.GetAuthority(account, authType)
+	if err != nil {
+		return false, err
+	}
+	return stx.SignWithAuthority(auth, privateKeys, chainID, c.AuthorityFetcher(authType))
+}
+
+// VerifyAuthority reports whether the signatures of stx satisfy the given authority of account.
+func (c *Client) VerifyAuthority(stx *transactions.SignedTransaction, account string, authType AuthorityType, chainID string) (bool, error) {
+	auth, err := c.GetAuthority(account, authType)
+	if err != nil {
+		return false, err
+	}
+	return stx.VerifyAuthority(auth, chainID, c.AuthorityFetcher(authType))
+}
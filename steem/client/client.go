@@ -1,9 +1,13 @@
 package client
 
 import (
-	// RPC
+	// Stdlib
+	"sync"
+	"time"
 
+	// RPC
 	"github.com/weibocom/ipc/interfaces"
+	"github.com/weibocom/ipc/steem"
 	"github.com/weibocom/ipc/steem/apis/condenser"
 	"github.com/weibocom/ipc/steem/apis/database"
 	"github.com/weibocom/ipc/steem/apis/follow"
@@ -32,11 +36,25 @@ type Client struct {
 
 	// Condenser represents condenser_api.
 	Condenser *condenser.API
+
+	expiration            time.Duration
+	irreversibleReference bool
+	propsTTL              time.Duration
+	maxRebuilds           int
+
+	propsMu        sync.Mutex
+	props          *database.DynamicGlobalProperties
+	propsFetchedAt time.Time
 }
 
 // NewClient creates a new RPC client that use the given CallCloser internally.
-func NewClient(cc interfaces.CallCloser) (*Client, error) {
-	client := &Client{cc: cc}
+func NewClient(cc interfaces.CallCloser, options ...Option) (*Client, error) {
+	client := &Client{cc: decodingCaller{cc}}
+	defaultOptions(client)
+	for _, opt := range options {
+		opt(client)
+	}
+
 	client.Login = login.NewAPI(client.cc)
 	client.Database = database.NewAPI(client.cc)
 
@@ -66,3 +84,13 @@ func NewClient(cc interfaces.CallCloser) (*Client, error) {
 func (c *Client) Close() error {
 	return c.cc.Close()
 }
+
+// decodingCaller decodes the steemd errors returned by calls into *steem.RPCError,
+// so they can be checked with errors.Is, e.g. errors.Is(err, steem.ErrExpired).
+type decodingCaller struct {
+	interfaces.CallCloser
+}
+
+func (c decodingCaller) Call(method string, params, response interface{}) error {
+	return steem.DecodeError(c.CallCloser.Call(method, params, response))
+}
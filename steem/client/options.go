@@ -0,0 +1,53 @@
+package client
+
+import (
+	"time"
+
+	"github.com/weibocom/ipc/steem/transactions"
+)
+
+// Option represents an option that can be passed into the client constructor.
+type Option func(*Client)
+
+// SetExpiration sets how long the created transactions stay valid.
+//
+// The default value is 30 seconds.
+func SetExpiration(expiration time.Duration) Option {
+	return func(c *Client) {
+		c.expiration = expiration
+	}
+}
+
+// SetIrreversibleReference can be used to reference the last irreversible block
+// instead of the head block in created transactions (TaPoS).
+// Transactions referencing an irreversible block are not dropped on forks.
+func SetIrreversibleReference(enabled bool) Option {
+	return func(c *Client) {
+		c.irreversibleReference = enabled
+	}
+}
+
+// SetPropertiesCacheTTL sets how long the dynamic global properties used to
+// create transactions are cached. A block is produced every 3 seconds,
+// so the ttl should be kept short.
+//
+// The default value is 0, which fetches the properties for every transaction.
+func SetPropertiesCacheTTL(ttl time.Duration) Option {
+	return func(c *Client) {
+		c.propsTTL = ttl
+	}
+}
+
+// SetMaxRebuilds sets how many times a transaction failed with an expiration
+// or TaPoS error is rebuilt, re-signed and broadcast again.
+//
+// The default value is 0, which disables rebuilding.
+func SetMaxRebuilds(n int) Option {
+	return func(c *Client) {
+		c.maxRebuilds = n
+	}
+}
+
+func defaultOptions(c *Client) {
+	c.expiration = transactions.DefaultExpiration
+}
@@ -55,6 +55,32 @@ func CreateCommentOperation(authorname, title, body, permlink, ptag, postImage s
 	}
 }
 
+// ReplyAsync adds a reply to the post of parentAuthor and parentPermlink.
+func (c *Client) ReplyAsync(privateKeys [][]byte, authorname, parentAuthor, parentPermlink, body, permlink string) error {
+	op := CreateReplyOperation(authorname, parentAuthor, parentPermlink, body, permlink)
+	return c.SendTrxAsync(privateKeys, op)
+}
+
+// CreateReplyOperation creates a CommentOperation which replies to the post of parentAuthor and parentPermlink.
+func CreateReplyOperation(authorname, parentAuthor, parentPermlink, body, permlink string) *types.CommentOperation {
+	return &types.CommentOperation{
+		ParentAuthor:   parentAuthor,
+		ParentPermlink: translit.EncodeTitle(parentPermlink),
+		Author:         authorname,
+		Permlink:       translit.EncodeTitle(permlink),
+		Body:           body,
+		JsonMetadata:   `{"lib": "go-steem-rpc"}`,
+	}
+}
+
+// Reply adds a reply to the post of parentAuthor and parentPermlink, and waits until it is
+// included in a block.
+func (c *Client) Reply(privateKeys [][]byte, authorname, parentAuthor, parentPermlink, body, permlink string) (bool, error) {
+	op := CreateReplyOperation(authorname, parentAuthor, parentPermlink, body, permlink)
+	_, err := c.SendTrx(privateKeys, op)
+	return err == nil, err
+}
+
 func (c *Client) BatchPost(privateKeys [][]byte, ops []types.Operation) (bool, error) {
 	_, err := c.SendTrx(privateKeys, ops...)
 	return err == nil, err
@@ -4,7 +4,16 @@ import (
 	"errors"
 	"time"
 
+	"github.com/weibocom/ipc/chain"
 	"github.com/weibocom/ipc/interfaces"
+	"github.com/weibocom/ipc/steem/types"
+)
+
+const (
+	// blockInterval is the time between two blocks, blocks of missed slots are skipped.
+	blockInterval = 3 * time.Second
+	// anchorBlocks is the number of blocks searched by Anchor for the transaction of a post.
+	anchorBlocks = 100
 )
 
 type AsyncPostCall struct {
@@ -25,8 +34,8 @@ type Steem struct {
 	done              chan struct{}
 }
 
-func NewSteemClient(cc interfaces.CallCloser, submitter string, privateKey []byte, company string) *Steem {
-	steem, err := NewClient(cc)
+func NewSteemClient(cc interfaces.CallCloser, submitter string, privateKey []byte, company string, options ...Option) *Steem {
+	steem, err := NewClient(cc, options...)
 	if err != nil {
 		panic(err)
 	}
@@ -75,7 +84,29 @@ func (s *Steem) Post(dna string) error {
 	if err != nil {
 		return err
 	}
+	return s.wait(dna)
+}
+
+// Update posts newDNA as a reply to the post of dna, so that the versions of a post
+// are linked on chain.
+func (s *Steem) Update(dna string, newDNA string) error {
+	err := s.steem.ReplyAsync([][]byte{s.privateKey}, s.submitter, s.submitter, dna, newDNA, newDNA)
+	if err != nil {
+		return err
+	}
+	return s.wait(newDNA)
+}
 
+// Retract posts a tombstone reply to the post of dna. The post is not deleted, as it is the
+// proof of the registration, and the chain refuses to delete the posts with replies or votes.
+func (s *Steem) Retract(dna string) error {
+	body := `{"retracted":"` + dna + `"}`
+	_, err := s.steem.Reply([][]byte{s.privateKey}, s.submitter, s.submitter, dna, body, "retract-"+dna)
+	return err
+}
+
+// wait waits until the post of dna can be verified.
+func (s *Steem) wait(dna string) error {
 	call := &AsyncPostCall{
 		DNA:         dna,
 		MaxWaitTime: 30 * time.Second,
@@ -105,6 +136,64 @@ func (s *Steem) Verify(dna string) error {
 	return nil
 }
 
+var _ chain.Anchorer = &Steem{}
+
+// Anchor returns the block time of the post of dna, which is the time it is created on chain,
+// and the transaction and the block of its comment operation if they are found in the
+// anchorBlocks blocks from the one estimated by the time.
+func (s *Steem) Anchor(dna string) (*chain.Anchor, error) {
+	content, err := s.steem.Condenser.GetContent(s.submitter, dna)
+	if err != nil {
+		return nil, err
+	}
+	// the content of a missing post is empty.
+	if content == nil || content.Author == "" || content.Created == nil || content.Created.Time == nil {
+		return nil, chain.ErrNotAnchored
+	}
+
+	a := &chain.Anchor{BlockTime: *content.Created.Time}
+	a.TxID, a.BlockNum, err = s.findComment(dna, a.BlockTime)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// findComment returns the transaction and the block of the comment operation of the post of dna
+// created at blockTime, or an empty transaction if it is not found.
+func (s *Steem) findComment(dna string, blockTime time.Time) (string, uint32, error) {
+	props, err := s.steem.Database.GetDynamicGlobalProperties()
+	if err != nil {
+		return "", 0, err
+	}
+	if props.Time == nil || props.Time.Time == nil {
+		return "", 0, nil
+	}
+
+	// blocks of missed slots make the post in a later block than estimated.
+	head := uint32(props.HeadBlockNumber)
+	behind := uint32(props.Time.Sub(blockTime) / blockInterval)
+	if behind >= head {
+		return "", 0, nil
+	}
+	for num := head - behind; num <= head && num < head-behind+anchorBlocks; num++ {
+		ops, err := s.steem.Database.GetOpsInBlock(num, false)
+		if err != nil {
+			return "", 0, err
+		}
+		for _, op := range ops {
+			if op.Timestamp != nil && op.Timestamp.Time != nil && op.Timestamp.After(blockTime) {
+				return "", 0, nil
+			}
+			comment, ok := op.Operation.(*types.CommentOperation)
+			if ok && comment.Author == s.submitter && comment.Permlink == dna {
+				return op.TransactionID, num, nil
+			}
+		}
+	}
+	return "", 0, nil
+}
+
 func (s *Steem) Close() error {
 	close(s.done)
 	return s.steem.Close()
@@ -2,27 +2,81 @@ package client
 
 import (
 	"log"
+	"time"
 
 	"github.com/weibocom/ipc/config"
 	"github.com/weibocom/ipc/steem"
+	"github.com/weibocom/ipc/steem/apis/database"
 	"github.com/weibocom/ipc/steem/apis/networkbroadcast"
 	"github.com/weibocom/ipc/steem/transactions"
 	"github.com/weibocom/ipc/steem/types"
+
+	"github.com/pkg/errors"
 )
 
-func (c *Client) CreateTransaction() (*types.Transaction, error) {
+// dynamicGlobalProperties returns the cached dynamic global properties,
+// and fetches them when the cache is disabled or expired.
+func (c *Client) dynamicGlobalProperties() (*database.DynamicGlobalProperties, error) {
+	if c.propsTTL <= 0 {
+		return c.Database.GetDynamicGlobalProperties()
+	}
+
+	c.propsMu.Lock()
+	defer c.propsMu.Unlock()
+
+	if c.props != nil && time.Since(c.propsFetchedAt) < c.propsTTL {
+		return c.props, nil
+	}
+
 	props, err := c.Database.GetDynamicGlobalProperties()
 	if err != nil {
 		return nil, err
 	}
-	refBlockPrefix, err := steem.RefBlockPrefix(props.HeadBlockID)
+	c.props = props
+	c.propsFetchedAt = time.Now()
+	return props, nil
+}
+
+func (c *Client) invalidateProperties() {
+	c.propsMu.Lock()
+	c.props = nil
+	c.propsMu.Unlock()
+}
+
+// referenceBlock returns the number and id of the block referenced by new transactions.
+func (c *Client) referenceBlock() (types.UInt32, string, error) {
+	props, err := c.dynamicGlobalProperties()
+	if err != nil {
+		return 0, "", err
+	}
+
+	lib := props.LastIrreversibleBlockNum
+	if !c.irreversibleReference || lib == 0 || types.UInt32(lib) >= props.HeadBlockNumber {
+		return props.HeadBlockNumber, props.HeadBlockID, nil
+	}
+
+	// the id of the last irreversible block is the previous id of the next block.
+	header, err := c.Database.GetBlockHeader(lib + 1)
+	if err != nil {
+		return 0, "", err
+	}
+	return types.UInt32(lib), header.Previous, nil
+}
+
+func (c *Client) CreateTransaction() (*types.Transaction, error) {
+	num, id, err := c.referenceBlock()
+	if err != nil {
+		return nil, err
+	}
+	refBlockPrefix, err := steem.RefBlockPrefix(id)
 	if err != nil {
 		return nil, err
 	}
 
 	tx := &types.Transaction{
-		RefBlockNum:    steem.RefBlockNum(props.HeadBlockNumber),
+		RefBlockNum:    steem.RefBlockNum(num),
 		RefBlockPrefix: refBlockPrefix,
+		Expiration:     types.NewTimePointSeconds(time.Now().Add(c.expiration)),
 	}
 	return tx, nil
 }
@@ -35,8 +89,7 @@ func (c *Client) CreateSignedTransaction(creator string, name string, fee int, j
 	return transactions.NewSignedTransaction(tx), nil
 }
 
-// SendTrx signs and sends transactions.
-func (c *Client) SendTrx(privateKeys [][]byte, operations ...types.Operation) (resp *networkbroadcast.BroadcastResponse, err error) {
+func (c *Client) buildTrx(privateKeys [][]byte, operations []types.Operation) (*transactions.SignedTransaction, error) {
 	tx, err := c.CreateTransaction()
 	if err != nil {
 		return nil, err
@@ -51,8 +104,40 @@ func (c *Client) SendTrx(privateKeys [][]byte, operations ...types.Operation) (r
 		log.Printf("transaction sig err:%v\n", err.Error())
 		return nil, err
 	}
+	return stx, nil
+}
+
+// isRebuildableError reports whether the transaction was rejected because
+// it is expired or references an unknown block, so that it can be rebuilt.
+func isRebuildableError(err error) bool {
+	return errors.Is(err, steem.ErrExpired) || errors.Is(err, steem.ErrInvalidTaPoS)
+}
+
+// broadcastTrx builds, signs and broadcasts the transaction, and rebuilds it
+// at most maxRebuilds times when it fails with an expiration or TaPoS error.
+func (c *Client) broadcastTrx(privateKeys [][]byte, operations []types.Operation, broadcast func(tx *types.Transaction) error) error {
+	for i := 0; ; i++ {
+		stx, err := c.buildTrx(privateKeys, operations)
+		if err != nil {
+			return err
+		}
 
-	resp, err = c.NetworkBroadcast.BroadcastTransactionSynchronous(stx.Transaction)
+		err = broadcast(stx.Transaction)
+		if err == nil || i >= c.maxRebuilds || !isRebuildableError(err) {
+			return err
+		}
+
+		log.Printf("rebuild transaction after broadcast failed:%v\n", err.Error())
+		c.invalidateProperties()
+	}
+}
+
+// SendTrx signs and sends transactions.
+func (c *Client) SendTrx(privateKeys [][]byte, operations ...types.Operation) (resp *networkbroadcast.BroadcastResponse, err error) {
+	err = c.broadcastTrx(privateKeys, operations, func(tx *types.Transaction) error {
+		resp, err = c.NetworkBroadcast.BroadcastTransactionSynchronous(tx)
+		return err
+	})
 	if err != nil {
 		log.Printf("broadcast failed:%v\n", err.Error())
 		return nil, err
@@ -62,22 +147,7 @@ func (c *Client) SendTrx(privateKeys [][]byte, operations ...types.Operation) (r
 }
 
 func (c *Client) SendTrxAsync(privateKeys [][]byte, operations ...types.Operation) error {
-	tx, err := c.CreateTransaction()
-	if err != nil {
-		return err
-	}
-	stx := transactions.NewSignedTransaction(tx)
-
-	for _, op := range operations {
-		stx.PushOperation(op)
-	}
-
-	if err := stx.Sign(privateKeys, config.GetChainID()); err != nil {
-		log.Printf("transaction sig err:%v\n", err.Error())
-		return err
-	}
-
-	err = c.NetworkBroadcast.BroadcastTransaction(stx.Transaction)
+	err := c.broadcastTrx(privateKeys, operations, c.NetworkBroadcast.BroadcastTransaction)
 	if err != nil {
 		log.Printf("broadcast async failed:%v\n", err.Error())
 	}
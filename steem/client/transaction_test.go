@@ -0,0 +1,106 @@
+package client
+
+import (
+	// Stdlib
+	"encoding/json"
+	"testing"
+	"time"
+
+	// RPC
+	"github.com/weibocom/ipc/keys"
+	"github.com/weibocom/ipc/steem"
+	"github.com/weibocom/ipc/steem/types"
+
+	// Vendor
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testHeadBlockID = "00000064a8d8ae8ffa4f4b2e1f0e8b7ecd4a0c7b"
+	testLIBBlockID  = "0000005a1122334455667788990011223344aabb"
+)
+
+// fakeCaller answers the database and broadcast calls used to send transactions.
+type fakeCaller struct {
+	calls          map[string]int
+	broadcastFails int
+	broadcasted    []*types.Transaction
+}
+
+func newFakeCaller() *fakeCaller {
+	return &fakeCaller{calls: make(map[string]int)}
+}
+
+func (f *fakeCaller) Call(method string, params, response interface{}) error {
+	f.calls[method]++
+
+	var data string
+	switch method {
+	case "database_api.get_dynamic_global_properties":
+		data = `{"head_block_number":100,"head_block_id":"` + testHeadBlockID + `","last_irreversible_block_num":90}`
+	case "database_api.get_block_header":
+		data = `{"previous":"` + testLIBBlockID + `"}`
+	case "network_broadcast_api.broadcast_transaction_synchronous":
+		if f.broadcastFails > 0 {
+			f.broadcastFails--
+			data := json.RawMessage(`{"code":3020000,"name":"transaction_tapos_exception","message":"transaction tapos exception","stack":[]}`)
+			return errors.Wrap(&jsonrpc2.Error{Code: -32000, Message: "transaction tapos exception", Data: &data}, "call failed")
+		}
+		f.broadcasted = append(f.broadcasted, params.(map[string]interface{})["trx"].(*types.Transaction))
+		data = `{"id":"1","block_num":101}`
+	default:
+		return errors.Errorf("unexpected call: %s", method)
+	}
+	return json.Unmarshal([]byte(data), response)
+}
+
+func (f *fakeCaller) Close() error {
+	return nil
+}
+
+func TestCreateTransactionOptions(t *testing.T) {
+	f := newFakeCaller()
+	c, err := NewClient(f, SetExpiration(time.Minute), SetIrreversibleReference(true), SetPropertiesCacheTTL(time.Minute))
+	require.NoError(t, err, "new client")
+
+	tx, err := c.CreateTransaction()
+	require.NoError(t, err, "create transaction")
+
+	prefix, err := steem.RefBlockPrefix(testLIBBlockID)
+	require.NoError(t, err, "ref block prefix")
+	assert.Equal(t, types.UInt16(90), tx.RefBlockNum, "references the last irreversible block")
+	assert.Equal(t, prefix, tx.RefBlockPrefix, "references the last irreversible block")
+	assert.WithinDuration(t, time.Now().Add(time.Minute), *tx.Expiration.Time, 2*time.Second, "expiration")
+
+	_, err = c.CreateTransaction()
+	require.NoError(t, err, "create transaction")
+	assert.Equal(t, 1, f.calls["database_api.get_dynamic_global_properties"], "properties are cached")
+}
+
+func TestSendTrxRebuild(t *testing.T) {
+	w, err := keys.DecodeWIF("5JWHY5DxTF6qN5grTtChDCYBmWHfY9zaSsw4CxEKN5eZpH9iBma")
+	require.NoError(t, err, "decode wif")
+	privKeys := [][]byte{w.PrivateKey().Serialize()}
+	op := &types.TransferOperation{From: "alice", To: "bob", Amount: types.NewSteemAsset(1)}
+
+	f := newFakeCaller()
+	f.broadcastFails = 1
+	c, err := NewClient(f)
+	require.NoError(t, err, "new client")
+	_, err = c.SendTrx(privKeys, op)
+	assert.Error(t, err, "rebuild is disabled by default")
+
+	f = newFakeCaller()
+	f.broadcastFails = 1
+	c, err = NewClient(f, SetMaxRebuilds(1), SetPropertiesCacheTTL(time.Minute))
+	require.NoError(t, err, "new client")
+	resp, err := c.SendTrx(privKeys, op)
+	require.NoError(t, err, "send transaction")
+	assert.Equal(t, uint32(101), resp.BlockNum)
+	assert.Len(t, f.broadcasted, 1)
+	assert.Len(t, f.broadcasted[0].Signatures, 1, "rebuilt transaction is signed")
+	assert.Equal(t, 2, f.calls["database_api.get_dynamic_global_properties"], "properties are fetched again on rebuild")
+}
@@ -0,0 +1,312 @@
+// txtool builds, signs and broadcasts steem transactions.
+//
+// It can work offline: the TaPoS fields are derived from a head block id
+// given by -head-block-id, and the signed transaction can be broadcast later
+// from another machine.
+//
+//	txtool build -from alice -to bob -amount 1 -head-block-id <id> -wifs wifs.txt -out signed.json
+//	txtool build -tx ops.json -node ws://127.0.0.1:8090 -wifs wifs.txt
+//	txtool sign -tx unsigned.json -wifs wifs.txt -out signed.json
+//	txtool broadcast -tx signed.json -node ws://127.0.0.1:8090
+package main
+
+import (
+	// Stdlib
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	// RPC
+	"github.com/weibocom/ipc/config"
+	"github.com/weibocom/ipc/keys"
+	"github.com/weibocom/ipc/steem"
+	"github.com/weibocom/ipc/steem/client"
+	"github.com/weibocom/ipc/steem/transactions"
+	"github.com/weibocom/ipc/steem/types"
+	"github.com/weibocom/ipc/transports/websocket"
+
+	// Vendor
+	"github.com/pkg/errors"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `usage: txtool <command> [flags]
+
+commands:
+  build      build a transaction and sign it if -wifs is given
+  sign       sign a transaction loaded from -tx
+  broadcast  broadcast a signed transaction loaded from -tx
+
+run "txtool <command> -h" for the flags of a command.
+`)
+	os.Exit(2)
+}
+
+func main() {
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "build":
+		err = build(os.Args[2:])
+	case "sign":
+		err = sign(os.Args[2:])
+	case "broadcast":
+		err = broadcast(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func build(args []string) error {
+	fs := flag.NewFlagSet("build", flag.ExitOnError)
+	txFile := fs.String("tx", "", "transaction or operations json file. operations are given like [[\"transfer\",{...}]]")
+	from := fs.String("from", "", "transfer from")
+	to := fs.String("to", "", "transfer to")
+	amount := fs.Int64("amount", 0, "transfer amount")
+	memo := fs.String("memo", "", "transfer memo")
+	headBlockID := fs.String("head-block-id", "", "reference block id, used to build the transaction offline")
+	node := fs.String("node", "", "blockchain rpc server address, used to get the reference block when -head-block-id is not set")
+	expiration := fs.Duration("expiration", 30*time.Second, "transaction expiration")
+	wifs := fs.String("wifs", "", "file with one wif per line. the transaction is signed if set")
+	chainID := fs.String("chain-id", config.GetChainID(), "chain id")
+	out := fs.String("out", "", "file to write the transaction json to")
+	fs.Parse(args)
+
+	tx := &types.Transaction{}
+	if *txFile != "" {
+		var err error
+		if tx, err = loadTransaction(*txFile); err != nil {
+			return err
+		}
+	}
+	if *from != "" || *to != "" {
+		tx.PushOperation(&types.TransferOperation{
+			From:   *from,
+			To:     *to,
+			Amount: types.NewSteemAsset(*amount),
+			Memo:   *memo,
+		})
+	}
+	if len(tx.Operations) == 0 {
+		return errors.New("no operation specified, use -tx or -from/-to/-amount")
+	}
+
+	switch {
+	case *headBlockID != "":
+		num, err := steem.BlockNum(*headBlockID)
+		if err != nil {
+			return err
+		}
+		prefix, err := steem.RefBlockPrefix(*headBlockID)
+		if err != nil {
+			return err
+		}
+		tx.RefBlockNum = steem.RefBlockNum(num)
+		tx.RefBlockPrefix = prefix
+	case *node != "":
+		c, err := newClient(*node)
+		if err != nil {
+			return err
+		}
+		ref, err := c.CreateTransaction()
+		c.Close()
+		if err != nil {
+			return err
+		}
+		tx.RefBlockNum = ref.RefBlockNum
+		tx.RefBlockPrefix = ref.RefBlockPrefix
+	case tx.RefBlockNum == 0 && tx.RefBlockPrefix == 0:
+		return errors.New("no reference block, use -head-block-id or -node")
+	}
+
+	if tx.Expiration == nil || isFlagSet(fs, "expiration") {
+		tx.Expiration = types.NewTimePointSeconds(time.Now().Add(*expiration))
+	}
+
+	stx := transactions.NewSignedTransaction(tx)
+	if *wifs != "" {
+		if err := signWithFile(stx, *wifs, *chainID); err != nil {
+			return err
+		}
+	}
+	return output(stx, *chainID, *out)
+}
+
+func sign(args []string) error {
+	fs := flag.NewFlagSet("sign", flag.ExitOnError)
+	txFile := fs.String("tx", "", "transaction json file")
+	wifs := fs.String("wifs", "", "file with one wif per line")
+	chainID := fs.String("chain-id", config.GetChainID(), "chain id")
+	out := fs.String("out", "", "file to write the signed transaction json to")
+	fs.Parse(args)
+
+	if *txFile == "" || *wifs == "" {
+		return errors.New("-tx and -wifs are required")
+	}
+
+	tx, err := loadTransaction(*txFile)
+	if err != nil {
+		return err
+	}
+	stx := transactions.NewSignedTransaction(tx)
+	if err := signWithFile(stx, *wifs, *chainID); err != nil {
+		return err
+	}
+	return output(stx, *chainID, *out)
+}
+
+func broadcast(args []string) error {
+	fs := flag.NewFlagSet("broadcast", flag.ExitOnError)
+	txFile := fs.String("tx", "", "signed transaction json file")
+	node := fs.String("node", "", "blockchain rpc server address")
+	fs.Parse(args)
+
+	if *txFile == "" || *node == "" {
+		return errors.New("-tx and -node are required")
+	}
+
+	tx, err := loadTransaction(*txFile)
+	if err != nil {
+		return err
+	}
+	if len(tx.Signatures) == 0 {
+		return errors.New("transaction is not signed")
+	}
+
+	c, err := newClient(*node)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	resp, err := c.NetworkBroadcast.BroadcastTransactionSynchronous(tx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("id:    %s\nblock: %d\n", resp.ID, resp.BlockNum)
+	return nil
+}
+
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func newClient(node string) (*client.Client, error) {
+	tran, err := websocket.NewTransport([]string{node})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to new transport to %s", node)
+	}
+	c, err := client.NewClient(tran)
+	if err != nil {
+		tran.Close()
+		return nil, errors.Wrap(err, "failed to new client")
+	}
+	return c, nil
+}
+
+// loadTransaction reads a transaction json file.
+// A json array is treated as the operations of a new transaction.
+func loadTransaction(file string) (*types.Transaction, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := &types.Transaction{}
+	if s := strings.TrimSpace(string(data)); strings.HasPrefix(s, "[") {
+		err = json.Unmarshal(data, &tx.Operations)
+	} else {
+		err = json.Unmarshal(data, tx)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode %s", file)
+	}
+	return tx, nil
+}
+
+func loadWIFs(file string) ([][]byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var privKeys [][]byte
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		w, err := keys.DecodeWIF(line)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decode wif")
+		}
+		privKeys = append(privKeys, w.PrivateKey().Serialize())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(privKeys) == 0 {
+		return nil, errors.Errorf("no wif found in %s", file)
+	}
+	return privKeys, nil
+}
+
+func signWithFile(stx *transactions.SignedTransaction, file string, chainID string) error {
+	privKeys, err := loadWIFs(file)
+	if err != nil {
+		return err
+	}
+	return stx.AppendSign(privKeys, chainID)
+}
+
+func output(stx *transactions.SignedTransaction, chainID string, out string) error {
+	digest, err := stx.Digest(chainID)
+	if err != nil {
+		return err
+	}
+	id, err := stx.ID()
+	if err != nil {
+		return err
+	}
+	raw, err := stx.Serialize()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(stx.Transaction, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("digest: %s\n", hex.EncodeToString(digest))
+	fmt.Printf("id:     %s\n", id)
+	fmt.Printf("hex:    %s\n", hex.EncodeToString(raw))
+	fmt.Printf("json:\n%s\n", data)
+
+	if out != "" {
+		return ioutil.WriteFile(out, data, 0600)
+	}
+	return nil
+}
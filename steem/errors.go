@@ -0,0 +1,127 @@
+package steem
+
+import (
+	// Stdlib
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	// Vendor
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// Errors returned by steemd. They can be checked with errors.Is against
+// the error returned by a call, e.g. errors.Is(err, steem.ErrExpired).
+var (
+	ErrDuplicateTransaction  = errors.New("duplicate transaction")
+	ErrMissingAuthority      = errors.New("missing required authority")
+	ErrInsufficientBandwidth = errors.New("insufficient bandwidth")
+	ErrExpired               = errors.New("transaction expired")
+	ErrInvalidTaPoS          = errors.New("invalid reference block")
+	ErrAccountExists         = errors.New("account already exists")
+)
+
+// the fc exception names and messages which identify each error.
+var rpcErrorPatterns = []struct {
+	err      error
+	names    []string
+	messages []string
+}{
+	{ErrDuplicateTransaction, []string{"duplicate_transaction"}, []string{"duplicate transaction check failed"}},
+	{ErrMissingAuthority, []string{"tx_missing_active_auth", "tx_missing_owner_auth", "tx_missing_posting_auth", "tx_missing_other_auth"}, []string{"missing active authority", "missing owner authority", "missing posting authority", "missing authority"}},
+	{ErrInsufficientBandwidth, nil, []string{"bandwidth limit exceeded"}},
+	{ErrExpired, []string{"transaction_expiration_exception"}, []string{"now < trx.expiration"}},
+	{ErrInvalidTaPoS, []string{"transaction_tapos_exception"}, []string{"tapos_block_summary"}},
+	{ErrAccountExists, nil, []string{"most likely a uniqueness constraint was violated", "account name already exists"}},
+}
+
+// RPCError is the fc exception carried in the data of a steemd JSON-RPC error.
+type RPCError struct {
+	// Code and Message of the JSON-RPC error.
+	RPCCode    int64
+	RPCMessage string
+
+	// fc exception
+	Code    int64             `json:"code"`
+	Name    string            `json:"name"`
+	Message string            `json:"message"`
+	Stack   []*RPCErrorRecord `json:"stack"`
+
+	err error
+}
+
+// RPCErrorRecord is one frame of the fc exception stack.
+type RPCErrorRecord struct {
+	Context struct {
+		Level     string `json:"level"`
+		File      string `json:"file"`
+		Line      int    `json:"line"`
+		Method    string `json:"method"`
+		Timestamp string `json:"timestamp"`
+	} `json:"context"`
+	Format string                 `json:"format"`
+	Data   map[string]interface{} `json:"data"`
+}
+
+// DecodeError decodes the fc exception of the JSON-RPC error in err.
+// err is returned unchanged when it is not a JSON-RPC error.
+func DecodeError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var jerr *jsonrpc2.Error
+	if !errors.As(err, &jerr) {
+		return err
+	}
+
+	e := &RPCError{
+		RPCCode:    jerr.Code,
+		RPCMessage: jerr.Message,
+		err:        err,
+	}
+	if jerr.Data != nil {
+		// ignore the data which is not a fc exception.
+		json.Unmarshal(*jerr.Data, e)
+	}
+	return e
+}
+
+func (e *RPCError) Error() string {
+	if e.err != nil {
+		return e.err.Error()
+	}
+	return fmt.Sprintf("%s (%d): %s", e.Name, e.Code, e.RPCMessage)
+}
+
+// Unwrap returns the original error.
+func (e *RPCError) Unwrap() error {
+	return e.err
+}
+
+// Is reports whether e is the steemd error target.
+func (e *RPCError) Is(target error) bool {
+	text := strings.ToLower(e.RPCMessage + "\n" + e.Message)
+	for _, r := range e.Stack {
+		text += "\n" + strings.ToLower(r.Format)
+	}
+
+	for _, p := range rpcErrorPatterns {
+		if p.err != target {
+			continue
+		}
+		for _, name := range p.names {
+			if e.Name == name {
+				return true
+			}
+		}
+		for _, msg := range p.messages {
+			if strings.Contains(text, msg) {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package steem
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+func newRPCError(data string) error {
+	raw := json.RawMessage(data)
+	return errors.Wrap(&jsonrpc2.Error{Code: -32000, Message: "steemd error", Data: &raw}, "call failed")
+}
+
+func TestDecodeError(t *testing.T) {
+	cases := []struct {
+		data     string
+		expected error
+	}{
+		{`{"code":10,"name":"assert_exception","message":"Assert Exception","stack":[{"format":"(skip & skip_transaction_dupe_check) || trx_idx.indices().get<by_trx_id>().find(trx_id) == trx_idx.indices().get<by_trx_id>().end(): Duplicate transaction check failed"}]}`, ErrDuplicateTransaction},
+		{`{"code":3010000,"name":"tx_missing_posting_auth","message":"missing required posting authority","stack":[]}`, ErrMissingAuthority},
+		{`{"code":10,"name":"assert_exception","message":"Assert Exception","stack":[{"format":"Account: ${account} bandwidth limit exceeded. Please wait to transact or power up STEEM."}]}`, ErrInsufficientBandwidth},
+		{`{"code":3030000,"name":"transaction_expiration_exception","message":"transaction expiration exception","stack":[]}`, ErrExpired},
+		{`{"code":3020000,"name":"transaction_tapos_exception","message":"transaction tapos exception","stack":[]}`, ErrInvalidTaPoS},
+		{`{"code":13,"name":"N5boost16exception_detail10clone_implINS0_19error_info_injectorISt12out_of_rangeEEEE","message":"unknown key","stack":[{"format":"could not insert object, most likely a uniqueness constraint was violated"}]}`, ErrAccountExists},
+	}
+
+	all := []error{ErrDuplicateTransaction, ErrMissingAuthority, ErrInsufficientBandwidth, ErrExpired, ErrInvalidTaPoS, ErrAccountExists}
+
+	for i, c := range cases {
+		err := DecodeError(newRPCError(c.data))
+
+		var rpcErr *RPCError
+		if !errors.As(err, &rpcErr) {
+			t.Fatalf("case %d: expected *RPCError, got %T", i, err)
+		}
+		if rpcErr.Name == "" {
+			t.Errorf("case %d: fc exception is not decoded", i)
+		}
+
+		for _, target := range all {
+			if errors.Is(err, target) != (target == c.expected) {
+				t.Errorf("case %d: errors.Is(%v) = %v", i, target, !(target == c.expected))
+			}
+		}
+
+		var jerr *jsonrpc2.Error
+		if !errors.As(err, &jerr) {
+			t.Errorf("case %d: original error is not wrapped", i)
+		}
+	}
+
+	plain := errors.New("plain")
+	if DecodeError(plain) != plain {
+		t.Error("non JSON-RPC error should be returned unchanged")
+	}
+}
@@ -0,0 +1,257 @@
+package transactions
+
+import (
+	// Stdlib
+	"bytes"
+	"encoding/hex"
+
+	// RPC
+	"github.com/weibocom/ipc/keys"
+	"github.com/weibocom/ipc/signature"
+	"github.com/weibocom/ipc/steem/types"
+
+	// Vendor
+	"github.com/pkg/errors"
+)
+
+// MaxAuthorityDepth refered to `STEEMIT_MAX_SIG_CHECK_DEPTH`.
+// account_auths are resolved recursively at most this many levels.
+const MaxAuthorityDepth = 2
+
+// AuthorityFetcher returns the authorities of the given accounts.
+// It is used to resolve the account_auths of an authority.
+type AuthorityFetcher func(names []string) (map[string]*types.Authority, error)
+
+func keyID(key types.PublicKey) string {
+	return string(key.Bytes())
+}
+
+// RequiredKeys returns all public keys which can contribute weight to auth,
+// including the keys of its account authorities resolved by fetch.
+// fetch may be nil, then account_auths are ignored.
+func RequiredKeys(auth *types.Authority, fetch AuthorityFetcher) ([]types.PublicKey, error) {
+	var (
+		required []types.PublicKey
+		seen     = make(map[string]bool)
+	)
+
+	var collect func(auth *types.Authority, depth int) error
+	collect = func(auth *types.Authority, depth int) error {
+		for key := range auth.KeyAuths {
+			if !seen[keyID(key)] {
+				seen[keyID(key)] = true
+				required = append(required, key)
+			}
+		}
+
+		if fetch == nil || depth >= MaxAuthorityDepth || len(auth.AccountAuths) == 0 {
+			return nil
+		}
+
+		auths, err := fetchAccountAuths(auth, fetch)
+		if err != nil {
+			return err
+		}
+		for _, a := range auths {
+			if err := collect(a, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := collect(auth, 0); err != nil {
+		return nil, err
+	}
+	return required, nil
+}
+
+// CheckAuthority reports whether the signed keys satisfy the weight threshold of auth.
+// An account authority contributes its weight when its own authority is satisfied.
+func CheckAuthority(auth *types.Authority, signed []types.PublicKey, fetch AuthorityFetcher) (bool, error) {
+	signedKeys := make(map[string]bool, len(signed))
+	for _, key := range signed {
+		signedKeys[keyID(key)] = true
+	}
+	return checkAuthority(auth, signedKeys, fetch, 0)
+}
+
+func checkAuthority(auth *types.Authority, signed map[string]bool, fetch AuthorityFetcher, depth int) (bool, error) {
+	var total int64
+	threshold := int64(auth.WeightThreshold)
+
+	for key, weight := range auth.KeyAuths {
+		if signed[keyID(key)] {
+			total += weight
+			if total >= threshold {
+				return true, nil
+			}
+		}
+	}
+
+	if fetch == nil || depth >= MaxAuthorityDepth || len(auth.AccountAuths) == 0 {
+		return total >= threshold, nil
+	}
+
+	auths, err := fetchAccountAuths(auth, fetch)
+	if err != nil {
+		return false, err
+	}
+	for name, weight := range auth.AccountAuths {
+		a, ok := auths[string(name)]
+		if !ok {
+			continue
+		}
+		satisfied, err := checkAuthority(a, signed, fetch, depth+1)
+		if err != nil {
+			return false, err
+		}
+		if satisfied {
+			total += weight
+			if total >= threshold {
+				return true, nil
+			}
+		}
+	}
+
+	return total >= threshold, nil
+}
+
+func fetchAccountAuths(auth *types.Authority, fetch AuthorityFetcher) (map[string]*types.Authority, error) {
+	names := make([]string, 0, len(auth.AccountAuths))
+	for name := range auth.AccountAuths {
+		names = append(names, string(name))
+	}
+
+	auths, err := fetch(names)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to fetch authorities of %v", names)
+	}
+	return auths, nil
+}
+
+// SignedKeys recovers the public keys of all signatures in the transaction.
+func (tx *SignedTransaction) SignedKeys(chainID string) ([]types.PublicKey, error) {
+	digest, err := tx.Digest(chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	signed := make([]types.PublicKey, 0, len(tx.Signatures))
+	for _, sigStr := range tx.Signatures {
+		sig, err := hex.DecodeString(sigStr)
+		if err != nil {
+			return nil, err
+		}
+		key, err := signature.Recover(digest, sig)
+		if err != nil {
+			return nil, err
+		}
+		signed = append(signed, types.PublicKey(key.String()))
+	}
+	return signed, nil
+}
+
+// AppendSign signs the transaction with privKeys and appends the signatures
+// to the ones already present, so that several parties can sign the same transaction.
+// Keys which have already signed the transaction are skipped.
+func (tx *SignedTransaction) AppendSign(privKeys [][]byte, chainID string) error {
+	signed, err := tx.SignedKeys(chainID)
+	if err != nil {
+		return err
+	}
+	signedKeys := make(map[string]bool, len(signed))
+	for _, key := range signed {
+		signedKeys[keyID(key)] = true
+	}
+
+	toSign := make([][]byte, 0, len(privKeys))
+	for _, privKey := range privKeys {
+		var pk keys.PrivateKey
+		pk.FromBytes(privKey)
+		id := string(pk.Public().Serialize())
+		if signedKeys[id] {
+			continue
+		}
+		signedKeys[id] = true
+		toSign = append(toSign, privKey)
+	}
+	if len(toSign) == 0 {
+		return nil
+	}
+
+	sigs := tx.Signatures
+	if err := tx.Sign(toSign, chainID); err != nil {
+		tx.Signatures = sigs
+		return err
+	}
+	tx.Signatures = append(sigs, tx.Signatures...)
+	return nil
+}
+
+// Merge collects the signatures of other copies of the same transaction.
+func (tx *SignedTransaction) Merge(chainID string, others ...*SignedTransaction) error {
+	digest, err := tx.Digest(chainID)
+	if err != nil {
+		return err
+	}
+
+	exists := make(map[string]bool, len(tx.Signatures))
+	for _, sig := range tx.Signatures {
+		exists[sig] = true
+	}
+
+	for _, other := range others {
+		d, err := other.Digest(chainID)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(d, digest) {
+			return errors.New("can not merge signatures of different transactions")
+		}
+
+		for _, sig := range other.Signatures {
+			if !exists[sig] {
+				exists[sig] = true
+				tx.Signatures = append(tx.Signatures, sig)
+			}
+		}
+	}
+	return nil
+}
+
+// SignWithAuthority signs the transaction with those of privKeys which are
+// required by auth, and reports whether auth is satisfied afterwards.
+func (tx *SignedTransaction) SignWithAuthority(auth *types.Authority, privKeys [][]byte, chainID string, fetch AuthorityFetcher) (bool, error) {
+	required, err := RequiredKeys(auth, fetch)
+	if err != nil {
+		return false, err
+	}
+	requiredKeys := make(map[string]bool, len(required))
+	for _, key := range required {
+		requiredKeys[keyID(key)] = true
+	}
+
+	toSign := make([][]byte, 0, len(privKeys))
+	for _, privKey := range privKeys {
+		var pk keys.PrivateKey
+		pk.FromBytes(privKey)
+		if requiredKeys[string(pk.Public().Serialize())] {
+			toSign = append(toSign, privKey)
+		}
+	}
+
+	if err := tx.AppendSign(toSign, chainID); err != nil {
+		return false, err
+	}
+	return tx.VerifyAuthority(auth, chainID, fetch)
+}
+
+// VerifyAuthority reports whether the signatures of the transaction satisfy auth.
+func (tx *SignedTransaction) VerifyAuthority(auth *types.Authority, chainID string, fetch AuthorityFetcher) (bool, error) {
+	signed, err := tx.SignedKeys(chainID)
+	if err != nil {
+		return false, err
+	}
+	return CheckAuthority(auth, signed, fetch)
+}
@@ -0,0 +1,153 @@
+package transactions
+
+import (
+	// Stdlib
+	"testing"
+	"time"
+
+	// RPC
+	"github.com/weibocom/ipc/config"
+	"github.com/weibocom/ipc/keys"
+	"github.com/weibocom/ipc/steem/types"
+
+	// Vendor
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var multisigWIFs = []string{
+	"5JWHY5DxTF6qN5grTtChDCYBmWHfY9zaSsw4CxEKN5eZpH9iBma",
+	"5KPipdRzoxrp6dDqsBfMD6oFZG356trVHV5QBGx3rABs1zzWWs8",
+	"5JzpcbsNCu6Hpad1TYmudH4rj1A22SW9Zhb1ofBGHRZSp5poqAX",
+}
+
+func decodeMultisigKeys(t *testing.T) ([][]byte, []types.PublicKey) {
+	privKeys := make([][]byte, 0, len(multisigWIFs))
+	pubKeys := make([]types.PublicKey, 0, len(multisigWIFs))
+	for _, v := range multisigWIFs {
+		w, err := keys.DecodeWIF(v)
+		require.NoError(t, err, "decode wif")
+		privKeys = append(privKeys, w.PrivateKey().Serialize())
+		pubKeys = append(pubKeys, types.PublicKey(w.PublicKey().String()))
+	}
+	return privKeys, pubKeys
+}
+
+func newMultisigTransaction() *SignedTransaction {
+	mtx := &types.Transaction{
+		RefBlockNum:    12699,
+		RefBlockPrefix: 103618507,
+		Expiration:     types.NewTimePointSeconds(time.Date(2018, 4, 12, 13, 33, 22, 0, time.UTC)),
+	}
+	mtx.PushOperation(&types.TransferOperation{
+		From:   "alice",
+		To:     "bob",
+		Amount: types.NewSteemAsset(1),
+	})
+	return NewSignedTransaction(mtx)
+}
+
+func TestCheckAuthorityWeights(t *testing.T) {
+	_, pubKeys := decodeMultisigKeys(t)
+
+	auth := &types.Authority{
+		KeyAuths: types.KeyAuthorityMap{
+			pubKeys[0]: 1,
+			pubKeys[1]: 1,
+			pubKeys[2]: 2,
+		},
+		WeightThreshold: 2,
+	}
+
+	cases := []struct {
+		signed   []types.PublicKey
+		expected bool
+	}{
+		{nil, false},
+		{[]types.PublicKey{pubKeys[0]}, false},
+		{[]types.PublicKey{pubKeys[0], pubKeys[1]}, true},
+		{[]types.PublicKey{pubKeys[2]}, true},
+	}
+
+	for i, c := range cases {
+		ok, err := CheckAuthority(auth, c.signed, nil)
+		require.NoError(t, err, "case %d", i)
+		assert.Equal(t, c.expected, ok, "case %d", i)
+	}
+}
+
+func TestCheckAccountAuthority(t *testing.T) {
+	_, pubKeys := decodeMultisigKeys(t)
+
+	auth := &types.Authority{
+		AccountAuths:    types.KeyAuthorityMap{"alice": 1},
+		KeyAuths:        types.KeyAuthorityMap{pubKeys[0]: 1},
+		WeightThreshold: 2,
+	}
+	fetch := func(names []string) (map[string]*types.Authority, error) {
+		auths := make(map[string]*types.Authority)
+		for _, name := range names {
+			if name == "alice" {
+				auths[name] = &types.Authority{
+					KeyAuths:        types.KeyAuthorityMap{pubKeys[1]: 1},
+					WeightThreshold: 1,
+				}
+			}
+		}
+		return auths, nil
+	}
+
+	required, err := RequiredKeys(auth, fetch)
+	require.NoError(t, err, "required keys")
+	assert.Len(t, required, 2, "required keys")
+
+	ok, err := CheckAuthority(auth, []types.PublicKey{pubKeys[0]}, fetch)
+	require.NoError(t, err, "check authority")
+	assert.False(t, ok, "only key authority signed")
+
+	ok, err = CheckAuthority(auth, []types.PublicKey{pubKeys[0], pubKeys[1]}, fetch)
+	require.NoError(t, err, "check authority")
+	assert.True(t, ok, "key and account authority signed")
+
+	ok, err = CheckAuthority(auth, []types.PublicKey{pubKeys[0], pubKeys[1]}, nil)
+	require.NoError(t, err, "check authority")
+	assert.False(t, ok, "account authority is not resolved without fetcher")
+}
+
+func TestMultisigTransaction(t *testing.T) {
+	privKeys, pubKeys := decodeMultisigKeys(t)
+	chainID := config.GetChainID()
+
+	auth := &types.Authority{
+		KeyAuths: types.KeyAuthorityMap{
+			pubKeys[0]: 1,
+			pubKeys[1]: 1,
+		},
+		WeightThreshold: 2,
+	}
+
+	// two parties sign their own copy of the transaction.
+	stx1 := newMultisigTransaction()
+	stx2 := newMultisigTransaction()
+
+	ok, err := stx1.SignWithAuthority(auth, privKeys[:1], chainID, nil)
+	require.NoError(t, err, "sign by first party")
+	assert.False(t, ok, "threshold is not reached by one party")
+
+	// the third key is not required by the authority and should be skipped.
+	ok, err = stx2.SignWithAuthority(auth, privKeys[1:], chainID, nil)
+	require.NoError(t, err, "sign by second party")
+	assert.False(t, ok, "threshold is not reached by one party")
+	assert.Len(t, stx2.Signatures, 1, "only required keys sign")
+
+	require.NoError(t, stx1.Merge(chainID, stx2), "merge signatures")
+	assert.Len(t, stx1.Signatures, 2, "merged signatures")
+
+	ok, err = stx1.VerifyAuthority(auth, chainID, nil)
+	require.NoError(t, err, "verify authority")
+	assert.True(t, ok, "threshold is reached by both parties")
+
+	// signing again with the same key does not add a signature.
+	require.NoError(t, stx1.AppendSign(privKeys[:1], chainID), "sign again")
+	assert.Len(t, stx1.Signatures, 2, "duplicated signature")
+}
@@ -14,19 +14,43 @@ import (
 	// Vendor
 )
 
+// DefaultExpiration is used when a transaction is created without expiration.
+const DefaultExpiration = 30 * time.Second
+
 type SignedTransaction struct {
 	*types.Transaction
 }
 
 func NewSignedTransaction(tx *types.Transaction) *SignedTransaction {
 	if tx.Expiration == nil {
-		expiration := time.Now().Add(30 * time.Second)
+		expiration := time.Now().Add(DefaultExpiration)
 		tx.Expiration = types.NewTimePointSeconds(expiration)
 	}
 
 	return &SignedTransaction{tx}
 }
 
+// Serialize returns the binary serialization of the transaction, signatures excluded.
+func (tx *SignedTransaction) Serialize() ([]byte, error) {
+	var b bytes.Buffer
+	encoder := encoding.NewEncoder(&b)
+	if err := encoder.Encode(tx.Transaction); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// ID returns the transaction id, which is the hex encoded first 20 bytes
+// of the sha256 hash of the serialized transaction.
+func (tx *SignedTransaction) ID() (string, error) {
+	b, err := tx.Serialize()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:20]), nil
+}
+
 func (tx *SignedTransaction) Digest(chainID string) ([]byte, error) {
 	var b bytes.Buffer
 	encoder := encoding.NewRollingEncoder(encoding.NewEncoder(&b))
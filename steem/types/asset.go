@@ -37,10 +37,13 @@ func (a Asset) Marshal(encoder *encoding.Encoder) error {
 
 func (a *Asset) UnmarshalJSON(data []byte) error {
 
-	s := string(data)
+	s := strings.TrimSpace(string(data))
 	s = strings.TrimPrefix(s, "[")
 	s = strings.TrimSuffix(s, "]")
 	ss := strings.Split(s, ",")
+	for i := range ss {
+		ss[i] = strings.TrimSpace(ss[i])
+	}
 
 	// fee returned by GetAccountHistory is like `0.001 STEEM`, not like CreateAccount `["1", 3, "@@000000021"]``
 	if len(ss) == 1 {
@@ -479,6 +479,14 @@ func (op *DeleteCommentOperation) Data() interface{} {
 	return op
 }
 
+func (op *DeleteCommentOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+	enc.EncodeUVarint(uint64(TypeDeleteComment.Code()))
+	enc.Encode(op.Author)
+	enc.Encode(op.Permlink)
+	return enc.Err()
+}
+
 // FC_REFLECT( steemit::chain::comment_options_operation,
 //             (author)
 //             (permlink)
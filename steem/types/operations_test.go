@@ -33,6 +33,24 @@ func TestVoteOperationMarshal(t *testing.T) {
 	assert.Equal(t, expectedHex, serializedHex, "encode vote operation")
 }
 
+func TestDeleteCommentOperationMarshal(t *testing.T) {
+	op := &DeleteCommentOperation{
+		Author:   "xeroc",
+		Permlink: "piston",
+	}
+
+	expectedHex := "11057865726f6306706973746f6e"
+
+	var b bytes.Buffer
+	encoder := encoding.NewEncoder(&b)
+	err := encoder.Encode(op)
+
+	require.NoError(t, err, "encode delete comment operation")
+
+	serializedHex := hex.EncodeToString(b.Bytes())
+	assert.Equal(t, expectedHex, serializedHex, "encode delete comment operation")
+}
+
 func TestAccountCreateOperation(t *testing.T) {
 	// 这个签名是通过steem的pack与encoder进行序列化，hash之后的值
 	expectedHex := "cce3032fb2bec232d302d9e242d5e4c129d0d21cb63b65b940ace4b29ba32e81"
@@ -0,0 +1,147 @@
+package store
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+// Cache is the cache layer of CachedStore.
+type Cache interface {
+	// Get returns the value of key, and false if key is not cached.
+	Get(key string) ([]byte, bool, error)
+	// Set caches value for ttl. A ttl <= 0 means no expiration.
+	Set(key string, value []byte, ttl time.Duration) error
+	Delete(key string) error
+}
+
+var (
+	_ Cache = &LRUCache{}
+	_ Cache = &MemcacheCache{}
+)
+
+// LRUCache is an in-process cache which evicts the least recently used items.
+type LRUCache struct {
+	size int
+
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type lruItem struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewLRUCache creates a LRUCache holding at most size items.
+func NewLRUCache(size int) *LRUCache {
+	return &LRUCache{
+		size:  size,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+func (c *LRUCache) Get(key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	it := e.Value.(*lruItem)
+	if !it.expiresAt.IsZero() && !time.Now().Before(it.expiresAt) {
+		c.ll.Remove(e)
+		delete(c.items, key)
+		return nil, false, nil
+	}
+	c.ll.MoveToFront(e)
+	return it.value, true, nil
+}
+
+func (c *LRUCache) Set(key string, value []byte, ttl time.Duration) error {
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		it := e.Value.(*lruItem)
+		it.value = value
+		it.expiresAt = expiresAt
+		c.ll.MoveToFront(e)
+		return nil
+	}
+
+	c.items[key] = c.ll.PushFront(&lruItem{key: key, value: value, expiresAt: expiresAt})
+	for c.size > 0 && c.ll.Len() > c.size {
+		e := c.ll.Back()
+		c.ll.Remove(e)
+		delete(c.items, e.Value.(*lruItem).key)
+	}
+	return nil
+}
+
+func (c *LRUCache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		c.ll.Remove(e)
+		delete(c.items, key)
+	}
+	return nil
+}
+
+// Len returns the number of cached items, including the expired ones not evicted yet.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// MemcacheCache is a Cache with memcached.
+type MemcacheCache struct {
+	mc *memcache.Client
+}
+
+// NewMemcacheCache creates a MemcacheCache with the memcached servers.
+func NewMemcacheCache(server ...string) *MemcacheCache {
+	return &MemcacheCache{mc: memcache.New(server...)}
+}
+
+func (c *MemcacheCache) Get(key string) ([]byte, bool, error) {
+	item, err := c.mc.Get(key)
+	if err == memcache.ErrCacheMiss {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return item.Value, true, nil
+}
+
+func (c *MemcacheCache) Set(key string, value []byte, ttl time.Duration) error {
+	var expiration int32
+	if ttl > 0 {
+		// memcached expiration is in seconds.
+		expiration = int32((ttl + time.Second - 1) / time.Second)
+	}
+	return c.mc.Set(&memcache.Item{Key: key, Value: value, Expiration: expiration})
+}
+
+func (c *MemcacheCache) Delete(key string) error {
+	err := c.mc.Delete(key)
+	if err == memcache.ErrCacheMiss {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,273 @@
+package store
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	"github.com/weibocom/ipc/model"
+	"github.com/weibocom/ipc/util"
+)
+
+// CachedStore is a read-through and write-through cache in front of a Store.
+// LoadAccount, LoadPost, GetPostByDNA and GetPostByMsgID are served by the cache,
+// missing entities are cached too (negative caching) so that repeated lookups of
+// them do not hit the store. Other methods go to the store directly.
+type CachedStore struct {
+	Store
+
+	cache       Cache
+	prefix      string
+	ttl         time.Duration
+	negativeTTL time.Duration
+	registry    metrics.Registry
+
+	hits   int64
+	misses int64
+}
+
+var _ Store = &CachedStore{}
+
+// CacheOption is the option of CachedStore.
+type CacheOption func(*CachedStore)
+
+// SetCachePrefix sets the prefix of cache keys, so that several stores can share a cache.
+func SetCachePrefix(prefix string) CacheOption {
+	return func(s *CachedStore) {
+		s.prefix = prefix
+	}
+}
+
+// SetCacheTTL sets how long the entities are cached. 0 means no expiration.
+func SetCacheTTL(ttl time.Duration) CacheOption {
+	return func(s *CachedStore) {
+		s.ttl = ttl
+	}
+}
+
+// SetNegativeCacheTTL sets how long missing entities are cached. 0 disables negative caching.
+func SetNegativeCacheTTL(ttl time.Duration) CacheOption {
+	return func(s *CachedStore) {
+		s.negativeTTL = ttl
+	}
+}
+
+// SetCacheMetrics registers the hit and miss meters of the cache into registry,
+// as store.cache.hit and store.cache.miss.
+func SetCacheMetrics(registry metrics.Registry) CacheOption {
+	return func(s *CachedStore) {
+		s.registry = registry
+	}
+}
+
+// NewCachedStore creates a CachedStore which caches s with cache.
+func NewCachedStore(s Store, cache Cache, options ...CacheOption) *CachedStore {
+	cs := &CachedStore{
+		Store:       s,
+		cache:       cache,
+		prefix:      "ipc",
+		ttl:         10 * time.Minute,
+		negativeTTL: 10 * time.Second,
+	}
+	for _, opt := range options {
+		opt(cs)
+	}
+	return cs
+}
+
+// CacheStats returns the hits and misses of the cache.
+func (s *CachedStore) CacheStats() (hits, misses int64) {
+	return atomic.LoadInt64(&s.hits), atomic.LoadInt64(&s.misses)
+}
+
+func (s *CachedStore) hit() {
+	atomic.AddInt64(&s.hits, 1)
+	if s.registry != nil {
+		metrics.GetOrRegisterMeter("store.cache.hit", s.registry).Mark(1)
+	}
+}
+
+func (s *CachedStore) miss() {
+	atomic.AddInt64(&s.misses, 1)
+	if s.registry != nil {
+		metrics.GetOrRegisterMeter("store.cache.miss", s.registry).Mark(1)
+	}
+}
+
+func (s *CachedStore) accountKey(name string) string {
+	return generateKey(s.prefix, "account", name)
+}
+
+func (s *CachedStore) postKey(dna string) string {
+	return generateKey(s.prefix, "post", dna)
+}
+
+func (s *CachedStore) midKey(author string, mid int64) string {
+	return generateKey(s.prefix, "mid", author+"-"+strconv.FormatInt(mid, 10))
+}
+
+// get reads key from the cache into v. It returns true if key is cached,
+// and ErrNonExist if the entity is cached as missing.
+// Errors of the cache are treated as misses, so the store is still available without the cache.
+func (s *CachedStore) get(key string, v interface{}) (bool, error) {
+	data, ok, err := s.cache.Get(key)
+	if err != nil || !ok {
+		s.miss()
+		return false, nil
+	}
+	// an empty value marks a missing entity, as valid json is never empty.
+	if len(data) == 0 {
+		s.hit()
+		return true, ErrNonExist
+	}
+	if err := util.FromJSON(data, v); err != nil {
+		s.miss()
+		return false, nil
+	}
+	s.hit()
+	return true, nil
+}
+
+// set caches v, or caches key as missing if err is ErrNonExist.
+func (s *CachedStore) set(key string, v interface{}, err error) {
+	if err == ErrNonExist {
+		if s.negativeTTL > 0 {
+			s.cache.Set(key, []byte{}, s.negativeTTL)
+		}
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	data, err := util.ToJSON(v)
+	if err != nil {
+		return
+	}
+	// drop the stale entry if it can not be updated.
+	if s.cache.Set(key, data, s.ttl) != nil {
+		s.cache.Delete(key)
+	}
+}
+
+func (s *CachedStore) ExistAccount(name string) (bool, error) {
+	_, err := s.LoadAccount(name)
+	if err == ErrNonExist {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *CachedStore) SaveAccount(a *model.Account) error {
+	if err := s.Store.SaveAccount(a); err != nil {
+		s.cache.Delete(s.accountKey(a.Name))
+		return err
+	}
+	s.set(s.accountKey(a.Name), a, nil)
+	return nil
+}
+
+func (s *CachedStore) LoadAccount(name string) (*model.Account, error) {
+	key := s.accountKey(name)
+	a := &model.Account{}
+	if ok, err := s.get(key, a); ok {
+		if err != nil {
+			return nil, err
+		}
+		return a, nil
+	}
+
+	a, err := s.Store.LoadAccount(name)
+	s.set(key, a, err)
+	return a, err
+}
+
+func (s *CachedStore) ExistPost(dna model.DNA) (bool, error) {
+	_, err := s.LoadPost(dna)
+	if err == ErrNonExist {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *CachedStore) SavePost(p *model.Post) error {
+	key := s.postKey(p.DNA)
+	if err := s.Store.SavePost(p); err != nil {
+		s.cache.Delete(key)
+		s.cache.Delete(s.midKey(p.Author, p.MSGID))
+		return err
+	}
+	s.set(key, p, nil)
+	s.set(s.midKey(p.Author, p.MSGID), p, nil)
+	return nil
+}
+
+func (s *CachedStore) UpdatePost(dna model.DNA, p *model.Post) error {
+	err := s.Store.UpdatePost(dna, p)
+	// the replaced post is removed from the store.
+	s.cache.Delete(s.postKey(dna.String()))
+	if err != nil {
+		s.cache.Delete(s.postKey(p.DNA))
+		s.cache.Delete(s.midKey(p.Author, p.MSGID))
+		return err
+	}
+	s.set(s.postKey(p.DNA), p, nil)
+	s.set(s.midKey(p.Author, p.MSGID), p, nil)
+	return nil
+}
+
+func (s *CachedStore) ReindexPost(p *model.Post) error {
+	err := s.Store.ReindexPost(p)
+	// the other fields of the cached post may be stale, so it is loaded again.
+	s.cache.Delete(s.postKey(p.DNA))
+	s.cache.Delete(s.midKey(p.Author, p.MSGID))
+	return err
+}
+
+func (s *CachedStore) SetPostAnchor(dna model.DNA, txID string, blockNum uint32, blockTime time.Time) error {
+	if err := s.Store.SetPostAnchor(dna, txID, blockNum, blockTime); err != nil {
+		return err
+	}
+	s.cache.Delete(s.postKey(dna.String()))
+	p, err := s.Store.GetPostByDNA(dna)
+	if err != nil {
+		return err
+	}
+	s.cache.Delete(s.midKey(p.Author, p.MSGID))
+	return nil
+}
+
+func (s *CachedStore) LoadPost(dna model.DNA) (*model.Post, error) {
+	return s.GetPostByDNA(dna)
+}
+
+func (s *CachedStore) GetPostByDNA(dna model.DNA) (*model.Post, error) {
+	key := s.postKey(dna.String())
+	p := &model.Post{}
+	if ok, err := s.get(key, p); ok {
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+
+	p, err := s.Store.GetPostByDNA(dna)
+	s.set(key, p, err)
+	return p, err
+}
+
+func (s *CachedStore) GetPostByMsgID(author string, mid int64) (*model.Post, error) {
+	key := s.midKey(author, mid)
+	p := &model.Post{}
+	if ok, err := s.get(key, p); ok {
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+
+	p, err := s.Store.GetPostByMsgID(author, mid)
+	s.set(key, p, err)
+	return p, err
+}
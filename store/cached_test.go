@@ -0,0 +1,133 @@
+package store_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weibocom/ipc/model"
+	"github.com/weibocom/ipc/store"
+	"github.com/weibocom/ipc/store/storetest"
+)
+
+func TestCachedStoreLRU(t *testing.T) {
+	storetest.TestStore(t, func() store.Store {
+		return store.NewCachedStore(store.NewMemStore("test"), store.NewLRUCache(1000))
+	})
+}
+
+func TestCachedStoreMemcache(t *testing.T) {
+	server, err := storetest.NewMemcachedServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	i := 0
+	storetest.TestStore(t, func() store.Store {
+		i++
+		return store.NewCachedStore(store.NewMemStore("test"), store.NewMemcacheCache(server.Addr()),
+			store.SetCachePrefix("test"+strconv.Itoa(i)))
+	})
+}
+
+func TestCachedStoreHits(t *testing.T) {
+	registry := metrics.NewRegistry()
+	s := store.NewCachedStore(store.NewMemStore("test"), store.NewLRUCache(100), store.SetCacheMetrics(registry))
+
+	require.NoError(t, s.SavePost(&model.Post{DNA: "dna-1", Author: "wb-1", MSGID: 1, Content: "hello"}))
+
+	// saved posts are cached by dna and mid.
+	_, err := s.LoadPost(model.DNA("dna-1"))
+	require.NoError(t, err)
+	_, err = s.GetPostByMsgID("wb-1", 1)
+	require.NoError(t, err)
+	hits, misses := s.CacheStats()
+	assert.Equal(t, int64(2), hits)
+	assert.Equal(t, int64(0), misses)
+
+	// accounts not in the cache are read through.
+	require.NoError(t, s.Store.SaveAccount(&model.Account{Name: "wb-1", WIF: "wif"}))
+	for i := 0; i < 3; i++ {
+		a, err := s.LoadAccount("wb-1")
+		require.NoError(t, err)
+		assert.Equal(t, "wif", a.WIF)
+	}
+	hits, misses = s.CacheStats()
+	assert.Equal(t, int64(4), hits)
+	assert.Equal(t, int64(1), misses)
+
+	assert.Equal(t, int64(4), metrics.GetOrRegisterMeter("store.cache.hit", registry).Count())
+	assert.Equal(t, int64(1), metrics.GetOrRegisterMeter("store.cache.miss", registry).Count())
+}
+
+func TestCachedStoreNegative(t *testing.T) {
+	mem := store.NewMemStore("test")
+	s := store.NewCachedStore(mem, store.NewLRUCache(100), store.SetNegativeCacheTTL(50*time.Millisecond))
+
+	_, err := s.LoadAccount("wb-1")
+	assert.Equal(t, store.ErrNonExist, err)
+	require.NoError(t, mem.SaveAccount(&model.Account{Name: "wb-1", WIF: "wif"}))
+
+	// the missing account is cached until the negative ttl expires.
+	exist, err := s.ExistAccount("wb-1")
+	require.NoError(t, err)
+	assert.False(t, exist)
+	hits, misses := s.CacheStats()
+	assert.Equal(t, int64(1), hits)
+	assert.Equal(t, int64(1), misses)
+
+	time.Sleep(60 * time.Millisecond)
+	exist, err = s.ExistAccount("wb-1")
+	require.NoError(t, err)
+	assert.True(t, exist)
+
+	// saves through the cached store replace the negative entries.
+	_, err = s.GetPostByMsgID("wb-1", 1)
+	assert.Equal(t, store.ErrNonExist, err)
+	require.NoError(t, s.SavePost(&model.Post{DNA: "dna-1", Author: "wb-1", MSGID: 1, Content: "hello"}))
+	p, err := s.GetPostByMsgID("wb-1", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "dna-1", p.DNA)
+}
+
+func TestCachedStoreTTL(t *testing.T) {
+	mem := store.NewMemStore("test")
+	s := store.NewCachedStore(mem, store.NewLRUCache(100), store.SetCacheTTL(50*time.Millisecond))
+
+	require.NoError(t, s.SavePost(&model.Post{DNA: "dna-1", Author: "wb-1", MSGID: 1, Content: "hello"}))
+	require.NoError(t, mem.SavePost(&model.Post{DNA: "dna-1", Author: "wb-1", MSGID: 1, Content: "hello again"}))
+
+	p, err := s.LoadPost(model.DNA("dna-1"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", p.Content, "cached post")
+
+	time.Sleep(60 * time.Millisecond)
+	p, err = s.LoadPost(model.DNA("dna-1"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello again", p.Content, "expired post is reloaded")
+}
+
+func TestLRUCache(t *testing.T) {
+	c := store.NewLRUCache(2)
+	require.NoError(t, c.Set("a", []byte("1"), 0))
+	require.NoError(t, c.Set("b", []byte("2"), 0))
+	_, ok, _ := c.Get("a")
+	assert.True(t, ok)
+
+	// b is the least recently used.
+	require.NoError(t, c.Set("c", []byte("3"), 0))
+	_, ok, _ = c.Get("b")
+	assert.False(t, ok, "b is evicted")
+	v, ok, _ := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "1", string(v))
+	assert.Equal(t, 2, c.Len())
+
+	require.NoError(t, c.Delete("a"))
+	_, ok, _ = c.Get("a")
+	assert.False(t, ok, "a is deleted")
+}
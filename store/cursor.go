@@ -0,0 +1,106 @@
+package store
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/weibocom/ipc/model"
+)
+
+// ErrInvalidCursor is returned by ParseCursor if the token is not a cursor.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the position of keyset pagination in lists ordered by created_at desc
+// and then by key, which is the name of accounts or the dna of posts.
+// The page after a cursor starts from the first item after it, so that pages are
+// consistent while new items are added.
+type Cursor struct {
+	CreatedAt time.Time
+	Key       string
+}
+
+// AccountCursor returns the cursor of account a.
+func AccountCursor(a *model.Account) *Cursor {
+	return &Cursor{CreatedAt: a.CreatedAt, Key: a.Name}
+}
+
+// PostCursor returns the cursor of post p.
+func PostCursor(p *model.Post) *Cursor {
+	return &Cursor{CreatedAt: p.CreatedAt, Key: p.DNA}
+}
+
+// String returns the cursor as an opaque token, which can be parsed by ParseCursor.
+func (c *Cursor) String() string {
+	if c == nil {
+		return ""
+	}
+	s := strconv.FormatInt(c.CreatedAt.UnixNano(), 10) + "," + c.Key
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+// ParseCursor parses the token returned by Cursor.String.
+// An empty token is the cursor before the first page, which is nil.
+func ParseCursor(token string) (*Cursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	fields := strings.SplitN(string(b), ",", 2)
+	if len(fields) != 2 {
+		return nil, ErrInvalidCursor
+	}
+	ns, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &Cursor{CreatedAt: time.Unix(0, ns), Key: fields[1]}, nil
+}
+
+// before returns true if the item of createdAt and key is before the cursor,
+// or is the item of the cursor.
+func (c *Cursor) before(createdAt time.Time, key string) bool {
+	if c == nil {
+		return false
+	}
+	if !createdAt.Equal(c.CreatedAt) {
+		return createdAt.After(c.CreatedAt)
+	}
+	return key <= c.Key
+}
+
+// fetchLimit returns how many items to fetch for a page of limit items,
+// one more item is fetched to know whether there is a next page.
+// A limit <= 0 means all items.
+func fetchLimit(limit int) int {
+	if limit <= 0 {
+		return -1
+	}
+	return limit + 1
+}
+
+// accountsPage trims accounts fetched with fetchLimit to limit,
+// and returns the cursor of the next page, nil if there is not.
+func accountsPage(accounts []*model.Account, limit int) ([]*model.Account, *Cursor) {
+	if limit <= 0 || len(accounts) <= limit {
+		return accounts, nil
+	}
+	accounts = accounts[:limit]
+	return accounts, AccountCursor(accounts[limit-1])
+}
+
+// postsPage trims posts fetched with fetchLimit to limit,
+// and returns the cursor of the next page, nil if there is not.
+func postsPage(posts []*model.Post, limit int) ([]*model.Post, *Cursor) {
+	if limit <= 0 || len(posts) <= limit {
+		return posts, nil
+	}
+	posts = posts[:limit]
+	return posts, PostCursor(posts[limit-1])
+}
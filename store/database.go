@@ -1,7 +1,7 @@
 package store
 
 import (
-	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -11,27 +11,78 @@ import (
 )
 
 type DBStore struct {
-	db *gorm.DB
+	db   *gorm.DB
+	opts indexOptions
 }
 
 var _ Store = &DBStore{}
 
-func NewMySQLStore(conn string) *DBStore {
-	db, err := gorm.Open("mysql", conn)
+// NewMySQLStore creates a store with mysql, and panics if the db can not be opened.
+// Use NewSQLStore to handle the error.
+func NewMySQLStore(conn string, options ...Option) *DBStore {
+	s, err := NewSQLStore("mysql", conn, options...)
 	if err != nil {
 		panic(err)
 	}
-	s := &DBStore{db: db}
+	return s
+}
 
-	s.db.AutoMigrate(&model.Account{})
-	s.db.AutoMigrate(&model.Member{})
-	s.db.AutoMigrate(&model.Post{})
+// NewSQLStore creates a store with the db of dialect, which is one of mysql, postgres and sqlite3.
+// The schema must be migrated before, see Migrate.
+// The driver of the dialect must be imported, e.g. _ "github.com/jinzhu/gorm/dialects/postgres".
+func NewSQLStore(dialect string, dsn string, options ...Option) (*DBStore, error) {
+	if dialect == "sqlite" {
+		dialect = "sqlite3"
+	}
+	db, err := gorm.Open(dialect, dsn)
+	if err != nil {
+		return nil, err
+	}
 
-	return s
+	if dialect == "sqlite3" {
+		// sqlite does not support concurrent writes.
+		db.DB().SetMaxOpenConns(1)
+	}
+
+	s, err := NewDBStore(db, options...)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// SplitDSN returns the dialect and the dsn of the driver of a dsn.
+// postgres://... and postgresql://... are postgres dsn, sqlite3://path and sqlite://path are sqlite3 files,
+// and others are mysql dsn.
+func SplitDSN(dsn string) (dialect string, driverDSN string) {
+	switch {
+	case strings.HasPrefix(dsn, "postgres://"), strings.HasPrefix(dsn, "postgresql://"):
+		return "postgres", dsn
+	case strings.HasPrefix(dsn, "sqlite3://"):
+		return "sqlite3", strings.TrimPrefix(dsn, "sqlite3://")
+	case strings.HasPrefix(dsn, "sqlite://"):
+		return "sqlite3", strings.TrimPrefix(dsn, "sqlite://")
+	default:
+		return "mysql", dsn
+	}
+}
+
+// NewDBStore creates a store with an opened db.
+// It returns ErrSchemaOutdated if the migrations are not applied, see Migrate.
+func NewDBStore(db *gorm.DB, options ...Option) (*DBStore, error) {
+	if err := checkSchema(db); err != nil {
+		return nil, err
+	}
+	return &DBStore{db: db, opts: newIndexOptions(options)}, nil
 }
+
 func (s *DBStore) SaveAccount(a *model.Account) error {
 	company := getCompany(a.Name)
 	a.Company = company
+	if a.PublicKey == "" {
+		a.PublicKey = getPublicKey(a.WIF)
+	}
 	a.CreatedAt = time.Now()
 	return s.db.Save(a).Error
 }
@@ -50,13 +101,41 @@ func (s *DBStore) LoadAccount(name string) (*model.Account, error) {
 	return a, nil
 }
 
+func (s *DBStore) LoadAccountByPublicKey(pubKey string) (*model.Account, error) {
+	a := &model.Account{}
+	db := s.db.Model(&model.Account{}).Where("public_key = ?", pubKey).First(a)
+
+	if db.RecordNotFound() {
+		return nil, ErrNonExist
+	}
+
+	if db.Error != nil {
+		return nil, db.Error
+	}
+	return a, nil
+}
+
 func (s *DBStore) GetAccounts(company string, offset int, limit int) ([]*model.Account, error) {
 	var accounts []*model.Account
-	db := s.db.Model(&model.Account{}).Where(&model.Account{Company: company}).Order("created_at desc").Offset(offset).Limit(limit).Find(&accounts)
+	db := s.db.Model(&model.Account{}).Where(&model.Account{Company: company}).Order("created_at desc, name").Offset(offset).Limit(limit).Find(&accounts)
 
 	return accounts, db.Error
 }
 
+func (s *DBStore) GetAccountsAfter(company string, after *Cursor, limit int) ([]*model.Account, *Cursor, error) {
+	var accounts []*model.Account
+	db := s.db.Model(&model.Account{}).Where(&model.Account{Company: company})
+	if after != nil {
+		db = db.Where("created_at < ? OR (created_at = ? AND name > ?)", after.CreatedAt, after.CreatedAt, after.Key)
+	}
+	if err := db.Order("created_at desc, name").Limit(fetchLimit(limit)).Find(&accounts).Error; err != nil {
+		return nil, nil, err
+	}
+
+	page, next := accountsPage(accounts, limit)
+	return page, next, nil
+}
+
 func (s *DBStore) ExistAccount(name string) (bool, error) {
 	a, err := s.LoadAccount(name)
 	if err == ErrNonExist {
@@ -85,11 +164,245 @@ func (s *DBStore) GetAccountPostCount(name string) (int, error) {
 
 	return count, db.Error
 }
+
 func (s *DBStore) SavePost(p *model.Post) error {
-	keywords := content.Extract(p.Content, 6)
-	sort.Strings(keywords)
-	p.Keywords = strings.Join(keywords, ",")
-	return s.db.Save(p).Error
+	preparePost(p)
+
+	tx := s.db.Begin()
+	old := &model.Post{}
+	if db := tx.Where("dna = ?", p.DNA).First(old); db.RecordNotFound() {
+		old = nil
+	} else if db.Error != nil {
+		tx.Rollback()
+		return db.Error
+	}
+	if err := samePost(old, p); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Where("dna = ?", p.DNA).Delete(&model.Post{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Create(p).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := s.saveSimHashBands(tx, p.DNA, p); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := saveMediaHashBands(tx, p.DNA, p); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := saveTerms(tx, p.DNA, p); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// postSimHashBand indexes the posts by the bands of their SimHash, see content.SimHashBands.
+type postSimHashBand struct {
+	DNA   string `gorm:"COLUMN:dna;TYPE:VARCHAR(255);NOT NULL;index:idx_post_simhash_bands_dna"`
+	Band  int    `gorm:"COLUMN:band;NOT NULL;index:idx_post_simhash_bands_band_value"`
+	Value int64  `gorm:"COLUMN:value;NOT NULL;index:idx_post_simhash_bands_band_value"`
+}
+
+func (postSimHashBand) TableName() string { return "post_simhash_bands" }
+
+// saveSimHashBands replaces the bands of the post of dna with the ones of p.
+func (s *DBStore) saveSimHashBands(tx *gorm.DB, dna string, p *model.Post) error {
+	if err := tx.Where("dna IN (?)", []string{dna, p.DNA}).Delete(&postSimHashBand{}).Error; err != nil {
+		return err
+	}
+	if p.SimHash == 0 {
+		return nil
+	}
+	for i, v := range content.SimHashBands(uint64(p.SimHash), s.opts.simhashBands) {
+		if err := tx.Create(&postSimHashBand{DNA: p.DNA, Band: i, Value: int64(v)}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// postMediaHashBand indexes the media posts by the bands of every pHash of them, see
+// content.MediaHashBands. Hash is the index of the pHash in the media hashes of the post.
+type postMediaHashBand struct {
+	DNA   string `gorm:"COLUMN:dna;TYPE:VARCHAR(255);NOT NULL;index:idx_post_media_hash_bands_dna"`
+	Hash  int    `gorm:"COLUMN:hash;NOT NULL"`
+	Band  int    `gorm:"COLUMN:band;NOT NULL;index:idx_post_media_hash_bands_band_value"`
+	Value int64  `gorm:"COLUMN:value;NOT NULL;index:idx_post_media_hash_bands_band_value"`
+}
+
+func (postMediaHashBand) TableName() string { return "post_media_hash_bands" }
+
+// saveMediaHashBands replaces the media hash bands of the post of dna with the ones of p.
+func saveMediaHashBands(tx *gorm.DB, dna string, p *model.Post) error {
+	if err := tx.Where("dna IN (?)", []string{dna, p.DNA}).Delete(&postMediaHashBand{}).Error; err != nil {
+		return err
+	}
+	for i, h := range mediaHashes(p) {
+		for j, v := range content.SimHashBands(h, content.MediaHashBands) {
+			if err := tx.Create(&postMediaHashBand{DNA: p.DNA, Hash: i, Band: j, Value: int64(v)}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// postTerm indexes the posts by the terms of their contents for full-text search, with the
+// positions of the term in the content joined by comma.
+type postTerm struct {
+	DNA       string `gorm:"COLUMN:dna;TYPE:VARCHAR(255);NOT NULL;index:idx_post_terms_dna"`
+	Term      string `gorm:"COLUMN:term;TYPE:VARCHAR(255);NOT NULL;index:idx_post_terms_term"`
+	Positions string `gorm:"COLUMN:positions;TYPE:TEXT;NOT NULL"`
+}
+
+func (postTerm) TableName() string { return "post_terms" }
+
+// saveTerms replaces the terms of the post of dna with the ones of p.
+func saveTerms(tx *gorm.DB, dna string, p *model.Post) error {
+	if err := tx.Where("dna IN (?)", []string{dna, p.DNA}).Delete(&postTerm{}).Error; err != nil {
+		return err
+	}
+	for term, positions := range content.TermPositions(searchTokens(p)) {
+		t := &postTerm{DNA: p.DNA, Term: term, Positions: formatPositions(positions)}
+		if err := tx.Create(t).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatPositions(positions []int) string {
+	fields := make([]string, len(positions))
+	for i, p := range positions {
+		fields[i] = strconv.Itoa(p)
+	}
+	return strings.Join(fields, ",")
+}
+
+func parsePositions(s string) ([]int, error) {
+	fields := strings.Split(s, ",")
+	positions := make([]int, len(fields))
+	for i, f := range fields {
+		p, err := strconv.Atoi(f)
+		if err != nil {
+			return nil, err
+		}
+		positions[i] = p
+	}
+	return positions, nil
+}
+
+func (s *DBStore) UpdatePost(dna model.DNA, p *model.Post) error {
+	old, err := s.LoadPost(dna)
+	if err != nil {
+		return err
+	}
+	v := nextVersion(old, p)
+	preparePost(p)
+
+	tx := s.db.Begin()
+	other := &model.Post{}
+	if db := tx.Where("dna = ?", p.DNA).First(other); db.RecordNotFound() {
+		other = nil
+	} else if db.Error != nil {
+		tx.Rollback()
+		return db.Error
+	}
+	if err := samePost(other, p); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Create(v).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Where("dna = ?", old.DNA).Delete(&model.Post{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Create(p).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := s.saveSimHashBands(tx, old.DNA, p); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := saveMediaHashBands(tx, old.DNA, p); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := saveTerms(tx, old.DNA, p); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (s *DBStore) ReindexPost(p *model.Post) error {
+	tx := s.db.Begin()
+	post := &model.Post{}
+	if db := tx.Where("dna = ?", p.DNA).First(post); db.Error != nil {
+		tx.Rollback()
+		if db.RecordNotFound() {
+			return ErrNonExist
+		}
+		return db.Error
+	}
+	setFingerprints(post, p)
+
+	err := tx.Model(&model.Post{}).Where("dna = ?", p.DNA).Updates(map[string]interface{}{
+		"keywords":          post.Keywords,
+		"language":          post.Language,
+		"simhash":           post.SimHash,
+		"media_hashes":      post.MediaHashes,
+		"normalized_digest": post.NormalizedDigest,
+	}).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := s.saveSimHashBands(tx, post.DNA, post); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := saveMediaHashBands(tx, post.DNA, post); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := saveTerms(tx, post.DNA, post); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (s *DBStore) SetPostAnchor(dna model.DNA, txID string, blockNum uint32, blockTime time.Time) error {
+	// RowsAffected of mysql is 0 if the anchor is not changed, so the post is checked first.
+	if ok, err := s.ExistPost(dna); err != nil || !ok {
+		if err == nil {
+			err = ErrNonExist
+		}
+		return err
+	}
+	return s.db.Model(&model.Post{}).Where("dna = ?", dna.String()).Updates(map[string]interface{}{
+		"tx_id":      txID,
+		"block_num":  blockNum,
+		"block_time": blockTime,
+	}).Error
+}
+
+func (s *DBStore) GetPostVersions(author string, mid int64) ([]*model.PostVersion, error) {
+	var versions []*model.PostVersion
+	err := s.db.Where("author = ? AND mid = ?", author, mid).Order("version").Find(&versions).Error
+	return versions, err
 }
 
 func (s *DBStore) LoadPost(dna model.DNA) (*model.Post, error) {
@@ -114,34 +427,251 @@ func (s *DBStore) ExistPost(dna model.DNA) (bool, error) {
 
 func (s *DBStore) GetLatestPost() (*model.Post, error) {
 	a := &model.Post{}
-	err := s.db.Model(&model.Post{}).Order("created_at desc").Last(a).Error
-	return a, err
+	db := s.db.Model(&model.Post{}).Order("created_at desc, dna").First(a)
+	return findPost(a, db)
 }
 
 func (s *DBStore) GetPostByMsgID(author string, mid int64) (*model.Post, error) {
 	a := &model.Post{}
-	err := s.db.Model(&model.Post{}).Where("author = ? AND mid = ?", author, mid).First(&a).Error
-	return a, err
+	db := s.db.Model(&model.Post{}).Where("author = ? AND mid = ?", author, mid).First(a)
+	return findPost(a, db)
+}
+
+func (s *DBStore) GetPostsAfter(after *Cursor, limit int) ([]*model.Post, *Cursor, error) {
+	return s.postsAfter(s.db.Model(&model.Post{}), after, limit)
 }
 
 func (s *DBStore) GetPostByAuthor(author string, offset int, limit int) ([]*model.Post, error) {
 	var a []*model.Post
-	err := s.db.Model(&model.Post{}).Where("author = ?", author).Order("created_at desc").Offset(offset).Limit(limit).Find(&a).Error
+	err := s.db.Model(&model.Post{}).Where("author = ?", author).Order("created_at desc, dna").Offset(offset).Limit(limit).Find(&a).Error
 	return a, err
 }
 
+func (s *DBStore) GetPostByAuthorAfter(author string, after *Cursor, limit int) ([]*model.Post, *Cursor, error) {
+	return s.postsAfter(s.db.Model(&model.Post{}).Where("author = ?", author), after, limit)
+}
+
 func (s *DBStore) GetPostByDNA(dna model.DNA) (*model.Post, error) {
 	a := &model.Post{}
-	err := s.db.Model(&model.Post{}).Where("dna = ?", dna.String()).First(&a).Error
-	return a, err
+	db := s.db.Model(&model.Post{}).Where("dna = ?", dna.String()).First(a)
+	return findPost(a, db)
 }
 
 func (s *DBStore) LookupSimilarPosts(dna string, keywords string, offset int, limit int) ([]*model.Post, error) {
 	var a []*model.Post
-	err := s.db.Model(&model.Post{}).Where("dna != ? AND keywords = ?", dna, keywords).Order("created_at desc").Offset(offset).Limit(limit).Find(&a).Error
+	err := s.db.Model(&model.Post{}).Where("dna != ? AND keywords = ?", dna, keywords).Order("created_at desc, dna").Offset(offset).Limit(limit).Find(&a).Error
 	return a, err
 }
 
+func (s *DBStore) LookupSimilarPostsAfter(dna string, keywords string, after *Cursor, limit int) ([]*model.Post, *Cursor, error) {
+	return s.postsAfter(s.db.Model(&model.Post{}).Where("dna != ? AND keywords = ?", dna, keywords), after, limit)
+}
+
+func (s *DBStore) LookupNearDuplicatePosts(simhash uint64, maxDistance int, limit int) ([]*model.Post, error) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+	for i, v := range content.SimHashBands(simhash, s.opts.simhashBands) {
+		conds = append(conds, "(band = ? AND value = ?)")
+		args = append(args, i, int64(v))
+	}
+	// candidates share a band, and are filtered by the distance.
+	bands := s.db.Model(&postSimHashBand{}).Select("dna").Where(strings.Join(conds, " OR "), args...)
+
+	var posts []*model.Post
+	if err := s.db.Model(&model.Post{}).Where("dna IN (?)", bands.QueryExpr()).Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	return nearDuplicates(posts, simhash, maxDistance, limit), nil
+}
+
+func (s *DBStore) LookupMediaPosts(hashes []uint64, limit int) ([]*model.Post, error) {
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+	var conds []string
+	var args []interface{}
+	for _, h := range hashes {
+		for i, v := range content.SimHashBands(h, content.MediaHashBands) {
+			conds = append(conds, "(band = ? AND value = ?)")
+			args = append(args, i, int64(v))
+		}
+	}
+	bands := s.db.Model(&postMediaHashBand{}).Select("dna").Where(strings.Join(conds, " OR "), args...)
+
+	var posts []*model.Post
+	if err := s.db.Model(&model.Post{}).Where("dna IN (?)", bands.QueryExpr()).Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	return nearMedia(posts, hashes, limit), nil
+}
+
+func (s *DBStore) MaxSimHashDistance() int {
+	return s.opts.maxSimHashDistance()
+}
+
+// searchBatch is the number of candidates of SearchPosts which are matched at a time.
+const searchBatch = 100
+
+// SearchPosts finds the candidates which have all the terms of a group of the query in SQL, ordered
+// by created_at desc, and matches them by the positions of the terms a batch at a time until the
+// page is filled.
+func (s *DBStore) SearchPosts(q *SearchQuery, offset int, limit int) ([]*model.Post, error) {
+	query, err := content.ParseQuery(q.Text)
+	if err != nil {
+		return nil, err
+	}
+
+	var conds []string
+	var args []interface{}
+	for _, terms := range query.Terms() {
+		if len(terms) == 0 {
+			continue
+		}
+		candidates := s.db.Model(&postTerm{}).Select("dna").Where("term IN (?)", terms).
+			Group("dna").Having("COUNT(*) = ?", len(terms))
+		conds = append(conds, "dna IN (?)")
+		args = append(args, candidates.QueryExpr())
+	}
+	if len(conds) == 0 {
+		return nil, nil
+	}
+
+	db := s.db.Model(&model.Post{}).Where(strings.Join(conds, " OR "), args...)
+	if q.Company != "" {
+		db = db.Where("author LIKE ? ESCAPE '!'", escapeLike(q.Company)+"-%")
+	}
+	if q.Author != "" {
+		db = db.Where("author = ?", q.Author)
+	}
+	if !q.Since.IsZero() {
+		db = db.Where("created_at >= ?", q.Since)
+	}
+	if !q.Until.IsZero() {
+		db = db.Where("created_at < ?", q.Until)
+	}
+
+	var (
+		result []*model.Post
+		after  *Cursor
+	)
+	for {
+		posts, next, err := s.postsAfter(db, after, searchBatch)
+		if err != nil {
+			return nil, err
+		}
+		matched, err := s.matchQuery(query, posts)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, matched...)
+		if next == nil || (limit >= 0 && len(result) >= offset+limit) {
+			break
+		}
+		after = next
+	}
+	start, end := page(len(result), offset, limit)
+	return result[start:end], nil
+}
+
+// matchQuery returns the posts which match query by the positions of their terms.
+func (s *DBStore) matchQuery(query *content.Query, posts []*model.Post) ([]*model.Post, error) {
+	if len(posts) == 0 {
+		return nil, nil
+	}
+	dnas := make([]string, 0, len(posts))
+	for _, p := range posts {
+		dnas = append(dnas, p.DNA)
+	}
+	var rows []*postTerm
+	if err := s.db.Where("dna IN (?) AND term IN (?)", dnas, query.Tokens()).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	positions := make(map[string]map[string][]int)
+	for _, r := range rows {
+		if positions[r.DNA] == nil {
+			positions[r.DNA] = make(map[string][]int)
+		}
+		var err error
+		if positions[r.DNA][r.Term], err = parsePositions(r.Positions); err != nil {
+			return nil, err
+		}
+	}
+
+	var result []*model.Post
+	for _, p := range posts {
+		if query.Match(positions[p.DNA]) {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+// escapeLike escapes the wildcards of s in a LIKE pattern with the escape character '!'.
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
+
+// postsAfter returns the page of posts of the query after the cursor.
+func (s *DBStore) postsAfter(db *gorm.DB, after *Cursor, limit int) ([]*model.Post, *Cursor, error) {
+	var posts []*model.Post
+	if after != nil {
+		db = db.Where("created_at < ? OR (created_at = ? AND dna > ?)", after.CreatedAt, after.CreatedAt, after.Key)
+	}
+	if err := db.Order("created_at desc, dna").Limit(fetchLimit(limit)).Find(&posts).Error; err != nil {
+		return nil, nil, err
+	}
+
+	page, next := postsPage(posts, limit)
+	return page, next, nil
+}
+
+// findPost converts the not found error of db to ErrNonExist.
+func findPost(p *model.Post, db *gorm.DB) (*model.Post, error) {
+	if db.RecordNotFound() {
+		return nil, ErrNonExist
+	}
+	if db.Error != nil {
+		return nil, db.Error
+	}
+	return p, nil
+}
+
+func (s *DBStore) LookupPostsByTerms(terms []string, limit int) ([]*model.Post, error) {
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	var rows []struct {
+		DNA    string
+		Shared int
+	}
+	db := s.db.Model(&postTerm{}).Select("dna, COUNT(*) AS shared").Where("term IN (?)", terms).
+		Group("dna").Order("shared desc, dna")
+	if limit > 0 {
+		db = db.Limit(limit)
+	}
+	if err := db.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	shared := make(map[string]int, len(rows))
+	dnas := make([]string, len(rows))
+	for i, r := range rows {
+		shared[r.DNA] = r.Shared
+		dnas[i] = r.DNA
+	}
+	var posts []*model.Post
+	if err := s.db.Where("dna IN (?)", dnas).Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	return mostShared(posts, shared, limit), nil
+}
+
 func (s *DBStore) Close() error {
 	return s.db.Close()
 }
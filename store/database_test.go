@@ -0,0 +1,94 @@
+package store_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/weibocom/ipc/model"
+	"github.com/weibocom/ipc/store"
+	"github.com/weibocom/ipc/store/storetest"
+)
+
+func TestDBStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ipc-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	i := 0
+	storetest.TestStore(t, func() store.Store {
+		i++
+		dsn := filepath.Join(dir, fmt.Sprintf("ipc-%d.db", i))
+		migrate(t, "sqlite3", dsn)
+		s, err := store.NewSQLStore("sqlite3", dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	})
+}
+
+func migrate(t *testing.T, dialect string, dsn string) {
+	db, err := gorm.Open(dialect, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := store.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestPostgresStore runs against the postgres database of IPC_TEST_POSTGRES,
+// e.g. IPC_TEST_POSTGRES=postgres://postgres@127.0.0.1/ipc_test?sslmode=disable.
+// All tables of the store are dropped.
+func TestPostgresStore(t *testing.T) {
+	dsn := os.Getenv("IPC_TEST_POSTGRES")
+	if dsn == "" {
+		t.Skip("IPC_TEST_POSTGRES is not set")
+	}
+
+	storetest.TestStore(t, func() store.Store {
+		db, err := gorm.Open("postgres", dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.DropTableIfExists(&model.Account{}, &model.Member{}, &model.Post{}, &store.SchemaMigration{})
+		db.Close()
+
+		migrate(t, "postgres", dsn)
+		s, err := store.NewSQLStore("postgres", dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	})
+}
+
+func TestSplitDSN(t *testing.T) {
+	cases := []struct {
+		dsn       string
+		dialect   string
+		driverDSN string
+	}{
+		{"root@/ipc?charset=utf8mb4&parseTime=True", "mysql", "root@/ipc?charset=utf8mb4&parseTime=True"},
+		{"postgres://postgres@127.0.0.1/ipc?sslmode=disable", "postgres", "postgres://postgres@127.0.0.1/ipc?sslmode=disable"},
+		{"postgresql://127.0.0.1/ipc", "postgres", "postgresql://127.0.0.1/ipc"},
+		{"sqlite3://./ipc.db", "sqlite3", "./ipc.db"},
+		{"sqlite:///tmp/ipc.db", "sqlite3", "/tmp/ipc.db"},
+	}
+
+	for _, c := range cases {
+		dialect, driverDSN := store.SplitDSN(c.dsn)
+		assert.Equal(t, c.dialect, dialect, c.dsn)
+		assert.Equal(t, c.driverDSN, driverDSN, c.dsn)
+	}
+}
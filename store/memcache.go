@@ -1,6 +1,9 @@
 package store
 
 import (
+	"strconv"
+	"time"
+
 	"github.com/bradfitz/gomemcache/memcache"
 	"github.com/weibocom/ipc/model"
 	"github.com/weibocom/ipc/util"
@@ -28,17 +31,30 @@ func (s *MemcacheStore) Client() *memcache.Client {
 }
 
 func (s *MemcacheStore) SaveAccount(a *model.Account) error {
+	a.Company = getCompany(a.Name)
+	a.CreatedAt = time.Now()
+	if a.PublicKey == "" {
+		a.PublicKey = getPublicKey(a.WIF)
+	}
 	v, err := util.ToJSON(a)
 	if err != nil {
 		return err
 	}
 	key := generateKey(s.prefix, "account", a.Name)
-	return s.mc.Set(&memcache.Item{
+	err = s.mc.Set(&memcache.Item{
 		Key:   key,
 		Value: v,
 	})
+	if err != nil || a.PublicKey == "" {
+		return err
+	}
 
-	return nil
+	// index public key -> account name
+	key = generateKey(s.prefix, "pubkey", a.PublicKey)
+	return s.mc.Set(&memcache.Item{
+		Key:   key,
+		Value: []byte(a.Name),
+	})
 }
 
 func (s *MemcacheStore) LoadAccount(name string) (*model.Account, error) {
@@ -56,6 +72,19 @@ func (s *MemcacheStore) LoadAccount(name string) (*model.Account, error) {
 	return a, err
 }
 
+func (s *MemcacheStore) LoadAccountByPublicKey(pubKey string) (*model.Account, error) {
+	key := generateKey(s.prefix, "pubkey", pubKey)
+	item, err := s.mc.Get(key)
+	if err != nil {
+		if err == memcache.ErrCacheMiss {
+			return nil, ErrNonExist
+		}
+		return nil, err
+	}
+
+	return s.LoadAccount(string(item.Value))
+}
+
 func (s *MemcacheStore) ExistAccount(name string) (bool, error) {
 	a, err := s.LoadAccount(name)
 	if err == ErrNonExist {
@@ -69,6 +98,10 @@ func (s *MemcacheStore) GetAccounts(company string, offset int, limit int) ([]*m
 	return nil, ErrNotImplemented
 }
 
+func (s *MemcacheStore) GetAccountsAfter(company string, after *Cursor, limit int) ([]*model.Account, *Cursor, error) {
+	return nil, nil, ErrNotImplemented
+}
+
 func (s *MemcacheStore) GetAccountCount() (int, error) {
 	return 0, ErrNotImplemented
 }
@@ -82,19 +115,91 @@ func (s *MemcacheStore) GetAccountPostCount(name string) (int, error) {
 }
 
 func (s *MemcacheStore) SavePost(p *model.Post) error {
+	preparePost(p)
+	old, err := s.GetPostByDNA(model.DNA(p.DNA))
+	if err != nil && err != ErrNonExist {
+		return err
+	}
+	if err := samePost(old, p); err != nil {
+		return err
+	}
 	v, err := util.ToJSON(p)
 	if err != nil {
 		return err
 	}
 	key := generateKey(s.prefix, "post", p.DNA)
-	return s.mc.Set(&memcache.Item{
+	err = s.mc.Set(&memcache.Item{
 		Key:   key,
 		Value: v,
 	})
+	if err != nil {
+		return err
+	}
+
+	// index author-mid -> dna
+	key = generateKey(s.prefix, "mid", p.Author+"-"+strconv.FormatInt(p.MSGID, 10))
+	return s.mc.Set(&memcache.Item{
+		Key:   key,
+		Value: []byte(p.DNA),
+	})
+}
+
+// UpdatePost saves the previous versions of a post as a json list, it is not atomic
+// as the other methods of the store.
+func (s *MemcacheStore) UpdatePost(dna model.DNA, p *model.Post) error {
+	old, err := s.LoadPost(dna)
+	if err != nil {
+		return err
+	}
+	v := nextVersion(old, p)
+	other, err := s.LoadPost(model.DNA(p.DNA))
+	if err != nil && err != ErrNonExist {
+		return err
+	}
+	if err := samePost(other, p); err != nil {
+		return err
+	}
+
+	versions, err := s.GetPostVersions(old.Author, old.MSGID)
+	if err != nil {
+		return err
+	}
+	data, err := util.ToJSON(append(versions, v))
+	if err != nil {
+		return err
+	}
+	err = s.mc.Set(&memcache.Item{
+		Key:   generateKey(s.prefix, "versions", old.Author+"-"+strconv.FormatInt(old.MSGID, 10)),
+		Value: data,
+	})
+	if err != nil {
+		return err
+	}
 
+	if err := s.SavePost(p); err != nil {
+		return err
+	}
+	if err := s.mc.Delete(generateKey(s.prefix, "post", old.DNA)); err != nil && err != memcache.ErrCacheMiss {
+		return err
+	}
 	return nil
 }
 
+func (s *MemcacheStore) GetPostVersions(author string, mid int64) ([]*model.PostVersion, error) {
+	key := generateKey(s.prefix, "versions", author+"-"+strconv.FormatInt(mid, 10))
+	item, err := s.mc.Get(key)
+	if err != nil {
+		if err == memcache.ErrCacheMiss {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var versions []*model.PostVersion
+	err = util.FromJSON(item.Value, &versions)
+	return versions, err
+}
+
 func (s *MemcacheStore) LoadPost(dna model.DNA) (*model.Post, error) {
 	key := generateKey(s.prefix, "post", dna.String())
 	item, err := s.mc.Get(key)
@@ -123,11 +228,20 @@ func (s *MemcacheStore) GetLatestPost() (*model.Post, error) {
 }
 
 func (s *MemcacheStore) GetPostByMsgID(author string, mid int64) (*model.Post, error) {
-	return nil, ErrNotImplemented
+	key := generateKey(s.prefix, "mid", author+"-"+strconv.FormatInt(mid, 10))
+	item, err := s.mc.Get(key)
+	if err != nil {
+		if err == memcache.ErrCacheMiss {
+			return nil, ErrNonExist
+		}
+		return nil, err
+	}
+
+	return s.LoadPost(model.DNA(item.Value))
 }
 
 func (s *MemcacheStore) GetPostByDNA(dna model.DNA) (*model.Post, error) {
-	return nil, ErrNotImplemented
+	return s.LoadPost(dna)
 }
 
 func (s *MemcacheStore) GetPostByAuthor(author string, offset int, limit int) ([]*model.Post, error) {
@@ -138,6 +252,46 @@ func (s *MemcacheStore) LookupSimilarPosts(dna string, keywords string, offset i
 	return nil, ErrNotImplemented
 }
 
+func (s *MemcacheStore) GetPostByAuthorAfter(author string, after *Cursor, limit int) ([]*model.Post, *Cursor, error) {
+	return nil, nil, ErrNotImplemented
+}
+
+func (s *MemcacheStore) LookupSimilarPostsAfter(dna string, keywords string, after *Cursor, limit int) ([]*model.Post, *Cursor, error) {
+	return nil, nil, ErrNotImplemented
+}
+
+func (s *MemcacheStore) LookupNearDuplicatePosts(simhash uint64, maxDistance int, limit int) ([]*model.Post, error) {
+	return nil, ErrNotImplemented
+}
+
+func (s *MemcacheStore) MaxSimHashDistance() int {
+	return DefaultSimHashBands - 1
+}
+
+func (s *MemcacheStore) GetPostsAfter(after *Cursor, limit int) ([]*model.Post, *Cursor, error) {
+	return nil, nil, ErrNotImplemented
+}
+
+func (s *MemcacheStore) ReindexPost(p *model.Post) error {
+	return ErrNotImplemented
+}
+
+func (s *MemcacheStore) SetPostAnchor(dna model.DNA, txID string, blockNum uint32, blockTime time.Time) error {
+	return ErrNotImplemented
+}
+
+func (s *MemcacheStore) SearchPosts(q *SearchQuery, offset int, limit int) ([]*model.Post, error) {
+	return nil, ErrNotImplemented
+}
+
+func (s *MemcacheStore) LookupMediaPosts(hashes []uint64, limit int) ([]*model.Post, error) {
+	return nil, ErrNotImplemented
+}
+
+func (s *MemcacheStore) LookupPostsByTerms(terms []string, limit int) ([]*model.Post, error) {
+	return nil, ErrNotImplemented
+}
+
 func (s *MemcacheStore) Close() error {
 	return nil
 }
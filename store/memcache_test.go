@@ -0,0 +1,24 @@
+package store_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/weibocom/ipc/store"
+	"github.com/weibocom/ipc/store/storetest"
+)
+
+func TestMemcacheStore(t *testing.T) {
+	server, err := storetest.NewMemcachedServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	// every store uses its own prefix, so that they do not see each other's data.
+	i := 0
+	storetest.TestStore(t, func() store.Store {
+		i++
+		return store.NewMemcacheStore("test"+strconv.Itoa(i), server.Addr())
+	})
+}
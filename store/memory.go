@@ -1,126 +1,552 @@
 package store
 
 import (
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
-	"sync/atomic"
+	"time"
 
+	"github.com/weibocom/ipc/content"
 	"github.com/weibocom/ipc/model"
 )
 
 var _ Store = &MemStore{}
 
 // MemStore implements the Store in memory for testing purposes.
+// Accounts and posts are copied in and out, so it is safe for concurrent use.
 type MemStore struct {
-	prefix    string
-	accounts  map[string]*model.Account
-	members   map[string]*model.Member
-	posts     map[string]*model.Post
-	posts2    map[string]*model.Post
-	lastPost  *model.Post
-	mu        sync.RWMutex
-	postCount uint64
+	prefix string
+	opts   indexOptions
+
+	mu       sync.RWMutex
+	accounts map[string]*model.Account
+	pubKeys  map[string]string
+	members  map[string]*model.Member
+	posts    map[string]*model.Post
+	posts2   map[string]*model.Post
+	versions map[string][]*model.PostVersion
+
+	// indexes, sorted by created_at desc.
+	allAccounts     []*model.Account
+	companyAccounts map[string][]*model.Account
+	allPosts        []*model.Post
+	authorPosts     map[string][]*model.Post
+	keywordPosts    map[string][]*model.Post
+	simhashes       *content.SimHashIndex
+	media           *content.SimHashIndex // of mediaID
+	terms           *content.SearchIndex
 }
 
-func NewMemStore(prefix string) *MemStore {
+func NewMemStore(prefix string, options ...Option) *MemStore {
+	opts := newIndexOptions(options)
 	return &MemStore{
-		prefix:   prefix,
-		accounts: make(map[string]*model.Account),
-		members:  make(map[string]*model.Member),
-		posts:    make(map[string]*model.Post),
-		posts2:   make(map[string]*model.Post),
+		prefix:          prefix,
+		opts:            opts,
+		accounts:        make(map[string]*model.Account),
+		pubKeys:         make(map[string]string),
+		members:         make(map[string]*model.Member),
+		posts:           make(map[string]*model.Post),
+		posts2:          make(map[string]*model.Post),
+		versions:        make(map[string][]*model.PostVersion),
+		companyAccounts: make(map[string][]*model.Account),
+		authorPosts:     make(map[string][]*model.Post),
+		keywordPosts:    make(map[string][]*model.Post),
+		simhashes:       content.NewSimHashIndex(opts.simhashBands),
+		media:           content.NewSimHashIndex(content.MediaHashBands),
+		terms:           content.NewSearchIndex(),
+	}
+}
+
+// mediaID is the id of the i-th pHash of the post of dna in the media index.
+func mediaID(dna string, i int) string {
+	return dna + "#" + strconv.Itoa(i)
+}
+
+// mediaDNA returns the dna of the post of a mediaID.
+func mediaDNA(id string) string {
+	return id[:strings.LastIndexByte(id, '#')]
+}
+
+func msgKey(author string, mid int64) string {
+	return author + "-" + strconv.FormatInt(mid, 10)
+}
+
+// accountLess orders accounts by created_at desc, then by name.
+func accountLess(a, b *model.Account) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return a.Name < b.Name
+}
+
+// postLess orders posts by created_at desc, then by dna.
+func postLess(a, b *model.Post) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return a.DNA < b.DNA
+}
+
+func insertAccount(accounts []*model.Account, a *model.Account) []*model.Account {
+	i := sort.Search(len(accounts), func(i int) bool { return !accountLess(accounts[i], a) })
+	accounts = append(accounts, nil)
+	copy(accounts[i+1:], accounts[i:])
+	accounts[i] = a
+	return accounts
+}
+
+func removeAccount(accounts []*model.Account, a *model.Account) []*model.Account {
+	for i, v := range accounts {
+		if v == a {
+			return append(accounts[:i], accounts[i+1:]...)
+		}
+	}
+	return accounts
+}
+
+func insertPost(posts []*model.Post, p *model.Post) []*model.Post {
+	i := sort.Search(len(posts), func(i int) bool { return !postLess(posts[i], p) })
+	posts = append(posts, nil)
+	copy(posts[i+1:], posts[i:])
+	posts[i] = p
+	return posts
+}
+
+func removePost(posts []*model.Post, p *model.Post) []*model.Post {
+	for i, v := range posts {
+		if v == p {
+			return append(posts[:i], posts[i+1:]...)
+		}
+	}
+	return posts
+}
+
+// page returns the range [start, end) of a list of n items.
+// A negative limit means no limit.
+func page(n int, offset int, limit int) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > n {
+		offset = n
+	}
+	end := n
+	if limit >= 0 && offset+limit < n {
+		end = offset + limit
+	}
+	return offset, end
+}
+
+func copyAccounts(accounts []*model.Account, offset int, limit int) []*model.Account {
+	start, end := page(len(accounts), offset, limit)
+	result := make([]*model.Account, 0, end-start)
+	for _, a := range accounts[start:end] {
+		cp := *a
+		result = append(result, &cp)
+	}
+	return result
+}
+
+func copyPosts(posts []*model.Post, offset int, limit int) []*model.Post {
+	start, end := page(len(posts), offset, limit)
+	result := make([]*model.Post, 0, end-start)
+	for _, p := range posts[start:end] {
+		cp := *p
+		result = append(result, &cp)
 	}
+	return result
 }
 
 func (s *MemStore) SaveAccount(a *model.Account) error {
+	a.Company = getCompany(a.Name)
+	if a.PublicKey == "" {
+		a.PublicKey = getPublicKey(a.WIF)
+	}
+	a.CreatedAt = time.Now()
+	cp := *a
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.accounts[a.Name] = a
+
+	if old, ok := s.accounts[a.Name]; ok {
+		s.allAccounts = removeAccount(s.allAccounts, old)
+		s.companyAccounts[old.Company] = removeAccount(s.companyAccounts[old.Company], old)
+		if s.pubKeys[old.PublicKey] == old.Name {
+			delete(s.pubKeys, old.PublicKey)
+		}
+	}
+
+	s.accounts[a.Name] = &cp
+	if cp.PublicKey != "" {
+		s.pubKeys[cp.PublicKey] = cp.Name
+	}
+	s.allAccounts = insertAccount(s.allAccounts, &cp)
+	s.companyAccounts[cp.Company] = insertAccount(s.companyAccounts[cp.Company], &cp)
 	return nil
 }
 
 func (s *MemStore) LoadAccount(name string) (*model.Account, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
 	v, ok := s.accounts[name]
 	if ok {
-		return v, nil
+		cp := *v
+		return &cp, nil
+	}
+	return nil, ErrNonExist
+}
+
+func (s *MemStore) LoadAccountByPublicKey(pubKey string) (*model.Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	name, ok := s.pubKeys[pubKey]
+	if !ok {
+		return nil, ErrNonExist
+	}
+	v, ok := s.accounts[name]
+	if ok {
+		cp := *v
+		return &cp, nil
 	}
 	return nil, ErrNonExist
 }
 
 func (s *MemStore) ExistAccount(name string) (bool, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	_, exist := s.accounts[name]
 	return exist, nil
 }
 
+// GetAccounts returns the accounts of company, or all accounts if company is empty.
 func (s *MemStore) GetAccounts(company string, offset int, limit int) ([]*model.Account, error) {
-	return nil, ErrNotImplemented
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if company == "" {
+		return copyAccounts(s.allAccounts, offset, limit), nil
+	}
+	return copyAccounts(s.companyAccounts[company], offset, limit), nil
+}
+
+func (s *MemStore) GetAccountsAfter(company string, after *Cursor, limit int) ([]*model.Account, *Cursor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	accounts := s.allAccounts
+	if company != "" {
+		accounts = s.companyAccounts[company]
+	}
+	start := sort.Search(len(accounts), func(i int) bool {
+		return !after.before(accounts[i].CreatedAt, accounts[i].Name)
+	})
+	page, next := accountsPage(copyAccounts(accounts[start:], 0, fetchLimit(limit)), limit)
+	return page, next, nil
 }
 
 func (s *MemStore) GetAccountCount() (int, error) {
-	return 0, ErrNotImplemented
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.accounts), nil
 }
 
 func (s *MemStore) GetPostCount() (int, error) {
-	count := atomic.LoadUint64(&s.postCount)
-	return int(count), ErrNotImplemented
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.posts), nil
 }
 
 func (s *MemStore) GetAccountPostCount(name string) (int, error) {
-	return 0, ErrNotImplemented
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.authorPosts[name]), nil
 }
 
 func (s *MemStore) SavePost(p *model.Post) error {
+	preparePost(p)
+	cp := *p
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
+
+	if old, ok := s.posts[p.DNA]; ok {
+		if err := samePost(old, p); err != nil {
+			return err
+		}
+		s.unindexPost(old)
+	}
+	s.indexPost(&cp)
+	return nil
+}
+
+func (s *MemStore) unindexPost(p *model.Post) {
+	delete(s.posts, p.DNA)
+	s.allPosts = removePost(s.allPosts, p)
+	s.authorPosts[p.Author] = removePost(s.authorPosts[p.Author], p)
+	s.keywordPosts[p.Keywords] = removePost(s.keywordPosts[p.Keywords], p)
+	s.simhashes.Remove(p.DNA)
+	for i := range mediaHashes(p) {
+		s.media.Remove(mediaID(p.DNA, i))
+	}
+	s.terms.Remove(p.DNA)
+	if s.posts2[msgKey(p.Author, p.MSGID)] == p {
+		delete(s.posts2, msgKey(p.Author, p.MSGID))
+	}
+}
+
+func (s *MemStore) indexPost(p *model.Post) {
 	s.posts[p.DNA] = p
-	s.posts2[p.Author+"-"+strconv.FormatInt(p.MSGID, 10)] = p
-	s.lastPost = p
+	s.posts2[msgKey(p.Author, p.MSGID)] = p
+	s.allPosts = insertPost(s.allPosts, p)
+	s.authorPosts[p.Author] = insertPost(s.authorPosts[p.Author], p)
+	s.keywordPosts[p.Keywords] = insertPost(s.keywordPosts[p.Keywords], p)
+	if p.SimHash != 0 {
+		s.simhashes.Add(p.DNA, uint64(p.SimHash))
+	}
+	for i, h := range mediaHashes(p) {
+		s.media.Add(mediaID(p.DNA, i), h)
+	}
+	s.terms.Add(p.DNA, searchTokens(p))
+}
+
+func (s *MemStore) UpdatePost(dna model.DNA, p *model.Post) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.posts[dna.String()]
+	if !ok {
+		return ErrNonExist
+	}
+	v := nextVersion(old, p)
+	if err := samePost(s.posts[p.DNA], p); err != nil {
+		return err
+	}
+	preparePost(p)
+	cp := *p
 
-	atomic.AddUint64(&s.postCount, 1)
+	key := msgKey(old.Author, old.MSGID)
+	s.versions[key] = append(s.versions[key], v)
+	s.unindexPost(old)
+	s.indexPost(&cp)
 	return nil
 }
 
-func (s *MemStore) LoadPost(dna model.DNA) (*model.Post, error) {
+func (s *MemStore) ReindexPost(p *model.Post) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	v, ok := s.posts[dna.String()]
-	if ok {
-		return v, nil
+	old, ok := s.posts[p.DNA]
+	if !ok {
+		return ErrNonExist
 	}
-	return nil, ErrNonExist
+	cp := *old
+	setFingerprints(&cp, p)
+	s.unindexPost(old)
+	s.indexPost(&cp)
+	return nil
 }
 
-func (s *MemStore) ExistPost(dna model.DNA) (bool, error) {
+func (s *MemStore) SetPostAnchor(dna model.DNA, txID string, blockNum uint32, blockTime time.Time) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+
+	p, ok := s.posts[dna.String()]
+	if !ok {
+		return ErrNonExist
+	}
+	p.TxID = txID
+	p.BlockNum = blockNum
+	p.BlockTime = &blockTime
+	return nil
+}
+
+func (s *MemStore) GetPostVersions(author string, mid int64) ([]*model.PostVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	versions := s.versions[msgKey(author, mid)]
+	result := make([]*model.PostVersion, 0, len(versions))
+	for _, v := range versions {
+		cp := *v
+		result = append(result, &cp)
+	}
+	return result, nil
+}
+
+func (s *MemStore) LoadPost(dna model.DNA) (*model.Post, error) {
+	return s.GetPostByDNA(dna)
+}
+
+func (s *MemStore) ExistPost(dna model.DNA) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	_, exist := s.posts[dna.String()]
 	return exist, nil
 }
 
 func (s *MemStore) GetLatestPost() (*model.Post, error) {
-	return s.lastPost, nil
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.allPosts) == 0 {
+		return nil, ErrNonExist
+	}
+	cp := *s.allPosts[0]
+	return &cp, nil
 }
 
 func (s *MemStore) GetPostByMsgID(author string, mid int64) (*model.Post, error) {
-	return s.posts2[author+"-"+strconv.FormatInt(mid, 10)], nil
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v, ok := s.posts2[msgKey(author, mid)]
+	if !ok {
+		return nil, ErrNonExist
+	}
+	cp := *v
+	return &cp, nil
 }
 
 func (s *MemStore) GetPostByDNA(dna model.DNA) (*model.Post, error) {
-	return s.posts[dna.String()], nil
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v, ok := s.posts[dna.String()]
+	if !ok {
+		return nil, ErrNonExist
+	}
+	cp := *v
+	return &cp, nil
+}
+
+func (s *MemStore) GetPostsAfter(after *Cursor, limit int) ([]*model.Post, *Cursor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	page, next := postsPage(copyPosts(postsAfter(s.allPosts, after), 0, fetchLimit(limit)), limit)
+	return page, next, nil
 }
 
 func (s *MemStore) GetPostByAuthor(author string, offset int, limit int) ([]*model.Post, error) {
-	return nil, ErrNotImplemented
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return copyPosts(s.authorPosts[author], offset, limit), nil
 }
 
+func (s *MemStore) GetPostByAuthorAfter(author string, after *Cursor, limit int) ([]*model.Post, *Cursor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	page, next := postsPage(copyPosts(postsAfter(s.authorPosts[author], after), 0, fetchLimit(limit)), limit)
+	return page, next, nil
+}
+
+// LookupSimilarPosts returns the posts with the same keywords, except the one of dna.
 func (s *MemStore) LookupSimilarPosts(dna string, keywords string, offset int, limit int) ([]*model.Post, error) {
-	return nil, ErrNotImplemented
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return copyPosts(s.similarPosts(dna, keywords), offset, limit), nil
+}
+
+func (s *MemStore) LookupSimilarPostsAfter(dna string, keywords string, after *Cursor, limit int) ([]*model.Post, *Cursor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	posts := postsAfter(s.similarPosts(dna, keywords), after)
+	page, next := postsPage(copyPosts(posts, 0, fetchLimit(limit)), limit)
+	return page, next, nil
+}
+
+// similarPosts returns the posts with the same keywords, except the one of dna.
+func (s *MemStore) similarPosts(dna string, keywords string) []*model.Post {
+	posts := s.keywordPosts[keywords]
+	if p, ok := s.posts[dna]; ok && p.Keywords == keywords {
+		others := make([]*model.Post, 0, len(posts))
+		for _, v := range posts {
+			if v != p {
+				others = append(others, v)
+			}
+		}
+		posts = others
+	}
+	return posts
+}
+
+// postsAfter returns the sorted posts after the cursor.
+func postsAfter(posts []*model.Post, after *Cursor) []*model.Post {
+	start := sort.Search(len(posts), func(i int) bool {
+		return !after.before(posts[i].CreatedAt, posts[i].DNA)
+	})
+	return posts[start:]
+}
+
+func (s *MemStore) LookupNearDuplicatePosts(simhash uint64, maxDistance int, limit int) ([]*model.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matches := s.simhashes.Query(simhash, maxDistance)
+	posts := make([]*model.Post, 0, len(matches))
+	for _, m := range matches {
+		cp := *s.posts[m.ID]
+		posts = append(posts, &cp)
+	}
+	return nearDuplicates(posts, simhash, maxDistance, limit), nil
+}
+
+func (s *MemStore) LookupMediaPosts(hashes []uint64, limit int) ([]*model.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var posts []*model.Post
+	for _, h := range hashes {
+		for _, m := range s.media.Query(h, content.MediaMatchDistance) {
+			dna := mediaDNA(m.ID)
+			if seen[dna] {
+				continue
+			}
+			seen[dna] = true
+			cp := *s.posts[dna]
+			posts = append(posts, &cp)
+		}
+	}
+	return nearMedia(posts, hashes, limit), nil
+}
+
+func (s *MemStore) MaxSimHashDistance() int {
+	return s.opts.maxSimHashDistance()
+}
+
+func (s *MemStore) SearchPosts(q *SearchQuery, offset int, limit int) ([]*model.Post, error) {
+	query, err := content.ParseQuery(q.Text)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	dnas := s.terms.Search(query)
+	posts := make([]*model.Post, 0, len(dnas))
+	for _, dna := range dnas {
+		posts = append(posts, s.posts[dna])
+	}
+	return copyPosts(searchResults(posts, q, offset, limit), 0, -1), nil
+}
+
+func (s *MemStore) LookupPostsByTerms(terms []string, limit int) ([]*model.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	shared := s.terms.Shared(terms)
+	posts := make([]*model.Post, 0, len(shared))
+	for dna := range shared {
+		posts = append(posts, s.posts[dna])
+	}
+	return copyPosts(mostShared(posts, shared, limit), 0, -1), nil
 }
 
 func (s *MemStore) Close() error {
@@ -0,0 +1,38 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weibocom/ipc/model"
+	"github.com/weibocom/ipc/store"
+	"github.com/weibocom/ipc/store/storetest"
+)
+
+func TestMemStore(t *testing.T) {
+	storetest.TestStore(t, func() store.Store {
+		return store.NewMemStore("test")
+	})
+}
+
+func TestSimHashBands(t *testing.T) {
+	const h = 0x0123456789abcdef
+	// differs in every band of 16 bits
+	near := int64(h ^ 0x0001000100010001)
+	for _, c := range []struct {
+		bands int
+		found int
+	}{
+		{store.DefaultSimHashBands, 0},
+		{16, 1},
+	} {
+		s := store.NewMemStore("test", store.SetSimHashBands(c.bands))
+		assert.Equal(t, c.bands-1, s.MaxSimHashDistance())
+		require.NoError(t, s.SavePost(&model.Post{DNA: "dna-1", Author: "wb-1", Content: "a", SimHash: near}))
+		posts, err := s.LookupNearDuplicatePosts(h, 10, 0)
+		require.NoError(t, err)
+		assert.Len(t, posts, c.found, "%d bands", c.bands)
+	}
+	assert.Panics(t, func() { store.SetSimHashBands(3) })
+}
@@ -0,0 +1,156 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ErrSchemaOutdated is returned by NewDBStore when there are migrations not applied yet.
+var ErrSchemaOutdated = errors.New("schema is outdated, apply the migrations first")
+
+// Migration is a versioned change of the schema of DBStore.
+// Migrations are applied in the order of Version, and rolled back in reverse order.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(db *gorm.DB) error
+	Down    func(db *gorm.DB) error
+}
+
+// SchemaMigration records an applied migration in the schema_migrations table.
+type SchemaMigration struct {
+	Version   int64     `gorm:"COLUMN:version;PRIMARY_KEY;AUTO_INCREMENT:false"`
+	Name      string    `gorm:"COLUMN:name;TYPE:VARCHAR(128);NOT NULL"`
+	AppliedAt time.Time `gorm:"COLUMN:applied_at;NOT NULL"`
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrations returns all migrations ordered by version.
+func Migrations() []Migration {
+	ms := make([]Migration, len(migrations))
+	copy(ms, migrations)
+	sort.Slice(ms, func(i, j int) bool { return ms[i].Version < ms[j].Version })
+	return ms
+}
+
+// LatestVersion returns the version of the last migration.
+func LatestVersion() int64 {
+	ms := Migrations()
+	if len(ms) == 0 {
+		return 0
+	}
+	return ms[len(ms)-1].Version
+}
+
+// AppliedMigrations returns the applied migrations ordered by version.
+func AppliedMigrations(db *gorm.DB) ([]*SchemaMigration, error) {
+	if !db.HasTable(&SchemaMigration{}) {
+		return nil, nil
+	}
+
+	var applied []*SchemaMigration
+	err := db.Order("version").Find(&applied).Error
+	return applied, err
+}
+
+// SchemaVersion returns the version of the last applied migration, 0 if none is applied.
+func SchemaVersion(db *gorm.DB) (int64, error) {
+	applied, err := AppliedMigrations(db)
+	if err != nil || len(applied) == 0 {
+		return 0, err
+	}
+	return applied[len(applied)-1].Version, nil
+}
+
+// Migrate applies all pending migrations.
+func Migrate(db *gorm.DB) error {
+	return MigrateTo(db, LatestVersion())
+}
+
+// MigrateTo applies the pending migrations whose version is not greater than version.
+func MigrateTo(db *gorm.DB, version int64) error {
+	if err := db.AutoMigrate(&SchemaMigration{}).Error; err != nil {
+		return err
+	}
+	applied, err := AppliedMigrations(db)
+	if err != nil {
+		return err
+	}
+	done := make(map[int64]bool, len(applied))
+	for _, m := range applied {
+		done[m.Version] = true
+	}
+
+	for _, m := range Migrations() {
+		if m.Version > version {
+			break
+		}
+		if done[m.Version] {
+			continue
+		}
+
+		// migrations are not run in a transaction, as DDL is not transactional in mysql.
+		if err := m.Up(db); err != nil {
+			return fmt.Errorf("migration %d %s failed: %v", m.Version, m.Name, err)
+		}
+		record := &SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}
+		if err := db.Create(record).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rollback rolls back the last n applied migrations.
+func Rollback(db *gorm.DB, n int) error {
+	applied, err := AppliedMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	ms := make(map[int64]Migration)
+	for _, m := range Migrations() {
+		ms[m.Version] = m
+	}
+
+	for i := len(applied) - 1; i >= 0 && n > 0; i, n = i-1, n-1 {
+		m, ok := ms[applied[i].Version]
+		if !ok {
+			return fmt.Errorf("unknown migration %d %s", applied[i].Version, applied[i].Name)
+		}
+
+		if err := m.Down(db); err != nil {
+			return fmt.Errorf("rollback of migration %d %s failed: %v", m.Version, m.Name, err)
+		}
+		if err := db.Delete(&SchemaMigration{}, "version = ?", m.Version).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkSchema returns ErrSchemaOutdated if there are migrations not applied.
+func checkSchema(db *gorm.DB) error {
+	applied, err := AppliedMigrations(db)
+	if err != nil {
+		return err
+	}
+	done := make(map[int64]bool, len(applied))
+	for _, m := range applied {
+		done[m.Version] = true
+	}
+
+	for _, m := range migrations {
+		if !done[m.Version] {
+			return ErrSchemaOutdated
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package store_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weibocom/ipc/content"
+	"github.com/weibocom/ipc/keys"
+	"github.com/weibocom/ipc/model"
+	"github.com/weibocom/ipc/store"
+)
+
+func TestMigrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ipc-migrate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dsn := filepath.Join(dir, "ipc.db")
+	db, err := gorm.Open("sqlite3", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = store.NewSQLStore("sqlite3", dsn)
+	assert.Equal(t, store.ErrSchemaOutdated, err, "store of empty db")
+
+	require.NoError(t, store.MigrateTo(db, 1), "migrate to 1")
+	version, err := store.SchemaVersion(db)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), version)
+	assert.True(t, db.HasTable("posts"), "tables are created")
+
+	require.NoError(t, store.Migrate(db), "migrate")
+	version, err = store.SchemaVersion(db)
+	require.NoError(t, err)
+	assert.Equal(t, store.LatestVersion(), version)
+	assert.True(t, db.HasTable("post_versions"), "post_versions is created")
+	assert.True(t, db.HasTable("post_simhash_bands"), "post_simhash_bands is created")
+	assert.True(t, db.Dialect().HasIndex("posts", "idx_posts_normalized_digest"), "index of normalized digest is created")
+	assert.True(t, db.HasTable("post_terms"), "post_terms is created")
+	assert.True(t, db.HasTable("post_media_hash_bands"), "post_media_hash_bands is created")
+	assert.True(t, db.Dialect().HasIndex("posts", "idx_posts_author_created_at_dna"), "keyset index of author is created")
+	assert.True(t, db.Dialect().HasIndex("accounts", "idx_accounts_created_at_name"), "keyset index of accounts is created")
+	require.NoError(t, store.Migrate(db), "migrate again")
+
+	s, err := store.NewSQLStore("sqlite3", dsn)
+	require.NoError(t, err, "store of migrated db")
+	defer s.Close()
+
+	now := time.Now()
+	require.NoError(t, s.SavePost(&model.Post{MSGID: 1, DNA: "dna-1", Author: "wb-1", Content: "a", CreatedAt: now}))
+	assert.Error(t, s.SavePost(&model.Post{MSGID: 1, DNA: "dna-2", Author: "wb-1", Content: "b", CreatedAt: now}), "unique (author, mid)")
+	assert.Error(t, db.Create(&model.Post{MSGID: 2, DNA: "dna-1", Author: "wb-1", Content: "c", CreatedAt: now}).Error, "unique dna")
+
+	require.NoError(t, store.Rollback(db, int(store.LatestVersion()-1)), "rollback to 1")
+	version, err = store.SchemaVersion(db)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), version)
+	assert.False(t, db.HasTable("post_versions"), "post_versions is dropped")
+	assert.False(t, db.HasTable("post_simhash_bands"), "post_simhash_bands is dropped")
+	assert.False(t, db.HasTable("post_terms"), "post_terms is dropped")
+	assert.False(t, db.HasTable("post_media_hash_bands"), "post_media_hash_bands is dropped")
+	assert.False(t, db.Dialect().HasIndex("posts", "idx_posts_keywords_created_at_dna"), "keyset index of keywords is removed")
+	assert.False(t, db.Dialect().HasIndex("posts", "idx_posts_normalized_digest"), "index of normalized digest is removed")
+	assert.NoError(t, db.Create(&model.Post{MSGID: 1, DNA: "dna-3", Author: "wb-1", Content: "d", CreatedAt: now}).Error, "unique index is removed")
+
+	require.NoError(t, store.Rollback(db, 10), "rollback all")
+	assert.False(t, db.HasTable("posts"), "tables are dropped")
+	applied, err := store.AppliedMigrations(db)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+}
+
+func TestMigrateBackfillsPublicKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ipc-migrate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	db, err := gorm.Open("sqlite3", filepath.Join(dir, "ipc.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, store.MigrateTo(db, 11))
+	wif, err := keys.GenerateWIF()
+	require.NoError(t, err)
+	require.NoError(t, db.Exec("INSERT INTO accounts (name, company, wif) VALUES (?, ?, ?)", "wb-1", "wb", wif.String()).Error)
+	require.NoError(t, db.Exec("INSERT INTO accounts (name, company, wif) VALUES (?, ?, ?)", "wb-2", "wb", "invalid").Error)
+
+	require.NoError(t, store.Migrate(db))
+	var a model.Account
+	require.NoError(t, db.Where("name = ?", "wb-1").First(&a).Error)
+	assert.Equal(t, wif.PublicKey().String(), a.PublicKey)
+	var invalid model.Account
+	require.NoError(t, db.Where("name = ?", "wb-2").First(&invalid).Error)
+	assert.Empty(t, invalid.PublicKey, "invalid wifs are skipped")
+}
+
+func TestMigrateMediaHashBands(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ipc-migrate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dsn := filepath.Join(dir, "ipc.db")
+	db, err := gorm.Open("sqlite3", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+
+	// the first pHash of the video was indexed as its SimHash.
+	hashes := []uint64{0x0123456789abcdef, 0xfedcba9876543210}
+	require.NoError(t, store.MigrateTo(db, 13))
+	require.NoError(t, db.Exec("INSERT INTO posts (dna, author, mid, content, digest, created_at, simhash, media_hashes) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		"dna-1", "wb-1", 1, "a", "digest", time.Now(), int64(hashes[0]), content.FormatHashes(hashes)).Error)
+	require.NoError(t, db.Exec("INSERT INTO post_simhash_bands (dna, band, value) VALUES (?, ?, ?)", "dna-1", 0, 0xcdef).Error)
+
+	require.NoError(t, store.Migrate(db))
+	s, err := store.NewSQLStore("sqlite3", dsn)
+	require.NoError(t, err)
+	defer s.Close()
+
+	posts, err := s.LookupMediaPosts([]uint64{hashes[1] ^ 0x3ff}, 0)
+	require.NoError(t, err)
+	if assert.Len(t, posts, 1, "every pHash is indexed") {
+		assert.Equal(t, "dna-1", posts[0].DNA)
+		assert.Equal(t, int64(0), posts[0].SimHash)
+	}
+	posts, err = s.LookupNearDuplicatePosts(hashes[0], 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, posts, "not a text near-duplicate")
+}
+
+func TestMigrateDuplicatedPosts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ipc-migrate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	db, err := gorm.Open("sqlite3", filepath.Join(dir, "ipc.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, store.MigrateTo(db, 1))
+	for _, dna := range []string{"dna-1", "dna-2"} {
+		require.NoError(t, db.Exec("INSERT INTO posts (dna, author, mid, content, digest, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+			dna, "wb-1", 1, "a", "digest", time.Now()).Error)
+	}
+	err = store.Migrate(db)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "(wb-1, 1) are duplicated 2 times")
+	version, err := store.SchemaVersion(db)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), version, "unique index is not added")
+
+	require.NoError(t, db.Exec("DELETE FROM posts WHERE dna = ?", "dna-2").Error)
+	require.NoError(t, store.Migrate(db))
+}
@@ -0,0 +1,418 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/weibocom/ipc/content"
+)
+
+// migrations of DBStore. Append new migrations with a greater version,
+// and never change the applied ones.
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_tables",
+		Up: func(db *gorm.DB) error {
+			if db.Dialect().GetName() == "mysql" {
+				// content may contain emoji.
+				db = db.Set("gorm:table_options", "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4")
+			}
+			// tables created by AutoMigrate before are kept and completed.
+			return db.AutoMigrate(&accountV1{}, &memberV1{}, &postV1{}).Error
+		},
+		Down: func(db *gorm.DB) error {
+			return db.DropTableIfExists(&postV1{}, &memberV1{}, &accountV1{}).Error
+		},
+	},
+	{
+		Version: 2,
+		Name:    "add_posts_author_mid_unique_index",
+		Up: func(db *gorm.DB) error {
+			// the posts saved twice before can not be told which one is kept, so they are
+			// left to be removed by hand.
+			var dup struct {
+				Author string
+				MID    int64 `gorm:"COLUMN:mid"`
+				Count  int
+			}
+			err := db.Model(&postV1{}).Select("author, mid, COUNT(*) AS count").Group("author, mid").
+				Having("COUNT(*) > 1").Limit(1).Scan(&dup).Error
+			if err == nil {
+				return fmt.Errorf("posts of (author, mid) = (%s, %d) are duplicated %d times, remove the duplicates before migrating", dup.Author, dup.MID, dup.Count)
+			}
+			if err != gorm.ErrRecordNotFound {
+				return err
+			}
+			return db.Model(&postV1{}).AddUniqueIndex("uix_posts_author_mid", "author", "mid").Error
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Dialect().RemoveIndex("posts", "uix_posts_author_mid")
+		},
+	},
+	{
+		Version: 3,
+		Name:    "make_posts_dna_index_unique",
+		Up: func(db *gorm.DB) error {
+			if db.Dialect().HasIndex("posts", "idx_dna") {
+				if err := db.Dialect().RemoveIndex("posts", "idx_dna"); err != nil {
+					return err
+				}
+			}
+			return db.Model(&postV1{}).AddUniqueIndex("uix_posts_dna", "dna").Error
+		},
+		Down: func(db *gorm.DB) error {
+			if err := db.Dialect().RemoveIndex("posts", "uix_posts_dna"); err != nil {
+				return err
+			}
+			return db.Model(&postV1{}).AddIndex("idx_dna", "dna").Error
+		},
+	},
+	{
+		Version: 4,
+		Name:    "add_posts_encryption_columns",
+		Up: func(db *gorm.DB) error {
+			// AutoMigrate only adds the missing columns.
+			return db.AutoMigrate(&postV4{}).Error
+		},
+		Down: func(db *gorm.DB) error {
+			// sqlite can not drop columns, the unused columns are kept.
+			if db.Dialect().GetName() == "sqlite3" {
+				return nil
+			}
+			return db.Model(&postV4{}).DropColumn("key_owner").DropColumn("wrapped_key").Error
+		},
+	},
+	{
+		Version: 5,
+		Name:    "add_post_versions",
+		Up: func(db *gorm.DB) error {
+			if db.Dialect().GetName() == "mysql" {
+				db = db.Set("gorm:table_options", "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4")
+			}
+			return db.AutoMigrate(&postV5{}, &postVersionV5{}).Error
+		},
+		Down: func(db *gorm.DB) error {
+			if err := db.DropTableIfExists(&postVersionV5{}).Error; err != nil {
+				return err
+			}
+			if db.Dialect().GetName() == "sqlite3" {
+				return nil
+			}
+			return db.Model(&postV5{}).DropColumn("version").DropColumn("retracted").DropColumn("retracted_at").Error
+		},
+	},
+	{
+		Version: 6,
+		Name:    "add_posts_simhash",
+		Up: func(db *gorm.DB) error {
+			// posts saved before have no fingerprint until they are saved again.
+			return db.AutoMigrate(&postV6{}, &postSimHashBandV6{}).Error
+		},
+		Down: func(db *gorm.DB) error {
+			if err := db.DropTableIfExists(&postSimHashBandV6{}).Error; err != nil {
+				return err
+			}
+			if db.Dialect().GetName() == "sqlite3" {
+				return nil
+			}
+			return db.Model(&postV6{}).DropColumn("simhash").Error
+		},
+	},
+	{
+		Version: 7,
+		Name:    "add_posts_media_hashes",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&postV7{}).Error
+		},
+		Down: func(db *gorm.DB) error {
+			if db.Dialect().GetName() == "sqlite3" {
+				return nil
+			}
+			return db.Model(&postV7{}).DropColumn("media_hashes").Error
+		},
+	},
+	{
+		Version: 8,
+		Name:    "add_posts_normalized_digest",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&postV8{}).Error
+		},
+		Down: func(db *gorm.DB) error {
+			if err := db.Dialect().RemoveIndex("posts", "idx_posts_normalized_digest"); err != nil {
+				return err
+			}
+			if db.Dialect().GetName() == "sqlite3" {
+				return nil
+			}
+			return db.Model(&postV8{}).DropColumn("normalized_digest").Error
+		},
+	},
+	{
+		Version: 9,
+		Name:    "add_posts_language",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&postV9{}).Error
+		},
+		Down: func(db *gorm.DB) error {
+			if db.Dialect().GetName() == "sqlite3" {
+				return nil
+			}
+			return db.Model(&postV9{}).DropColumn("language").Error
+		},
+	},
+	{
+		Version: 10,
+		Name:    "add_post_terms",
+		Up: func(db *gorm.DB) error {
+			if db.Dialect().GetName() == "mysql" {
+				db = db.Set("gorm:table_options", "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4")
+			}
+			// posts saved before are not searchable until they are reindexed, see client.ReindexPosts.
+			return db.AutoMigrate(&postTermV10{}).Error
+		},
+		Down: func(db *gorm.DB) error {
+			return db.DropTableIfExists(&postTermV10{}).Error
+		},
+	},
+	{
+		Version: 11,
+		Name:    "add_posts_anchor",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&postV11{}).Error
+		},
+		Down: func(db *gorm.DB) error {
+			if db.Dialect().GetName() == "sqlite3" {
+				return nil
+			}
+			for _, column := range []string{"tx_id", "block_num", "block_time"} {
+				if err := db.Model(&postV11{}).DropColumn(column).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 12,
+		Name:    "backfill_accounts_public_key",
+		Up: func(db *gorm.DB) error {
+			// accounts created before public keys are saved can not be found by them.
+			var accounts []*accountV1
+			if err := db.Where("public_key IS NULL OR public_key = ?", "").Find(&accounts).Error; err != nil {
+				return err
+			}
+			for _, a := range accounts {
+				pubKey := getPublicKey(a.WIF)
+				if pubKey == "" {
+					continue
+				}
+				if err := db.Model(&accountV1{}).Where("name = ?", a.Name).Update("public_key", pubKey).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(db *gorm.DB) error {
+			// the public keys are derived from the wifs, which are kept.
+			return nil
+		},
+	},
+	{
+		Version: 13,
+		Name:    "add_keyset_pagination_indexes",
+		Up: func(db *gorm.DB) error {
+			// the pages are ordered by created_at desc and the key, see GetPostByAuthorAfter.
+			if err := db.Model(&postV1{}).AddIndex("idx_posts_author_created_at_dna", "author", "created_at", "dna").Error; err != nil {
+				return err
+			}
+			if err := db.Model(&postV1{}).AddIndex("idx_posts_keywords_created_at_dna", "keywords", "created_at", "dna").Error; err != nil {
+				return err
+			}
+			return db.Model(&accountV1{}).AddIndex("idx_accounts_created_at_name", "created_at", "name").Error
+		},
+		Down: func(db *gorm.DB) error {
+			if err := db.Dialect().RemoveIndex("accounts", "idx_accounts_created_at_name"); err != nil {
+				return err
+			}
+			if err := db.Dialect().RemoveIndex("posts", "idx_posts_keywords_created_at_dna"); err != nil {
+				return err
+			}
+			return db.Dialect().RemoveIndex("posts", "idx_posts_author_created_at_dna")
+		},
+	},
+	{
+		Version: 14,
+		Name:    "add_post_media_hash_bands",
+		Up: func(db *gorm.DB) error {
+			if db.Dialect().GetName() == "mysql" {
+				db = db.Set("gorm:table_options", "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4")
+			}
+			if err := db.AutoMigrate(&postMediaHashBandV14{}).Error; err != nil {
+				return err
+			}
+
+			// the first pHash of media posts was indexed as their SimHash, which is moved to
+			// the media index with the others.
+			var posts []struct {
+				DNA         string
+				MediaHashes string
+			}
+			if err := db.Table("posts").Select("dna, media_hashes").Where("media_hashes <> ?", "").Scan(&posts).Error; err != nil {
+				return err
+			}
+			for _, p := range posts {
+				hashes, err := content.ParseHashes(p.MediaHashes)
+				if err != nil {
+					return fmt.Errorf("invalid media hashes of post %s: %v", p.DNA, err)
+				}
+				for i, h := range hashes {
+					for j, v := range content.SimHashBands(h, content.MediaHashBands) {
+						if err := db.Create(&postMediaHashBandV14{DNA: p.DNA, Hash: i, Band: j, Value: int64(v)}).Error; err != nil {
+							return err
+						}
+					}
+				}
+				if err := db.Where("dna = ?", p.DNA).Delete(&postSimHashBandV6{}).Error; err != nil {
+					return err
+				}
+				if err := db.Model(&postV6{}).Where("dna = ?", p.DNA).Update("simhash", 0).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(db *gorm.DB) error {
+			// the SimHash of media posts is not restored, reindex them with the former version.
+			return db.DropTableIfExists(&postMediaHashBandV14{}).Error
+		},
+	},
+}
+
+// the schema of version 1, which is the one created by AutoMigrate before migrations.
+
+type accountV1 struct {
+	Name      string    `gorm:"COLUMN:name;PRIMARY_KEY;TYPE:VARCHAR(64);NOT NULL"`
+	Company   string    `gorm:"COLUMN:company;TYPE:VARCHAR(64);NOT NULL"`
+	WIF       string    `gorm:"COLUMN:wif;TYPE:VARCHAR(128);NOT NULL"`
+	PublicKey string    `gorm:"COLUMN:public_key;TYPE:VARCHAR(128);index:idx_public_key"`
+	CreatedAt time.Time `gorm:"COLUMN:created_at;"`
+}
+
+func (accountV1) TableName() string { return "accounts" }
+
+type memberV1 struct {
+	Name       string    `gorm:"COLUMN:name;PRIMARY_KEY;TYPE:VARCHAR(64);NOT NULL"`
+	ID         int64     `gorm:"COLUMN:id;NOT NULL;unique"`
+	Company    string    `gorm:"COLUMN:company"`
+	SigningKey string    `gorm:"COLUMN:signing_key;TYPE:VARCHAR(128);NOT NULL"`
+	Wif        string    `gorm:"COLUMN:wif;TYPE:VARCHAR(128);NOT NULL"`
+	CreatedAt  time.Time `gorm:"COLUMN:created_at;"`
+}
+
+func (memberV1) TableName() string { return "members" }
+
+type postV1 struct {
+	MSGID       int64     `gorm:"COLUMN:mid;NOT NULL"`
+	DNA         string    `gorm:"COLUMN:dna;index:idx_dna;TYPE:VARCHAR(255);NOT NULL"`
+	Author      string    `gorm:"COLUMN:author;TYPE:VARCHAR(64);NOT NULL;index:idx_author"`
+	Content     string    `gorm:"COLUMN:content;TYPE:TEXT;NOT NULL"`
+	ContentType uint8     `gorm:"COLUMN:content_type"`
+	StoreType   uint8     `gorm:"COLUMN:store_type"`
+	Keywords    string    `gorm:"COLUMN:keywords;TYPE:VARCHAR(256);index:idx_keywords"`
+	Digest      string    `gorm:"COLUMN:digest;TYPE:VARCHAR(64);NOT NULL"`
+	CreatedAt   time.Time `gorm:"COLUMN:created_at;NOT NULL"`
+}
+
+func (postV1) TableName() string { return "posts" }
+
+// the columns added to posts in version 4.
+
+type postV4 struct {
+	KeyOwner   string `gorm:"COLUMN:key_owner;TYPE:VARCHAR(64)"`
+	WrappedKey string `gorm:"COLUMN:wrapped_key;TYPE:VARCHAR(512)"`
+}
+
+func (postV4) TableName() string { return "posts" }
+
+// the columns added to posts and the post_versions table in version 5.
+
+type postV5 struct {
+	Version     int        `gorm:"COLUMN:version;NOT NULL;DEFAULT:1"`
+	Retracted   bool       `gorm:"COLUMN:retracted;NOT NULL;DEFAULT:false"`
+	RetractedAt *time.Time `gorm:"COLUMN:retracted_at"`
+}
+
+func (postV5) TableName() string { return "posts" }
+
+type postVersionV5 struct {
+	DNA       string    `gorm:"COLUMN:dna;PRIMARY_KEY;TYPE:VARCHAR(255);NOT NULL"`
+	Author    string    `gorm:"COLUMN:author;TYPE:VARCHAR(64);NOT NULL;unique_index:uix_post_versions_author_mid_version"`
+	MSGID     int64     `gorm:"COLUMN:mid;NOT NULL;unique_index:uix_post_versions_author_mid_version"`
+	Version   int       `gorm:"COLUMN:version;NOT NULL;unique_index:uix_post_versions_author_mid_version"`
+	Digest    string    `gorm:"COLUMN:digest;TYPE:VARCHAR(64);NOT NULL"`
+	CreatedAt time.Time `gorm:"COLUMN:created_at;NOT NULL"`
+}
+
+func (postVersionV5) TableName() string { return "post_versions" }
+
+// the column added to posts and the post_simhash_bands table in version 6.
+
+type postV6 struct {
+	SimHash int64 `gorm:"COLUMN:simhash;NOT NULL;DEFAULT:0"`
+}
+
+func (postV6) TableName() string { return "posts" }
+
+type postSimHashBandV6 struct {
+	DNA   string `gorm:"COLUMN:dna;TYPE:VARCHAR(255);NOT NULL;index:idx_post_simhash_bands_dna"`
+	Band  int    `gorm:"COLUMN:band;NOT NULL;index:idx_post_simhash_bands_band_value"`
+	Value int64  `gorm:"COLUMN:value;NOT NULL;index:idx_post_simhash_bands_band_value"`
+}
+
+func (postSimHashBandV6) TableName() string { return "post_simhash_bands" }
+
+type postV7 struct {
+	MediaHashes string `gorm:"COLUMN:media_hashes;TYPE:TEXT"`
+}
+
+func (postV7) TableName() string { return "posts" }
+
+type postV8 struct {
+	NormalizedDigest string `gorm:"COLUMN:normalized_digest;TYPE:VARCHAR(64);index:idx_posts_normalized_digest"`
+}
+
+func (postV8) TableName() string { return "posts" }
+
+type postV9 struct {
+	Language string `gorm:"COLUMN:language;TYPE:VARCHAR(8)"`
+}
+
+func (postV9) TableName() string { return "posts" }
+
+type postTermV10 struct {
+	DNA       string `gorm:"COLUMN:dna;TYPE:VARCHAR(255);NOT NULL;index:idx_post_terms_dna"`
+	Term      string `gorm:"COLUMN:term;TYPE:VARCHAR(255);NOT NULL;index:idx_post_terms_term"`
+	Positions string `gorm:"COLUMN:positions;TYPE:TEXT;NOT NULL"`
+}
+
+func (postTermV10) TableName() string { return "post_terms" }
+
+type postV11 struct {
+	TxID      string     `gorm:"COLUMN:tx_id;TYPE:VARCHAR(64)"`
+	BlockNum  uint32     `gorm:"COLUMN:block_num;NOT NULL;DEFAULT:0"`
+	BlockTime *time.Time `gorm:"COLUMN:block_time"`
+}
+
+func (postV11) TableName() string { return "posts" }
+
+type postMediaHashBandV14 struct {
+	DNA   string `gorm:"COLUMN:dna;TYPE:VARCHAR(255);NOT NULL;index:idx_post_media_hash_bands_dna"`
+	Hash  int    `gorm:"COLUMN:hash;NOT NULL"`
+	Band  int    `gorm:"COLUMN:band;NOT NULL;index:idx_post_media_hash_bands_band_value"`
+	Value int64  `gorm:"COLUMN:value;NOT NULL;index:idx_post_media_hash_bands_band_value"`
+}
+
+func (postMediaHashBandV14) TableName() string { return "post_media_hash_bands" }
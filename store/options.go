@@ -0,0 +1,40 @@
+package store
+
+import "fmt"
+
+// DefaultSimHashBands is the number of bands which the SimHash of posts are indexed by by default.
+const DefaultSimHashBands = 4
+
+// Option configures the indexes of MemStore, DBStore and RedisStore.
+type Option func(*indexOptions)
+
+type indexOptions struct {
+	simhashBands int
+}
+
+func newIndexOptions(options []Option) indexOptions {
+	o := indexOptions{simhashBands: DefaultSimHashBands}
+	for _, opt := range options {
+		opt(&o)
+	}
+	return o
+}
+
+// maxSimHashDistance is the max distance of the SimHash of posts which can be looked up,
+// see content.SimHashBands.
+func (o indexOptions) maxSimHashDistance() int {
+	return o.simhashBands - 1
+}
+
+// SetSimHashBands sets the number of bands which the SimHash of posts are indexed by, n must be
+// a divisor of 64. Posts within a SimHash distance of n-1 can be looked up, while more bands
+// return more candidates to compare. Posts indexed with another number of bands are not found
+// until they are reindexed, see ReindexPost.
+func SetSimHashBands(n int) Option {
+	if n <= 0 || 64%n != 0 {
+		panic(fmt.Sprintf("store: %d simhash bands is not a divisor of 64", n))
+	}
+	return func(o *indexOptions) {
+		o.simhashBands = n
+	}
+}
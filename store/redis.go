@@ -0,0 +1,850 @@
+package store
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/weibocom/ipc/content"
+	"github.com/weibocom/ipc/model"
+)
+
+// RedisStore implements the Store with redis.
+//
+// Accounts and posts are saved as hashes, and indexed by sorted sets whose score is
+// the negative created_at in microseconds, so that ZRANGE returns the newest first
+// and the ones created at the same time are ordered by name or dna.
+//
+//	<prefix>:account:<name>        hash of the account
+//	<prefix>:pubkeys               hash of public key -> account name
+//	<prefix>:accounts              sorted set of all account names
+//	<prefix>:company:<company>     sorted set of account names of the company
+//	<prefix>:post:<dna>            hash of the post
+//	<prefix>:mids                  hash of author-mid -> dna
+//	<prefix>:posts                 sorted set of all post dnas
+//	<prefix>:author:<author>       sorted set of post dnas of the author
+//	<prefix>:keywords:<keywords>   sorted set of post dnas with the keywords
+//	<prefix>:simhash:<band>:<value> set of post dnas whose simhash has the value in the band
+//	<prefix>:media:<band>:<value>  set of post dnas which have a pHash of the value in the band
+//	<prefix>:term:<term>           set of post dnas whose content has the term
+//	<prefix>:terms:<dna>           hash of term -> positions of the term in the content of the post
+//	<prefix>:version:<dna>         hash of the previous version of a post
+//	<prefix>:versions:<author-mid> sorted set of previous version dnas of a post, scored by version
+type RedisStore struct {
+	prefix string
+	client *redis.Client
+	opts   indexOptions
+}
+
+var _ Store = &RedisStore{}
+
+// NewRedisStore creates a RedisStore with the redis server of addr.
+func NewRedisStore(prefix string, addr string, options ...Option) *RedisStore {
+	return NewRedisStoreWithClient(prefix, redis.NewClient(&redis.Options{Addr: addr}), options...)
+}
+
+// NewRedisStoreWithClient creates a RedisStore with the redis client.
+func NewRedisStoreWithClient(prefix string, client *redis.Client, options ...Option) *RedisStore {
+	return &RedisStore{prefix: prefix, client: client, opts: newIndexOptions(options)}
+}
+
+func (s *RedisStore) key(fields ...string) string {
+	k := s.prefix
+	for _, f := range fields {
+		k += ":" + f
+	}
+	return k
+}
+
+func score(t time.Time) float64 {
+	return -float64(t.UnixNano() / int64(time.Microsecond))
+}
+
+// rangeArgs converts offset and limit to the start and stop of ZRANGE.
+// ok is false if the range is empty.
+func rangeArgs(offset int, limit int) (start int64, stop int64, ok bool) {
+	if offset < 0 {
+		offset = 0
+	}
+	if limit == 0 {
+		return 0, 0, false
+	}
+	if limit < 0 {
+		return int64(offset), -1, true
+	}
+	return int64(offset), int64(offset + limit - 1), true
+}
+
+func (s *RedisStore) ExistAccount(name string) (bool, error) {
+	n, err := s.client.Exists(s.key("account", name)).Result()
+	return n > 0, err
+}
+
+func (s *RedisStore) SaveAccount(a *model.Account) error {
+	a.Company = getCompany(a.Name)
+	if a.PublicKey == "" {
+		a.PublicKey = getPublicKey(a.WIF)
+	}
+	a.CreatedAt = time.Now()
+
+	old, err := s.LoadAccount(a.Name)
+	if err != nil && err != ErrNonExist {
+		return err
+	}
+
+	_, err = s.client.TxPipelined(func(pipe redis.Pipeliner) error {
+		if old != nil {
+			pipe.ZRem(s.key("company", old.Company), old.Name)
+			if old.PublicKey != "" && old.PublicKey != a.PublicKey {
+				pipe.HDel(s.key("pubkeys"), old.PublicKey)
+			}
+		}
+
+		pipe.HMSet(s.key("account", a.Name), map[string]interface{}{
+			"name":       a.Name,
+			"company":    a.Company,
+			"wif":        a.WIF,
+			"public_key": a.PublicKey,
+			"created_at": a.CreatedAt.Format(time.RFC3339Nano),
+		})
+		if a.PublicKey != "" {
+			pipe.HSet(s.key("pubkeys"), a.PublicKey, a.Name)
+		}
+		z := redis.Z{Score: score(a.CreatedAt), Member: a.Name}
+		pipe.ZAdd(s.key("accounts"), z)
+		pipe.ZAdd(s.key("company", a.Company), z)
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) LoadAccount(name string) (*model.Account, error) {
+	m, err := s.client.HGetAll(s.key("account", name)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(m) == 0 {
+		return nil, ErrNonExist
+	}
+
+	a := &model.Account{
+		Name:      m["name"],
+		Company:   m["company"],
+		WIF:       m["wif"],
+		PublicKey: m["public_key"],
+	}
+	a.CreatedAt, err = time.Parse(time.RFC3339Nano, m["created_at"])
+	return a, err
+}
+
+func (s *RedisStore) LoadAccountByPublicKey(pubKey string) (*model.Account, error) {
+	name, err := s.client.HGet(s.key("pubkeys"), pubKey).Result()
+	if err == redis.Nil {
+		return nil, ErrNonExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.LoadAccount(name)
+}
+
+func (s *RedisStore) GetAccounts(company string, offset int, limit int) ([]*model.Account, error) {
+	key := s.key("accounts")
+	if company != "" {
+		key = s.key("company", company)
+	}
+
+	start, stop, ok := rangeArgs(offset, limit)
+	if !ok {
+		return nil, nil
+	}
+	names, err := s.client.ZRange(key, start, stop).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]*model.Account, 0, len(names))
+	for _, name := range names {
+		a, err := s.LoadAccount(name)
+		if err == ErrNonExist {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, nil
+}
+
+func (s *RedisStore) GetAccountsAfter(company string, after *Cursor, limit int) ([]*model.Account, *Cursor, error) {
+	key := s.key("accounts")
+	if company != "" {
+		key = s.key("company", company)
+	}
+
+	names, err := s.rangeAfter(key, after, fetchLimit(limit))
+	if err != nil {
+		return nil, nil, err
+	}
+	accounts := make([]*model.Account, 0, len(names))
+	for _, name := range names {
+		a, err := s.LoadAccount(name)
+		if err == ErrNonExist {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		accounts = append(accounts, a)
+	}
+
+	page, next := accountsPage(accounts, limit)
+	return page, next, nil
+}
+
+// rangeAfter returns at most limit members of the sorted set after the cursor, all if limit < 0.
+func (s *RedisStore) rangeAfter(key string, after *Cursor, limit int) ([]string, error) {
+	var start int64
+	if after != nil {
+		// members before the cursor: the ones with a less score, and the ones
+		// with the same score and a member not greater than the key.
+		sc := strconv.FormatFloat(score(after.CreatedAt), 'f', -1, 64)
+		n, err := s.client.ZCount(key, "-inf", "("+sc).Result()
+		if err != nil {
+			return nil, err
+		}
+		same, err := s.client.ZRangeByScore(key, redis.ZRangeBy{Min: sc, Max: sc}).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range same {
+			if m <= after.Key {
+				n++
+			}
+		}
+		start = n
+	}
+
+	if limit == 0 {
+		return nil, nil
+	}
+	stop := int64(-1)
+	if limit > 0 {
+		stop = start + int64(limit) - 1
+	}
+	return s.client.ZRange(key, start, stop).Result()
+}
+
+func (s *RedisStore) GetAccountCount() (int, error) {
+	n, err := s.client.ZCard(s.key("accounts")).Result()
+	return int(n), err
+}
+
+func (s *RedisStore) GetPostCount() (int, error) {
+	n, err := s.client.ZCard(s.key("posts")).Result()
+	return int(n), err
+}
+
+func (s *RedisStore) GetAccountPostCount(name string) (int, error) {
+	n, err := s.client.ZCard(s.key("author", name)).Result()
+	return int(n), err
+}
+
+func (s *RedisStore) ExistPost(dna model.DNA) (bool, error) {
+	n, err := s.client.Exists(s.key("post", dna.String())).Result()
+	return n > 0, err
+}
+
+func (s *RedisStore) SavePost(p *model.Post) error {
+	preparePost(p)
+
+	old, err := s.GetPostByDNA(model.DNA(p.DNA))
+	if err != nil && err != ErrNonExist {
+		return err
+	}
+	if err := samePost(old, p); err != nil {
+		return err
+	}
+	oldTerms, err := s.postTerms(old)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.TxPipelined(func(pipe redis.Pipeliner) error {
+		if old != nil {
+			s.unindexPost(pipe, old, oldTerms)
+		}
+		s.indexPost(pipe, p)
+		return nil
+	})
+	return err
+}
+
+// postTerms returns the indexed terms of p, or nil if p is nil. They are not extracted from
+// the content again, as the segmentation may be changed since p is indexed.
+func (s *RedisStore) postTerms(p *model.Post) ([]string, error) {
+	if p == nil {
+		return nil, nil
+	}
+	return s.client.HKeys(s.key("terms", p.DNA)).Result()
+}
+
+// unindexPost removes post p and its indexes in pipe, terms are the ones returned by postTerms.
+func (s *RedisStore) unindexPost(pipe redis.Pipeliner, p *model.Post, terms []string) {
+	pipe.Del(s.key("post", p.DNA))
+	pipe.ZRem(s.key("posts"), p.DNA)
+	pipe.ZRem(s.key("author", p.Author), p.DNA)
+	pipe.ZRem(s.key("keywords", p.Keywords), p.DNA)
+	pipe.HDel(s.key("mids"), msgKey(p.Author, p.MSGID))
+	for _, k := range s.simhashKeys(uint64(p.SimHash)) {
+		pipe.SRem(k, p.DNA)
+	}
+	for _, k := range s.mediaKeys(mediaHashes(p)...) {
+		pipe.SRem(k, p.DNA)
+	}
+	for _, t := range terms {
+		pipe.SRem(s.key("term", t), p.DNA)
+	}
+	pipe.Del(s.key("terms", p.DNA))
+}
+
+// simhashKeys returns the keys of the bands of simhash, or nil if simhash is 0.
+func (s *RedisStore) simhashKeys(simhash uint64) []string {
+	if simhash == 0 {
+		return nil
+	}
+	bands := content.SimHashBands(simhash, s.opts.simhashBands)
+	keys := make([]string, len(bands))
+	for i, b := range bands {
+		keys[i] = s.key("simhash", strconv.Itoa(i), strconv.FormatUint(b, 10))
+	}
+	return keys
+}
+
+// mediaKeys returns the keys of the bands of the pHashes, see content.MediaHashBands.
+func (s *RedisStore) mediaKeys(hashes ...uint64) []string {
+	var keys []string
+	for _, h := range hashes {
+		for i, b := range content.SimHashBands(h, content.MediaHashBands) {
+			keys = append(keys, s.key("media", strconv.Itoa(i), strconv.FormatUint(b, 10)))
+		}
+	}
+	return keys
+}
+
+// indexPost saves post p and its indexes in pipe.
+func (s *RedisStore) indexPost(pipe redis.Pipeliner, p *model.Post) {
+	fields := map[string]interface{}{
+		"mid":               p.MSGID,
+		"dna":               p.DNA,
+		"author":            p.Author,
+		"content":           p.Content,
+		"content_type":      p.ContentType,
+		"store_type":        p.StoreType,
+		"key_owner":         p.KeyOwner,
+		"wrapped_key":       p.WrappedKey,
+		"keywords":          p.Keywords,
+		"language":          p.Language,
+		"simhash":           p.SimHash,
+		"media_hashes":      p.MediaHashes,
+		"digest":            p.Digest,
+		"normalized_digest": p.NormalizedDigest,
+		"version":           p.Version,
+		"retracted":         strconv.FormatBool(p.Retracted),
+		"created_at":        p.CreatedAt.Format(time.RFC3339Nano),
+	}
+	if p.RetractedAt != nil {
+		fields["retracted_at"] = p.RetractedAt.Format(time.RFC3339Nano)
+	}
+	if p.BlockTime != nil {
+		fields["tx_id"] = p.TxID
+		fields["block_num"] = p.BlockNum
+		fields["block_time"] = p.BlockTime.Format(time.RFC3339Nano)
+	}
+	pipe.HMSet(s.key("post", p.DNA), fields)
+	pipe.HSet(s.key("mids"), msgKey(p.Author, p.MSGID), p.DNA)
+	z := redis.Z{Score: score(p.CreatedAt), Member: p.DNA}
+	pipe.ZAdd(s.key("posts"), z)
+	pipe.ZAdd(s.key("author", p.Author), z)
+	pipe.ZAdd(s.key("keywords", p.Keywords), z)
+	for _, k := range s.simhashKeys(uint64(p.SimHash)) {
+		pipe.SAdd(k, p.DNA)
+	}
+	for _, k := range s.mediaKeys(mediaHashes(p)...) {
+		pipe.SAdd(k, p.DNA)
+	}
+	if positions := content.TermPositions(searchTokens(p)); len(positions) > 0 {
+		terms := make(map[string]interface{}, len(positions))
+		for t, ps := range positions {
+			terms[t] = formatPositions(ps)
+			pipe.SAdd(s.key("term", t), p.DNA)
+		}
+		pipe.HMSet(s.key("terms", p.DNA), terms)
+	}
+}
+
+func (s *RedisStore) UpdatePost(dna model.DNA, p *model.Post) error {
+	old, err := s.GetPostByDNA(dna)
+	if err != nil {
+		return err
+	}
+	v := nextVersion(old, p)
+	other, err := s.GetPostByDNA(model.DNA(p.DNA))
+	if err != nil && err != ErrNonExist {
+		return err
+	}
+	if err := samePost(other, p); err != nil {
+		return err
+	}
+	preparePost(p)
+	oldTerms, err := s.postTerms(old)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HMSet(s.key("version", v.DNA), map[string]interface{}{
+			"dna":        v.DNA,
+			"author":     v.Author,
+			"mid":        v.MSGID,
+			"version":    v.Version,
+			"digest":     v.Digest,
+			"created_at": v.CreatedAt.Format(time.RFC3339Nano),
+		})
+		pipe.ZAdd(s.key("versions", msgKey(v.Author, v.MSGID)), redis.Z{Score: float64(v.Version), Member: v.DNA})
+
+		s.unindexPost(pipe, old, oldTerms)
+		s.indexPost(pipe, p)
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) ReindexPost(p *model.Post) error {
+	old, err := s.GetPostByDNA(model.DNA(p.DNA))
+	if err != nil {
+		return err
+	}
+	terms, err := s.postTerms(old)
+	if err != nil {
+		return err
+	}
+	cp := *old
+	setFingerprints(&cp, p)
+
+	_, err = s.client.TxPipelined(func(pipe redis.Pipeliner) error {
+		s.unindexPost(pipe, old, terms)
+		s.indexPost(pipe, &cp)
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) SetPostAnchor(dna model.DNA, txID string, blockNum uint32, blockTime time.Time) error {
+	key := s.key("post", dna.String())
+	n, err := s.client.Exists(key).Result()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNonExist
+	}
+	return s.client.HMSet(key, map[string]interface{}{
+		"tx_id":      txID,
+		"block_num":  blockNum,
+		"block_time": blockTime.Format(time.RFC3339Nano),
+	}).Err()
+}
+
+func (s *RedisStore) GetPostVersions(author string, mid int64) ([]*model.PostVersion, error) {
+	dnas, err := s.client.ZRange(s.key("versions", msgKey(author, mid)), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]*model.PostVersion, 0, len(dnas))
+	for _, dna := range dnas {
+		m, err := s.client.HGetAll(s.key("version", dna)).Result()
+		if err != nil {
+			return nil, err
+		}
+		if len(m) == 0 {
+			continue
+		}
+
+		v := &model.PostVersion{
+			DNA:    m["dna"],
+			Author: m["author"],
+			Digest: m["digest"],
+		}
+		if v.MSGID, err = strconv.ParseInt(m["mid"], 10, 64); err != nil {
+			return nil, err
+		}
+		if v.Version, err = strconv.Atoi(m["version"]); err != nil {
+			return nil, err
+		}
+		if v.CreatedAt, err = time.Parse(time.RFC3339Nano, m["created_at"]); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+func (s *RedisStore) LoadPost(dna model.DNA) (*model.Post, error) {
+	return s.GetPostByDNA(dna)
+}
+
+func (s *RedisStore) GetLatestPost() (*model.Post, error) {
+	dnas, err := s.client.ZRange(s.key("posts"), 0, 0).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(dnas) == 0 {
+		return nil, ErrNonExist
+	}
+	return s.GetPostByDNA(model.DNA(dnas[0]))
+}
+
+func (s *RedisStore) GetPostByMsgID(author string, mid int64) (*model.Post, error) {
+	dna, err := s.client.HGet(s.key("mids"), msgKey(author, mid)).Result()
+	if err == redis.Nil {
+		return nil, ErrNonExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPostByDNA(model.DNA(dna))
+}
+
+func (s *RedisStore) GetPostByDNA(dna model.DNA) (*model.Post, error) {
+	m, err := s.client.HGetAll(s.key("post", dna.String())).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(m) == 0 {
+		return nil, ErrNonExist
+	}
+	return parsePost(m)
+}
+
+func parsePost(m map[string]string) (*model.Post, error) {
+	p := &model.Post{
+		DNA:              m["dna"],
+		Author:           m["author"],
+		Content:          m["content"],
+		KeyOwner:         m["key_owner"],
+		WrappedKey:       m["wrapped_key"],
+		Keywords:         m["keywords"],
+		Language:         m["language"],
+		MediaHashes:      m["media_hashes"],
+		Digest:           m["digest"],
+		NormalizedDigest: m["normalized_digest"],
+	}
+
+	var err error
+	if p.MSGID, err = strconv.ParseInt(m["mid"], 10, 64); err != nil {
+		return nil, err
+	}
+	ct, err := strconv.ParseUint(m["content_type"], 10, 8)
+	if err != nil {
+		return nil, err
+	}
+	p.ContentType = uint8(ct)
+	st, err := strconv.ParseUint(m["store_type"], 10, 8)
+	if err != nil {
+		return nil, err
+	}
+	p.StoreType = uint8(st)
+	// posts saved before versions are the first version.
+	if v, ok := m["version"]; ok {
+		if p.Version, err = strconv.Atoi(v); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := m["simhash"]; ok {
+		if p.SimHash, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := m["retracted"]; ok {
+		if p.Retracted, err = strconv.ParseBool(v); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := m["retracted_at"]; ok {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return nil, err
+		}
+		p.RetractedAt = &t
+	}
+	if v, ok := m["block_time"]; ok {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return nil, err
+		}
+		p.BlockTime = &t
+		p.TxID = m["tx_id"]
+		n, err := strconv.ParseUint(m["block_num"], 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		p.BlockNum = uint32(n)
+	}
+	p.CreatedAt, err = time.Parse(time.RFC3339Nano, m["created_at"])
+	return p, err
+}
+
+func (s *RedisStore) getPosts(dnas []string) ([]*model.Post, error) {
+	posts := make([]*model.Post, 0, len(dnas))
+	for _, dna := range dnas {
+		p, err := s.GetPostByDNA(model.DNA(dna))
+		if err == ErrNonExist {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, p)
+	}
+	return posts, nil
+}
+
+func (s *RedisStore) GetPostsAfter(after *Cursor, limit int) ([]*model.Post, *Cursor, error) {
+	dnas, err := s.rangeAfter(s.key("posts"), after, fetchLimit(limit))
+	if err != nil {
+		return nil, nil, err
+	}
+	posts, err := s.getPosts(dnas)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	page, next := postsPage(posts, limit)
+	return page, next, nil
+}
+
+func (s *RedisStore) GetPostByAuthor(author string, offset int, limit int) ([]*model.Post, error) {
+	start, stop, ok := rangeArgs(offset, limit)
+	if !ok {
+		return nil, nil
+	}
+	dnas, err := s.client.ZRange(s.key("author", author), start, stop).Result()
+	if err != nil {
+		return nil, err
+	}
+	return s.getPosts(dnas)
+}
+
+func (s *RedisStore) GetPostByAuthorAfter(author string, after *Cursor, limit int) ([]*model.Post, *Cursor, error) {
+	dnas, err := s.rangeAfter(s.key("author", author), after, fetchLimit(limit))
+	if err != nil {
+		return nil, nil, err
+	}
+	posts, err := s.getPosts(dnas)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	page, next := postsPage(posts, limit)
+	return page, next, nil
+}
+
+func (s *RedisStore) LookupSimilarPostsAfter(dna string, keywords string, after *Cursor, limit int) ([]*model.Post, *Cursor, error) {
+	// fetch one more in case dna itself is in the page.
+	n := fetchLimit(limit)
+	if n > 0 {
+		n++
+	}
+	dnas, err := s.rangeAfter(s.key("keywords", keywords), after, n)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	others := make([]string, 0, len(dnas))
+	for _, v := range dnas {
+		if v != dna {
+			others = append(others, v)
+		}
+	}
+	posts, err := s.getPosts(others)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	page, next := postsPage(posts, limit)
+	return page, next, nil
+}
+
+// LookupSimilarPosts returns the posts with the same keywords, except the one of dna.
+func (s *RedisStore) LookupSimilarPosts(dna string, keywords string, offset int, limit int) ([]*model.Post, error) {
+	key := s.key("keywords", keywords)
+
+	// skip dna itself if it is before the page.
+	if offset > 0 {
+		rank, err := s.client.ZRank(key, dna).Result()
+		if err != nil && err != redis.Nil {
+			return nil, err
+		}
+		if err == nil && int(rank) < offset {
+			offset++
+		}
+	}
+
+	// fetch one more in case dna itself is in the page.
+	start, stop, ok := rangeArgs(offset, limit)
+	if !ok {
+		return nil, nil
+	}
+	if stop >= 0 {
+		stop++
+	}
+	dnas, err := s.client.ZRange(key, start, stop).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	others := make([]string, 0, len(dnas))
+	for _, v := range dnas {
+		if v != dna {
+			others = append(others, v)
+		}
+	}
+	if limit > 0 && len(others) > limit {
+		others = others[:limit]
+	}
+	return s.getPosts(others)
+}
+
+func (s *RedisStore) LookupNearDuplicatePosts(simhash uint64, maxDistance int, limit int) ([]*model.Post, error) {
+	if simhash == 0 {
+		return nil, nil
+	}
+	dnas, err := s.client.SUnion(s.simhashKeys(simhash)...).Result()
+	if err != nil {
+		return nil, err
+	}
+	posts, err := s.getPosts(dnas)
+	if err != nil {
+		return nil, err
+	}
+	return nearDuplicates(posts, simhash, maxDistance, limit), nil
+}
+
+func (s *RedisStore) LookupMediaPosts(hashes []uint64, limit int) ([]*model.Post, error) {
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+	dnas, err := s.client.SUnion(s.mediaKeys(hashes...)...).Result()
+	if err != nil {
+		return nil, err
+	}
+	posts, err := s.getPosts(dnas)
+	if err != nil {
+		return nil, err
+	}
+	return nearMedia(posts, hashes, limit), nil
+}
+
+func (s *RedisStore) MaxSimHashDistance() int {
+	return s.opts.maxSimHashDistance()
+}
+
+func (s *RedisStore) SearchPosts(q *SearchQuery, offset int, limit int) ([]*model.Post, error) {
+	query, err := content.ParseQuery(q.Text)
+	if err != nil {
+		return nil, err
+	}
+
+	// candidates have all the terms of a group, and are matched by the positions of the terms.
+	candidates := make(map[string]bool)
+	for _, terms := range query.Terms() {
+		keys := make([]string, len(terms))
+		for i, t := range terms {
+			keys[i] = s.key("term", t)
+		}
+		dnas, err := s.client.SInter(keys...).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, dna := range dnas {
+			candidates[dna] = true
+		}
+	}
+
+	tokens := query.Tokens()
+	var dnas []string
+	for dna := range candidates {
+		values, err := s.client.HMGet(s.key("terms", dna), tokens...).Result()
+		if err != nil {
+			return nil, err
+		}
+		positions := make(map[string][]int, len(tokens))
+		for i, v := range values {
+			if v, ok := v.(string); ok {
+				if positions[tokens[i]], err = parsePositions(v); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if query.Match(positions) {
+			dnas = append(dnas, dna)
+		}
+	}
+
+	posts, err := s.getPosts(dnas)
+	if err != nil {
+		return nil, err
+	}
+	return searchResults(posts, q, offset, limit), nil
+}
+
+func (s *RedisStore) LookupPostsByTerms(terms []string, limit int) ([]*model.Post, error) {
+	seen := make(map[string]bool, len(terms))
+	var cmds []*redis.StringSliceCmd
+	_, err := s.client.Pipelined(func(pipe redis.Pipeliner) error {
+		for _, t := range terms {
+			if !seen[t] {
+				seen[t] = true
+				cmds = append(cmds, pipe.SMembers(s.key("term", t)))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	shared := make(map[string]int)
+	for _, cmd := range cmds {
+		for _, dna := range cmd.Val() {
+			shared[dna]++
+		}
+	}
+	dnas := make([]string, 0, len(shared))
+	for dna := range shared {
+		dnas = append(dnas, dna)
+	}
+	sort.Slice(dnas, func(i, j int) bool {
+		if shared[dnas[i]] != shared[dnas[j]] {
+			return shared[dnas[i]] > shared[dnas[j]]
+		}
+		return dnas[i] < dnas[j]
+	})
+	if limit > 0 && len(dnas) > limit {
+		dnas = dnas[:limit]
+	}
+
+	posts, err := s.getPosts(dnas)
+	if err != nil {
+		return nil, err
+	}
+	return mostShared(posts, shared, limit), nil
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
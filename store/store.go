@@ -2,8 +2,12 @@ package store
 
 import (
 	"errors"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/weibocom/ipc/content"
+	"github.com/weibocom/ipc/keys"
 	"github.com/weibocom/ipc/model"
 )
 
@@ -23,21 +27,177 @@ type Account interface {
 	ExistAccount(name string) (bool, error)
 	SaveAccount(a *model.Account) error
 	LoadAccount(name string) (*model.Account, error)
+	LoadAccountByPublicKey(pubKey string) (*model.Account, error)
 	GetAccounts(company string, offset int, limit int) ([]*model.Account, error)
+	// GetAccountsAfter returns the page of accounts after the cursor, and the cursor of the next page.
+	GetAccountsAfter(company string, after *Cursor, limit int) ([]*model.Account, *Cursor, error)
 	GetAccountCount() (int, error)
 }
 
 type Post interface {
 	GetPostCount() (int, error)
 	ExistPost(dna model.DNA) (bool, error)
+	// SavePost saves p, and replaces the post with the same dna if it is of the same author and mid.
+	// It returns ErrExist if the dna belongs to the post of another author or mid.
 	SavePost(p *model.Post) error
 	LoadPost(dna model.DNA) (*model.Post, error)
 	GetLatestPost() (*model.Post, error)
 	GetPostByMsgID(author string, mid int64) (*model.Post, error)
 	GetPostByDNA(dna model.DNA) (*model.Post, error)
 	GetPostByAuthor(author string, offset int, limit int) ([]*model.Post, error)
+	// GetPostByAuthorAfter returns the page of posts after the cursor, and the cursor of the next page.
+	GetPostByAuthorAfter(author string, after *Cursor, limit int) ([]*model.Post, *Cursor, error)
 	LookupSimilarPosts(dna string, keywords string, offset int, limit int) ([]*model.Post, error)
+	// LookupSimilarPostsAfter returns the page of similar posts after the cursor, and the cursor of the next page.
+	LookupSimilarPostsAfter(dna string, keywords string, after *Cursor, limit int) ([]*model.Post, *Cursor, error)
 	GetAccountPostCount(name string) (int, error)
+	// UpdatePost replaces the post of dna with p, which is the next version of the same author and mid,
+	// and keeps the replaced post as a PostVersion. It returns ErrNonExist if the post of dna does not exist,
+	// and ErrExist if the dna of p belongs to another post.
+	UpdatePost(dna model.DNA, p *model.Post) error
+	// GetPostVersions returns the previous versions of the post of author and mid, ordered by version.
+	GetPostVersions(author string, mid int64) ([]*model.PostVersion, error)
+	// LookupNearDuplicatePosts returns at most limit posts whose SimHash is within maxDistance of simhash,
+	// ordered by distance and then by created_at desc. A maxDistance greater than MaxSimHashDistance
+	// may miss posts, see content.SimHashBands.
+	LookupNearDuplicatePosts(simhash uint64, maxDistance int, limit int) ([]*model.Post, error)
+	// MaxSimHashDistance returns the max distance of LookupNearDuplicatePosts which finds all posts,
+	// see SetSimHashBands.
+	MaxSimHashDistance() int
+	// LookupMediaPosts returns at most limit media posts which have a pHash within
+	// content.MediaMatchDistance of any of hashes, ordered by the distance of the nearest pHashes
+	// and then by created_at desc. Every pHash of the keyframes of a video is indexed.
+	LookupMediaPosts(hashes []uint64, limit int) ([]*model.Post, error)
+	// GetPostsAfter returns the page of all posts after the cursor, and the cursor of the next page.
+	GetPostsAfter(after *Cursor, limit int) ([]*model.Post, *Cursor, error)
+	// ReindexPost saves the fingerprints of p, which are the keywords, the language, the SimHash,
+	// the media hashes, the normalized digest and the search terms, to the post of p.DNA, and
+	// rebuilds the indexes of it. The other fields of the post are kept. It returns ErrNonExist if the post does not exist.
+	ReindexPost(p *model.Post) error
+	// SetPostAnchor sets the transaction and the block which record the post of dna on chain. The
+	// other fields of the post are kept. It returns ErrNonExist if the post does not exist.
+	SetPostAnchor(dna model.DNA, txID string, blockNum uint32, blockTime time.Time) error
+	// SearchPosts returns the page of posts whose contents match the full-text query q, ordered by
+	// created_at desc. A negative limit means no limit. It returns content.ErrEmptyQuery or
+	// content.ErrUnclosedQuote if the query text is invalid.
+	SearchPosts(q *SearchQuery, offset int, limit int) ([]*model.Post, error)
+	// LookupPostsByTerms returns at most limit posts which have the most of the distinct terms in
+	// the full-text index, see content.SearchTokens, ordered by the number of the terms and then by
+	// created_at desc. Unlike SearchPosts, the posts need not have all the terms. Which of the posts
+	// of the same number of terms are cut by limit is up to the store. A non-positive limit means no limit.
+	LookupPostsByTerms(terms []string, limit int) ([]*model.Post, error)
+}
+
+// SearchQuery is a full-text search of posts.
+type SearchQuery struct {
+	// Text is the query of the contents, see content.ParseQuery.
+	Text string
+	// Company and Author restrict the posts to the ones of the company or the author if they are set.
+	Company string
+	Author  string
+	// Since and Until restrict the posts to the ones created in [Since, Until) if they are not zero.
+	Since time.Time
+	Until time.Time
+}
+
+// filter returns true if p is in the company, of the author and in the time range of q.
+func (q *SearchQuery) filter(p *model.Post) bool {
+	switch {
+	case q.Company != "" && getCompany(p.Author) != q.Company:
+		return false
+	case q.Author != "" && p.Author != q.Author:
+		return false
+	case !q.Since.IsZero() && p.CreatedAt.Before(q.Since):
+		return false
+	case !q.Until.IsZero() && !p.CreatedAt.Before(q.Until):
+		return false
+	}
+	return true
+}
+
+// searchTokens returns the tokens of p to index for full-text search, which are p.Terms if they
+// are given or the ones of the content. Media and retracted posts are not indexed, and neither
+// are encrypted posts, whose terms would reveal the content.
+func searchTokens(p *model.Post) []string {
+	if p.WrappedKey != "" || p.MediaHashes != "" || p.Retracted {
+		return nil
+	}
+	if p.Terms != nil {
+		return p.Terms
+	}
+	return content.SearchTokens(p.Content)
+}
+
+// searchResults returns the page of posts which match q, ordered by created_at desc.
+func searchResults(posts []*model.Post, q *SearchQuery, offset int, limit int) []*model.Post {
+	result := make([]*model.Post, 0, len(posts))
+	for _, p := range posts {
+		if q.filter(p) {
+			result = append(result, p)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return postLess(result[i], result[j])
+	})
+	start, end := page(len(result), offset, limit)
+	return result[start:end]
+}
+
+// mediaHashes returns the pHashes of p, or nil if p is not a media post or they are invalid.
+func mediaHashes(p *model.Post) []uint64 {
+	hashes, err := content.ParseHashes(p.MediaHashes)
+	if err != nil {
+		return nil
+	}
+	return hashes
+}
+
+// nearMedia returns at most limit posts which have a pHash within content.MediaMatchDistance of
+// any of hashes, ordered by the distance of the nearest pHashes and then by created_at desc.
+// A limit <= 0 means all posts.
+func nearMedia(posts []*model.Post, hashes []uint64, limit int) []*model.Post {
+	distances := make(map[*model.Post]int, len(posts))
+	result := make([]*model.Post, 0, len(posts))
+	for _, p := range posts {
+		nearest := content.MediaMatchDistance + 1
+		for _, a := range mediaHashes(p) {
+			for _, b := range hashes {
+				if d := content.HammingDistance(a, b); d < nearest {
+					nearest = d
+				}
+			}
+		}
+		if nearest <= content.MediaMatchDistance {
+			distances[p] = nearest
+			result = append(result, p)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if distances[result[i]] != distances[result[j]] {
+			return distances[result[i]] < distances[result[j]]
+		}
+		return postLess(result[i], result[j])
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// mostShared returns at most limit posts, ordered by the number of terms they share in shared
+// and then by created_at desc.
+func mostShared(posts []*model.Post, shared map[string]int, limit int) []*model.Post {
+	sort.Slice(posts, func(i, j int) bool {
+		if shared[posts[i].DNA] != shared[posts[j].DNA] {
+			return shared[posts[i].DNA] > shared[posts[j].DNA]
+		}
+		return postLess(posts[i], posts[j])
+	})
+	if limit > 0 && len(posts) > limit {
+		posts = posts[:limit]
+	}
+	return posts
 }
 
 func getCompany(name string) string {
@@ -48,3 +208,128 @@ func getCompany(name string) string {
 
 	return ""
 }
+
+// getPublicKey returns the public key (with address prefix) of the given wif,
+// or an empty string if the wif is invalid.
+func getPublicKey(wif string) string {
+	w, err := keys.DecodeWIF(wif)
+	if err != nil {
+		return ""
+	}
+	return w.PublicKey().String()
+}
+
+// samePost returns ErrExist if old, the post saved with the dna of p, is not the one of the
+// author and mid of p. The same content of an author has the same dna, so it can not be
+// saved for two mids.
+func samePost(old *model.Post, p *model.Post) error {
+	if old != nil && (old.Author != p.Author || old.MSGID != p.MSGID) {
+		return ErrExist
+	}
+	return nil
+}
+
+// nextVersion sets the version of p, the next version of old, and returns the version
+// which keeps old. Posts saved without a version are the first version.
+func nextVersion(old *model.Post, p *model.Post) *model.PostVersion {
+	v := &model.PostVersion{
+		DNA:       old.DNA,
+		Author:    old.Author,
+		MSGID:     old.MSGID,
+		Version:   old.Version,
+		Digest:    old.Digest,
+		CreatedAt: old.CreatedAt,
+	}
+	if v.Version == 0 {
+		v.Version = 1
+	}
+	p.Author = old.Author
+	p.MSGID = old.MSGID
+	p.Version = v.Version + 1
+	return v
+}
+
+// preparePost extracts the keywords, detects the language and computes the SimHash of p
+// unless they are given, e.g. by the client for posts whose content is stored out of the
+// store. Media posts are fingerprinted by their pHashes only, and retracted posts are not
+// fingerprinted, so they are not found as similar posts.
+func preparePost(p *model.Post) {
+	if p.Retracted {
+		clearFingerprints(p)
+		return
+	}
+	if p.MediaHashes != "" {
+		return
+	}
+	if p.Keywords == "" {
+		if p.Language == "" {
+			p.Language = content.DetectLanguage(p.Content)
+		}
+		p.Keywords = ExtractKeywords(p.Content)
+	}
+	if p.SimHash == 0 {
+		p.SimHash = int64(content.SimHash(p.Content))
+	}
+}
+
+// setFingerprints copies the fingerprints of p to post, see ReindexPost. The fingerprints of
+// retracted posts are cleared instead.
+func setFingerprints(post *model.Post, p *model.Post) {
+	if post.Retracted {
+		clearFingerprints(post)
+		return
+	}
+	post.Terms = p.Terms
+	post.Keywords = p.Keywords
+	post.Language = p.Language
+	post.SimHash = p.SimHash
+	post.MediaHashes = p.MediaHashes
+	post.NormalizedDigest = p.NormalizedDigest
+}
+
+// clearFingerprints removes the fingerprints of p.
+func clearFingerprints(p *model.Post) {
+	p.Terms = nil
+	p.Keywords = ""
+	p.Language = ""
+	p.SimHash = 0
+	p.MediaHashes = ""
+	p.NormalizedDigest = ""
+}
+
+// nearDuplicates returns at most limit posts within maxDistance of simhash, ordered by
+// distance and then by created_at desc. A limit <= 0 means all posts.
+func nearDuplicates(posts []*model.Post, simhash uint64, maxDistance int, limit int) []*model.Post {
+	distances := make(map[*model.Post]int, len(posts))
+	result := make([]*model.Post, 0, len(posts))
+	for _, p := range posts {
+		if p.SimHash == 0 {
+			continue
+		}
+		if d := content.HammingDistance(simhash, uint64(p.SimHash)); d <= maxDistance {
+			distances[p] = d
+			result = append(result, p)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if distances[result[i]] != distances[result[j]] {
+			return distances[result[i]] < distances[result[j]]
+		}
+		return postLess(result[i], result[j])
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// ExtractKeywords returns the sorted top keywords of content joined by comma,
+// which are used to lookup similar posts.
+// Stores extract the keywords of saved posts unless they are given, e.g. by the
+// client for posts whose content is stored out of the store.
+func ExtractKeywords(c string) string {
+	keywords := content.Extract(c, 6)
+	sort.Strings(keywords)
+	return strings.Join(keywords, ",")
+}
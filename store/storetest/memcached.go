@@ -0,0 +1,257 @@
+package storetest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemcachedServer is an in-process memcached stand-in speaking the text protocol,
+// so that memcached backed stores can be tested without a memcached server.
+// It supports get, gets, set, add, replace, cas, delete, touch, incr, decr, flush_all and version.
+type MemcachedServer struct {
+	ln net.Listener
+
+	mu    sync.Mutex
+	items map[string]*memcachedItem
+	cas   uint64
+	wg    sync.WaitGroup
+}
+
+type memcachedItem struct {
+	value     []byte
+	flags     uint32
+	cas       uint64
+	expiresAt time.Time
+}
+
+// NewMemcachedServer starts a memcached stand-in listening on a random local port.
+func NewMemcachedServer() (*MemcachedServer, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &MemcachedServer{ln: ln, items: make(map[string]*memcachedItem)}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Addr returns the address the server listens on.
+func (s *MemcachedServer) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Close stops the server.
+func (s *MemcachedServer) Close() error {
+	err := s.ln.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *MemcachedServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+// expiration converts the exptime of the protocol, which is either
+// seconds relative to now (at most 30 days) or an unix timestamp.
+func expiration(exptime int64) time.Time {
+	switch {
+	case exptime == 0:
+		return time.Time{}
+	case exptime < 0:
+		return time.Now()
+	case exptime <= 60*60*24*30:
+		return time.Now().Add(time.Duration(exptime) * time.Second)
+	default:
+		return time.Unix(exptime, 0)
+	}
+}
+
+// get returns the item of key, s.mu must be held.
+func (s *MemcachedServer) get(key string) *memcachedItem {
+	it, ok := s.items[key]
+	if !ok {
+		return nil
+	}
+	if !it.expiresAt.IsZero() && !time.Now().Before(it.expiresAt) {
+		delete(s.items, key)
+		return nil
+	}
+	return it
+}
+
+func (s *MemcachedServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		var reply string
+		switch cmd := fields[0]; cmd {
+		case "get", "gets":
+			s.mu.Lock()
+			for _, key := range fields[1:] {
+				it := s.get(key)
+				if it == nil {
+					continue
+				}
+				if cmd == "gets" {
+					fmt.Fprintf(w, "VALUE %s %d %d %d\r\n", key, it.flags, len(it.value), it.cas)
+				} else {
+					fmt.Fprintf(w, "VALUE %s %d %d\r\n", key, it.flags, len(it.value))
+				}
+				w.Write(it.value)
+				w.WriteString("\r\n")
+			}
+			s.mu.Unlock()
+			reply = "END"
+		case "set", "add", "replace", "cas":
+			reply, err = s.store(cmd, fields[1:], r)
+			if err != nil {
+				return
+			}
+		case "delete":
+			reply = "NOT_FOUND"
+			s.mu.Lock()
+			if len(fields) > 1 && s.get(fields[1]) != nil {
+				delete(s.items, fields[1])
+				reply = "DELETED"
+			}
+			s.mu.Unlock()
+		case "touch":
+			reply = "NOT_FOUND"
+			if len(fields) < 3 {
+				reply = "ERROR"
+				break
+			}
+			exptime, _ := strconv.ParseInt(fields[2], 10, 64)
+			s.mu.Lock()
+			if it := s.get(fields[1]); it != nil {
+				it.expiresAt = expiration(exptime)
+				reply = "TOUCHED"
+			}
+			s.mu.Unlock()
+		case "incr", "decr":
+			reply = s.incr(cmd, fields[1:])
+		case "flush_all":
+			s.mu.Lock()
+			s.items = make(map[string]*memcachedItem)
+			s.mu.Unlock()
+			reply = "OK"
+		case "version":
+			reply = "VERSION 1.4.0-storetest"
+		default:
+			reply = "ERROR"
+		}
+
+		w.WriteString(reply + "\r\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// store handles `<cmd> <key> <flags> <exptime> <bytes> [<cas unique>] [noreply]`.
+func (s *MemcachedServer) store(cmd string, args []string, r *bufio.Reader) (string, error) {
+	if len(args) < 4 || (cmd == "cas" && len(args) < 5) {
+		return "ERROR", nil
+	}
+	flags, _ := strconv.ParseUint(args[1], 10, 32)
+	exptime, _ := strconv.ParseInt(args[2], 10, 64)
+	size, err := strconv.Atoi(args[3])
+	if err != nil || size < 0 {
+		return "CLIENT_ERROR bad data chunk", nil
+	}
+
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := args[0]
+	old := s.get(key)
+	switch cmd {
+	case "add":
+		if old != nil {
+			return "NOT_STORED", nil
+		}
+	case "replace":
+		if old == nil {
+			return "NOT_STORED", nil
+		}
+	case "cas":
+		if old == nil {
+			return "NOT_FOUND", nil
+		}
+		if cas, _ := strconv.ParseUint(args[4], 10, 64); cas != old.cas {
+			return "EXISTS", nil
+		}
+	}
+
+	s.cas++
+	s.items[key] = &memcachedItem{
+		value:     data[:size],
+		flags:     uint32(flags),
+		cas:       s.cas,
+		expiresAt: expiration(exptime),
+	}
+	return "STORED", nil
+}
+
+func (s *MemcachedServer) incr(cmd string, args []string) string {
+	if len(args) < 2 {
+		return "ERROR"
+	}
+	delta, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		return "CLIENT_ERROR invalid numeric delta argument"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it := s.get(args[0])
+	if it == nil {
+		return "NOT_FOUND"
+	}
+	v, err := strconv.ParseUint(string(it.value), 10, 64)
+	if err != nil {
+		return "CLIENT_ERROR cannot increment or decrement non-numeric value"
+	}
+	if cmd == "incr" {
+		v += delta
+	} else if delta > v {
+		v = 0
+	} else {
+		v -= delta
+	}
+	s.cas++
+	it.value = []byte(strconv.FormatUint(v, 10))
+	it.cas = s.cas
+	return string(it.value)
+}
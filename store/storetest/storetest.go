@@ -0,0 +1,902 @@
+// Package storetest provides the conformance tests which every store.Store implementation must pass.
+//
+// The tests document the behavior shared by all stores:
+//   - lookups of missing accounts and posts return store.ErrNonExist, Exist* methods return false without error.
+//   - saving an account or a post (identified by dna) again replaces it, but a dna is never
+//     moved to the post of another author or mid.
+//   - updating a post replaces it with the next version, and keeps the previous one as a version.
+//     A version is never saved with the dna of another post.
+//   - the company of an account is derived from its name, and the public key from its wif.
+//   - lists are ordered by created_at desc and paginated by offset and limit.
+//   - near-duplicates are found by the simhash of the content, ordered by distance and then by created_at desc.
+//   - media posts are found by any of their pHashes within content.MediaMatchDistance.
+//   - reindexing a post replaces its fingerprints and their indexes only.
+//   - anchoring a post sets its transaction and block only.
+//   - retracted posts keep no fingerprints, so they are not found by them.
+//   - full-text searches match the plain text contents, and are ordered by created_at desc.
+//   - posts looked up by terms are ordered by the number of shared terms and then by created_at desc.
+//   - stores are safe for concurrent use.
+//
+// Stores which can not list or count, like the memcached store, return store.ErrNotImplemented
+// and the related tests are skipped.
+package storetest
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weibocom/ipc/content"
+	"github.com/weibocom/ipc/model"
+	"github.com/weibocom/ipc/store"
+)
+
+// TestStore runs the conformance tests against the stores created by newStore.
+// Every subtest gets a new empty store, which is closed at the end of the subtest.
+func TestStore(t *testing.T, newStore func() store.Store) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s store.Store)
+	}{
+		{"NotFound", testNotFound},
+		{"Account", testAccount},
+		{"DuplicateAccount", testDuplicateAccount},
+		{"Accounts", testAccounts},
+		{"Post", testPost},
+		{"DuplicatePost", testDuplicatePost},
+		{"SameDNA", testSameDNA},
+		{"UpdatePost", testUpdatePost},
+		{"ReindexPost", testReindexPost},
+		{"PostAnchor", testPostAnchor},
+		{"RetractedPost", testRetractedPost},
+		{"LatestPost", testLatestPost},
+		{"Counts", testCounts},
+		{"PostsByAuthor", testPostsByAuthor},
+		{"SimilarPosts", testSimilarPosts},
+		{"NearDuplicates", testNearDuplicates},
+		{"MediaPosts", testMediaPosts},
+		{"Search", testSearch},
+		{"SearchPages", testSearchPages},
+		{"PostsByTerms", testPostsByTerms},
+		{"Cursors", testCursors},
+		{"Concurrency", testConcurrency},
+	}
+
+	for _, tt := range tests {
+		fn := tt.fn
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStore()
+			defer s.Close()
+			fn(t, s)
+		})
+	}
+}
+
+const testWIF = "5JWHY5DxTF6qN5grTtChDCYBmWHfY9zaSsw4CxEKN5eZpH9iBma"
+
+// skipNotImplemented skips the test if the store does not support the method returning err.
+func skipNotImplemented(t *testing.T, err error) {
+	if err == store.ErrNotImplemented {
+		t.Skip("not implemented by the store")
+	}
+}
+
+func newPost(author string, mid int64, content string, createdAt time.Time) *model.Post {
+	return &model.Post{
+		MSGID:     mid,
+		DNA:       fmt.Sprintf("dna-%s-%d", author, mid),
+		Author:    author,
+		Content:   content,
+		Digest:    fmt.Sprintf("digest-%s-%d", author, mid),
+		CreatedAt: createdAt,
+	}
+}
+
+func testNotFound(t *testing.T, s store.Store) {
+	_, err := s.LoadAccount("wb-1")
+	assert.Equal(t, store.ErrNonExist, err, "load account")
+	_, err = s.LoadAccountByPublicKey("STM0")
+	assert.Equal(t, store.ErrNonExist, err, "load account by public key")
+	exist, err := s.ExistAccount("wb-1")
+	assert.NoError(t, err, "exist account")
+	assert.False(t, exist, "exist account")
+
+	dna := model.DNA("dna-wb-1-1")
+	_, err = s.LoadPost(dna)
+	assert.Equal(t, store.ErrNonExist, err, "load post")
+	_, err = s.GetPostByDNA(dna)
+	assert.Equal(t, store.ErrNonExist, err, "get post by dna")
+	_, err = s.GetPostByMsgID("wb-1", 1)
+	assert.Equal(t, store.ErrNonExist, err, "get post by mid")
+	exist, err = s.ExistPost(dna)
+	assert.NoError(t, err, "exist post")
+	assert.False(t, exist, "exist post")
+
+	_, err = s.GetLatestPost()
+	if err != store.ErrNotImplemented {
+		assert.Equal(t, store.ErrNonExist, err, "get latest post of empty store")
+	}
+}
+
+func testAccount(t *testing.T, s store.Store) {
+	require.NoError(t, s.SaveAccount(&model.Account{Name: "wb-1", WIF: testWIF}), "save account")
+
+	a, err := s.LoadAccount("wb-1")
+	require.NoError(t, err, "load account")
+	assert.Equal(t, "wb-1", a.Name)
+	assert.Equal(t, "wb", a.Company, "company is derived from the account name")
+	assert.Equal(t, testWIF, a.WIF)
+	assert.NotEmpty(t, a.PublicKey, "public key is derived from wif")
+	assert.False(t, a.CreatedAt.IsZero(), "created_at is set")
+
+	exist, err := s.ExistAccount("wb-1")
+	require.NoError(t, err, "exist account")
+	assert.True(t, exist, "exist account")
+
+	b, err := s.LoadAccountByPublicKey(a.PublicKey)
+	require.NoError(t, err, "load account by public key")
+	assert.Equal(t, "wb-1", b.Name)
+}
+
+func testDuplicateAccount(t *testing.T, s store.Store) {
+	require.NoError(t, s.SaveAccount(&model.Account{Name: "wb-1", WIF: "old"}), "save account")
+	require.NoError(t, s.SaveAccount(&model.Account{Name: "wb-1", WIF: testWIF}), "save account again")
+
+	a, err := s.LoadAccount("wb-1")
+	require.NoError(t, err, "load account")
+	assert.Equal(t, testWIF, a.WIF, "account is replaced")
+
+	count, err := s.GetAccountCount()
+	skipNotImplemented(t, err)
+	require.NoError(t, err, "account count")
+	assert.Equal(t, 1, count, "account count")
+}
+
+func testAccounts(t *testing.T, s store.Store) {
+	for i := 0; i < 5; i++ {
+		require.NoError(t, s.SaveAccount(&model.Account{Name: fmt.Sprintf("wb-%d", i), WIF: testWIF}), "save account")
+	}
+	for i := 0; i < 3; i++ {
+		require.NoError(t, s.SaveAccount(&model.Account{Name: fmt.Sprintf("zw-%d", i), WIF: testWIF}), "save account")
+	}
+
+	accounts, err := s.GetAccounts("zw", 0, 10)
+	skipNotImplemented(t, err)
+	require.NoError(t, err, "get accounts")
+	assert.Len(t, accounts, 3, "accounts of company")
+	for _, a := range accounts {
+		assert.Equal(t, "zw", a.Company, "accounts of company")
+	}
+
+	accounts, err = s.GetAccounts("", 0, 100)
+	require.NoError(t, err, "get all accounts")
+	assert.Len(t, accounts, 8, "accounts of all companies")
+
+	// pages are disjoint and cover all accounts of the company.
+	seen := make(map[string]bool)
+	for offset := 0; offset < 6; offset += 2 {
+		accounts, err := s.GetAccounts("wb", offset, 2)
+		require.NoError(t, err, "get accounts")
+		assert.True(t, len(accounts) <= 2, "page size")
+		for i, a := range accounts {
+			assert.False(t, seen[a.Name], "account %s in several pages", a.Name)
+			seen[a.Name] = true
+			if i > 0 {
+				assert.False(t, a.CreatedAt.After(accounts[i-1].CreatedAt), "accounts are ordered by created_at desc")
+			}
+		}
+	}
+	assert.Len(t, seen, 5, "accounts of all pages")
+
+	accounts, err = s.GetAccounts("wb", 10, 2)
+	require.NoError(t, err, "get accounts out of range")
+	assert.Empty(t, accounts, "accounts out of range")
+}
+
+func testPost(t *testing.T, s store.Store) {
+	now := time.Now().Truncate(time.Second)
+	require.NoError(t, s.SavePost(newPost("wb-1", 1, "hello world", now)), "save post")
+
+	dna := model.DNA("dna-wb-1-1")
+	p, err := s.LoadPost(dna)
+	require.NoError(t, err, "load post")
+	assert.Equal(t, "wb-1", p.Author)
+	assert.Equal(t, int64(1), p.MSGID)
+	assert.Equal(t, "hello world", p.Content)
+	assert.Equal(t, "digest-wb-1-1", p.Digest)
+	assert.NotEmpty(t, p.Keywords, "keywords are extracted")
+	assert.Equal(t, "en", p.Language, "language is detected")
+	assert.True(t, now.Equal(p.CreatedAt), "created_at is kept")
+
+	exist, err := s.ExistPost(dna)
+	require.NoError(t, err, "exist post")
+	assert.True(t, exist, "exist post")
+
+	p, err = s.GetPostByDNA(dna)
+	require.NoError(t, err, "get post by dna")
+	assert.Equal(t, dna.String(), p.DNA)
+
+	p, err = s.GetPostByMsgID("wb-1", 1)
+	require.NoError(t, err, "get post by mid")
+	assert.Equal(t, dna.String(), p.DNA)
+
+	_, err = s.GetPostByMsgID("wb-2", 1)
+	assert.Equal(t, store.ErrNonExist, err, "get post by mid of other author")
+
+	// fingerprints given by the client are kept.
+	media := newPost("wb-1", 2, "image", now)
+	media.MediaHashes = "0123456789abcdef,fedcba9876543210"
+	media.NormalizedDigest = "6b86b273ff34fce19d6b804eff5a3f5747ada4eaa22f1d49c01e52ddb7875b4b"
+	require.NoError(t, s.SavePost(media), "save media post")
+	p, err = s.GetPostByMsgID("wb-1", 2)
+	require.NoError(t, err, "get media post")
+	assert.Equal(t, media.MediaHashes, p.MediaHashes)
+	assert.Equal(t, media.NormalizedDigest, p.NormalizedDigest)
+	assert.Empty(t, p.Keywords, "media posts have no keywords")
+	assert.Empty(t, p.Language, "media posts have no language")
+	assert.Zero(t, p.SimHash, "media posts have no simhash")
+	assert.Nil(t, p.BlockTime, "post is not anchored")
+
+	// the anchor of the post on chain is kept.
+	blockTime := now.Add(3 * time.Second).UTC()
+	p.TxID = "4b5c0e3f9b1a2c7d8e6f00112233445566778899"
+	p.BlockNum = 1024
+	p.BlockTime = &blockTime
+	require.NoError(t, s.SavePost(p), "save anchored post")
+	p, err = s.GetPostByMsgID("wb-1", 2)
+	require.NoError(t, err, "get anchored post")
+	assert.Equal(t, "4b5c0e3f9b1a2c7d8e6f00112233445566778899", p.TxID)
+	assert.Equal(t, uint32(1024), p.BlockNum)
+	if assert.NotNil(t, p.BlockTime) {
+		assert.True(t, blockTime.Equal(*p.BlockTime), "block time is kept")
+	}
+}
+
+func testDuplicatePost(t *testing.T, s store.Store) {
+	now := time.Now().Truncate(time.Second)
+	require.NoError(t, s.SavePost(newPost("wb-1", 1, "hello world", now)), "save post")
+	require.NoError(t, s.SavePost(newPost("wb-1", 1, "hello again", now)), "save post again")
+
+	p, err := s.LoadPost(model.DNA("dna-wb-1-1"))
+	require.NoError(t, err, "load post")
+	assert.Equal(t, "hello again", p.Content, "post is replaced")
+
+	count, err := s.GetPostCount()
+	skipNotImplemented(t, err)
+	require.NoError(t, err, "post count")
+	assert.Equal(t, 1, count, "post count")
+}
+
+func testSameDNA(t *testing.T, s store.Store) {
+	now := time.Now().Truncate(time.Second)
+	require.NoError(t, s.SavePost(newPost("wb-1", 1, "hello world", now)), "save post")
+
+	for _, p := range []*model.Post{newPost("wb-1", 2, "hello world", now), newPost("wb-2", 1, "hello world", now)} {
+		p.DNA = "dna-wb-1-1"
+		assert.Equal(t, store.ErrExist, s.SavePost(p), "save the dna of another post")
+	}
+
+	p, err := s.GetPostByMsgID("wb-1", 1)
+	require.NoError(t, err, "the post of the dna is kept")
+	assert.Equal(t, "dna-wb-1-1", p.DNA)
+	_, err = s.GetPostByMsgID("wb-1", 2)
+	assert.Equal(t, store.ErrNonExist, err, "post is not saved")
+
+	// the new version of another post has the dna.
+	require.NoError(t, s.SavePost(newPost("wb-1", 2, "hello again", now)), "save another post")
+	next := newPost("wb-1", 2, "hello world", now)
+	next.DNA = "dna-wb-1-1"
+	assert.Equal(t, store.ErrExist, s.UpdatePost(model.DNA("dna-wb-1-2"), next), "update to the dna of another post")
+	for mid, dna := range map[int64]string{1: "dna-wb-1-1", 2: "dna-wb-1-2"} {
+		p, err = s.GetPostByMsgID("wb-1", mid)
+		require.NoError(t, err, "posts are kept")
+		assert.Equal(t, dna, p.DNA)
+	}
+	versions, err := s.GetPostVersions("wb-1", 2)
+	require.NoError(t, err, "get post versions")
+	assert.Empty(t, versions, "post is not updated")
+
+	count, err := s.GetPostCount()
+	skipNotImplemented(t, err)
+	require.NoError(t, err, "post count")
+	assert.Equal(t, 2, count, "post count")
+}
+
+func testUpdatePost(t *testing.T, s store.Store) {
+	now := time.Now().Truncate(time.Second)
+	require.NoError(t, s.SavePost(newPost("wb-1", 1, "hello world", now)), "save post")
+
+	err := s.UpdatePost(model.DNA("dna-wb-1-2"), &model.Post{DNA: "dna-2", Content: "hello", CreatedAt: now})
+	assert.Equal(t, store.ErrNonExist, err, "update missing post")
+
+	for i := 2; i <= 3; i++ {
+		p := &model.Post{
+			DNA:       fmt.Sprintf("dna-wb-1-1-v%d", i),
+			Content:   fmt.Sprintf("hello again %d", i),
+			Digest:    fmt.Sprintf("digest-wb-1-1-v%d", i),
+			CreatedAt: now.Add(time.Duration(i) * time.Minute),
+		}
+		old, err := s.GetPostByMsgID("wb-1", 1)
+		require.NoError(t, err, "get post by mid")
+		require.NoError(t, s.UpdatePost(model.DNA(old.DNA), p), "update post")
+		assert.Equal(t, "wb-1", p.Author, "author is kept")
+		assert.Equal(t, int64(1), p.MSGID, "mid is kept")
+		assert.Equal(t, i, p.Version, "next version")
+
+		_, err = s.LoadPost(model.DNA(old.DNA))
+		assert.Equal(t, store.ErrNonExist, err, "previous version is replaced")
+	}
+
+	p, err := s.GetPostByMsgID("wb-1", 1)
+	require.NoError(t, err, "get post by mid")
+	assert.Equal(t, "dna-wb-1-1-v3", p.DNA)
+	assert.Equal(t, "hello again 3", p.Content)
+	assert.Equal(t, 3, p.Version)
+
+	versions, err := s.GetPostVersions("wb-1", 1)
+	require.NoError(t, err, "get post versions")
+	require.Len(t, versions, 2)
+	assert.Equal(t, "dna-wb-1-1", versions[0].DNA)
+	assert.Equal(t, "digest-wb-1-1", versions[0].Digest)
+	assert.Equal(t, 1, versions[0].Version)
+	assert.True(t, now.Equal(versions[0].CreatedAt), "created_at of the version")
+	assert.Equal(t, "dna-wb-1-1-v2", versions[1].DNA)
+	assert.Equal(t, 2, versions[1].Version)
+
+	versions, err = s.GetPostVersions("wb-1", 2)
+	require.NoError(t, err, "get post versions")
+	assert.Empty(t, versions, "post without versions")
+
+	count, err := s.GetPostCount()
+	skipNotImplemented(t, err)
+	require.NoError(t, err, "post count")
+	assert.Equal(t, 1, count, "post count")
+	posts, err := s.GetPostByAuthor("wb-1", 0, 10)
+	require.NoError(t, err, "get posts by author")
+	require.Len(t, posts, 1)
+	assert.Equal(t, "dna-wb-1-1-v3", posts[0].DNA)
+}
+
+func testLatestPost(t *testing.T, s store.Store) {
+	now := time.Now().Truncate(time.Second)
+	require.NoError(t, s.SavePost(newPost("wb-2", 2, "hello ipc", now)), "save post")
+	require.NoError(t, s.SavePost(newPost("wb-1", 1, "hello world", now.Add(-time.Minute))), "save post")
+
+	p, err := s.GetLatestPost()
+	skipNotImplemented(t, err)
+	require.NoError(t, err, "get latest post")
+	assert.Equal(t, "dna-wb-2-2", p.DNA, "latest post by created_at")
+}
+
+func testCounts(t *testing.T, s store.Store) {
+	now := time.Now().Truncate(time.Second)
+	require.NoError(t, s.SaveAccount(&model.Account{Name: "wb-1", WIF: testWIF}), "save account")
+	require.NoError(t, s.SaveAccount(&model.Account{Name: "wb-2", WIF: testWIF}), "save account")
+	require.NoError(t, s.SavePost(newPost("wb-1", 1, "hello world", now)), "save post")
+	require.NoError(t, s.SavePost(newPost("wb-1", 2, "hello ipc", now)), "save post")
+	require.NoError(t, s.SavePost(newPost("wb-2", 1, "hello", now)), "save post")
+
+	count, err := s.GetAccountCount()
+	skipNotImplemented(t, err)
+	require.NoError(t, err, "account count")
+	assert.Equal(t, 2, count, "account count")
+
+	count, err = s.GetPostCount()
+	require.NoError(t, err, "post count")
+	assert.Equal(t, 3, count, "post count")
+
+	count, err = s.GetAccountPostCount("wb-1")
+	require.NoError(t, err, "account post count")
+	assert.Equal(t, 2, count, "account post count")
+
+	count, err = s.GetAccountPostCount("wb-3")
+	require.NoError(t, err, "account post count")
+	assert.Equal(t, 0, count, "post count of account without post")
+}
+
+func testPostsByAuthor(t *testing.T, s store.Store) {
+	now := time.Now().Truncate(time.Second)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, s.SavePost(newPost("wb-1", int64(i), fmt.Sprintf("post %d", i), now.Add(time.Duration(i)*time.Second))), "save post")
+	}
+	require.NoError(t, s.SavePost(newPost("wb-2", 1, "other author", now)), "save post")
+
+	_, err := s.GetPostByAuthor("wb-1", 0, 2)
+	skipNotImplemented(t, err)
+
+	var mids []int64
+	for offset := 0; offset < 6; offset += 2 {
+		posts, err := s.GetPostByAuthor("wb-1", offset, 2)
+		require.NoError(t, err, "get posts by author")
+		assert.True(t, len(posts) <= 2, "page size")
+		for _, p := range posts {
+			assert.Equal(t, "wb-1", p.Author, "posts of author")
+			mids = append(mids, p.MSGID)
+		}
+	}
+	assert.Equal(t, []int64{4, 3, 2, 1, 0}, mids, "posts are ordered by created_at desc")
+
+	posts, err := s.GetPostByAuthor("wb-3", 0, 10)
+	require.NoError(t, err, "get posts of author without post")
+	assert.Empty(t, posts, "posts of author without post")
+}
+
+func testSimilarPosts(t *testing.T, s store.Store) {
+	now := time.Now().Truncate(time.Second)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, s.SavePost(newPost(fmt.Sprintf("wb-%d", i), 1, "the same content", now.Add(time.Duration(i)*time.Second))), "save post")
+	}
+	require.NoError(t, s.SavePost(newPost("wb-9", 1, "something else", now)), "save post")
+
+	p, err := s.LoadPost(model.DNA("dna-wb-0-1"))
+	require.NoError(t, err, "load post")
+
+	posts, err := s.LookupSimilarPosts(p.DNA, p.Keywords, 0, 10)
+	skipNotImplemented(t, err)
+	require.NoError(t, err, "lookup similar posts")
+	var dnas []string
+	for _, v := range posts {
+		dnas = append(dnas, v.DNA)
+	}
+	assert.Equal(t, []string{"dna-wb-2-1", "dna-wb-1-1"}, dnas, "similar posts exclude itself and are ordered by created_at desc")
+
+	posts, err = s.LookupSimilarPosts(p.DNA, p.Keywords, 1, 10)
+	require.NoError(t, err, "lookup similar posts")
+	assert.Len(t, posts, 1, "similar posts with offset")
+}
+
+func testNearDuplicates(t *testing.T, s store.Store) {
+	const (
+		text  = "The quick brown fox jumps over the lazy dog, and runs away into the forest before the hunter comes back."
+		edit  = "The quick brown fox jumps over the lazy dog, and then runs away into the forest before the hunter comes back."
+		other = "Steem is a blockchain database that supports community building and social interaction with cryptocurrency rewards."
+	)
+	now := time.Now().Truncate(time.Second)
+	require.NoError(t, s.SavePost(newPost("wb-0", 1, text, now)), "save post")
+	require.NoError(t, s.SavePost(newPost("wb-1", 1, text, now.Add(time.Second))), "save post")
+	require.NoError(t, s.SavePost(newPost("wb-2", 1, edit, now)), "save post")
+	require.NoError(t, s.SavePost(newPost("wb-3", 1, other, now)), "save post")
+
+	p, err := s.LoadPost(model.DNA("dna-wb-0-1"))
+	require.NoError(t, err, "load post")
+	require.NotZero(t, p.SimHash, "simhash is computed on save")
+
+	simhash := uint64(p.SimHash)
+	posts, err := s.LookupNearDuplicatePosts(simhash, s.MaxSimHashDistance(), 0)
+	skipNotImplemented(t, err)
+	require.NoError(t, err, "lookup near-duplicate posts")
+	var dnas []string
+	for _, v := range posts {
+		dnas = append(dnas, v.DNA)
+	}
+	assert.Equal(t, []string{"dna-wb-1-1", "dna-wb-0-1", "dna-wb-2-1"}, dnas, "near-duplicates are ordered by distance and created_at desc")
+
+	posts, err = s.LookupNearDuplicatePosts(simhash, 0, 0)
+	require.NoError(t, err, "lookup near-duplicate posts")
+	assert.Len(t, posts, 2, "distance threshold")
+
+	posts, err = s.LookupNearDuplicatePosts(simhash, s.MaxSimHashDistance(), 1)
+	require.NoError(t, err, "lookup near-duplicate posts")
+	assert.Len(t, posts, 1, "limit")
+
+	// the index follows updates of the post.
+	require.NoError(t, s.UpdatePost(model.DNA("dna-wb-1-1"), newPost("wb-1", 2, other, now.Add(time.Second))), "update post")
+	posts, err = s.LookupNearDuplicatePosts(simhash, 0, 0)
+	require.NoError(t, err, "lookup near-duplicate posts")
+	if assert.Len(t, posts, 1, "updated post is not a near-duplicate") {
+		assert.Equal(t, "dna-wb-0-1", posts[0].DNA)
+	}
+}
+
+func testMediaPosts(t *testing.T, s store.Store) {
+	const (
+		frame1 = uint64(0x0123456789abcdef)
+		frame2 = uint64(0xfedcba9876543210)
+	)
+	now := time.Now().Truncate(time.Second)
+	video := newPost("wb-1", 1, "video", now)
+	video.MediaHashes = content.FormatHashes([]uint64{frame1, frame2})
+	require.NoError(t, s.SavePost(video), "save video post")
+	image := newPost("wb-1", 2, "image", now.Add(time.Second))
+	image.MediaHashes = content.FormatHashes([]uint64{frame2 ^ 0x1})
+	require.NoError(t, s.SavePost(image), "save image post")
+	text := newPost("wb-1", 3, "The quick brown fox jumps over the lazy dog", now)
+	text.SimHash = int64(frame1)
+	require.NoError(t, s.SavePost(text), "save text post")
+
+	lookup := func(hashes ...uint64) []string {
+		posts, err := s.LookupMediaPosts(hashes, 0)
+		require.NoError(t, err, "lookup media posts")
+		dnas := []string{}
+		for _, p := range posts {
+			dnas = append(dnas, p.DNA)
+		}
+		return dnas
+	}
+
+	_, err := s.LookupMediaPosts([]uint64{frame1}, 0)
+	skipNotImplemented(t, err)
+	assert.Equal(t, []string{"dna-wb-1-1"}, lookup(frame1), "first keyframe")
+	// 10 bits in different bands.
+	var far uint64
+	for i := uint(0); i < content.MediaMatchDistance; i++ {
+		far |= 1 << (i * 6)
+	}
+	assert.Equal(t, []string{"dna-wb-1-1", "dna-wb-1-2"}, lookup(frame2^far^0x1), "other keyframes within the match distance, the nearest first")
+	assert.Equal(t, []string{"dna-wb-1-2", "dna-wb-1-1"}, lookup(frame2^0x1), "ordered by distance")
+	assert.Empty(t, lookup(frame1^far^1<<63), "beyond the match distance")
+	assert.Empty(t, lookup(), "no hashes")
+
+	posts, err := s.LookupNearDuplicatePosts(frame1, 0, 0)
+	require.NoError(t, err, "lookup near-duplicate posts")
+	if assert.Len(t, posts, 1, "pHashes are not in the simhash index") {
+		assert.Equal(t, "dna-wb-1-3", posts[0].DNA)
+	}
+
+	// the index follows updates of the post.
+	updated := newPost("wb-1", 1, "video", now)
+	updated.MediaHashes = content.FormatHashes([]uint64{0x3c3c3c3c3c3c3c3c})
+	require.NoError(t, s.UpdatePost(model.DNA(video.DNA), updated), "update post")
+	assert.Equal(t, []string{"dna-wb-1-2"}, lookup(frame1, frame2), "updated post")
+	assert.Equal(t, []string{"dna-wb-1-1"}, lookup(0x3c3c3c3c3c3c3c3c), "updated post")
+}
+
+func testSearch(t *testing.T, s store.Store) {
+	now := time.Now().Truncate(time.Second)
+	require.NoError(t, s.SavePost(newPost("wb-0", 1, "The quick brown fox jumps over the lazy dog", now)), "save post")
+	require.NoError(t, s.SavePost(newPost("wb-1", 1, "A lazy brown dog sleeps all day", now.Add(time.Second))), "save post")
+	require.NoError(t, s.SavePost(newPost("qq-0", 1, "Brown bears are not foxes", now.Add(2*time.Second))), "save post")
+	encrypted := newPost("wb-2", 1, "YnJvd24gZm94", now)
+	encrypted.Keywords = "brown,fox"
+	encrypted.WrappedKey = "key"
+	require.NoError(t, s.SavePost(encrypted), "save post")
+
+	search := func(q *store.SearchQuery, offset int, limit int) []string {
+		posts, err := s.SearchPosts(q, offset, limit)
+		require.NoError(t, err, "search posts %q", q.Text)
+		dnas := []string{}
+		for _, p := range posts {
+			dnas = append(dnas, p.DNA)
+		}
+		return dnas
+	}
+
+	_, err := s.SearchPosts(&store.SearchQuery{Text: "brown"}, 0, -1)
+	skipNotImplemented(t, err)
+	assert.Equal(t, []string{"dna-qq-0-1", "dna-wb-1-1", "dna-wb-0-1"}, search(&store.SearchQuery{Text: "brown"}, 0, -1), "ordered by created_at desc")
+	assert.Equal(t, []string{"dna-wb-1-1"}, search(&store.SearchQuery{Text: "brown"}, 1, 1), "offset and limit")
+	assert.Equal(t, []string{"dna-qq-0-1", "dna-wb-0-1"}, search(&store.SearchQuery{Text: "fox"}, 0, -1), "stemmed terms")
+	assert.Equal(t, []string{"dna-wb-0-1"}, search(&store.SearchQuery{Text: `"lazy dog"`}, 0, -1), "phrase")
+	assert.Equal(t, []string{"dna-qq-0-1", "dna-wb-1-1"}, search(&store.SearchQuery{Text: "brown -quick"}, 0, -1), "exclusion")
+	assert.Equal(t, []string{"dna-qq-0-1", "dna-wb-1-1"}, search(&store.SearchQuery{Text: "bears OR sleeps"}, 0, -1), "or")
+	assert.Equal(t, []string{"dna-wb-1-1", "dna-wb-0-1"}, search(&store.SearchQuery{Text: "brown", Company: "wb"}, 0, -1), "company")
+	assert.Empty(t, search(&store.SearchQuery{Text: "brown", Company: "w%"}, 0, -1), "wildcard in company")
+	assert.Empty(t, search(&store.SearchQuery{Text: "brown", Company: "w_"}, 0, -1), "wildcard in company")
+	assert.Equal(t, []string{"dna-wb-0-1"}, search(&store.SearchQuery{Text: "brown", Author: "wb-0"}, 0, -1), "author")
+	assert.Equal(t, []string{"dna-wb-1-1"}, search(&store.SearchQuery{Text: "brown", Since: now.Add(time.Second), Until: now.Add(2 * time.Second)}, 0, -1), "time range")
+	assert.Empty(t, search(&store.SearchQuery{Text: "cat"}, 0, -1), "no match")
+
+	_, err = s.SearchPosts(&store.SearchQuery{Text: " "}, 0, -1)
+	assert.Equal(t, content.ErrEmptyQuery, err, "empty query")
+
+	// the index follows updates of the post.
+	require.NoError(t, s.UpdatePost(model.DNA("dna-wb-0-1"), newPost("wb-0", 1, "A red cat", now)), "update post")
+	assert.Equal(t, []string{"dna-qq-0-1"}, search(&store.SearchQuery{Text: "fox"}, 0, -1), "updated post")
+	assert.Equal(t, []string{"dna-wb-0-1"}, search(&store.SearchQuery{Text: "cat"}, 0, -1), "updated post")
+
+	// the terms given with the post are indexed instead of the ones of the content.
+	blob := newPost("wb-3", 1, "QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG", now)
+	blob.StoreType = 3
+	blob.Terms = content.SearchTokens("A grey wolf in the blob store")
+	require.NoError(t, s.SavePost(blob), "save post")
+	assert.Equal(t, []string{"dna-wb-3-1"}, search(&store.SearchQuery{Text: "wolf"}, 0, -1), "given terms")
+	assert.Empty(t, search(&store.SearchQuery{Text: blob.Content}, 0, -1), "not the content")
+}
+
+func testSearchPages(t *testing.T, s store.Store) {
+	const n = 250
+	now := time.Now().Truncate(time.Second)
+	var expected []string
+	for i := 0; i < n; i++ {
+		text := "brown fox"
+		if i%3 == 0 {
+			text = "quick brown fox"
+		} else {
+			expected = append([]string{fmt.Sprintf("dna-wb-1-%d", i)}, expected...)
+		}
+		require.NoError(t, s.SavePost(newPost("wb-1", int64(i), text, now.Add(time.Duration(i)*time.Second))), "save post")
+	}
+
+	search := func(offset int, limit int) []string {
+		posts, err := s.SearchPosts(&store.SearchQuery{Text: "brown -quick"}, offset, limit)
+		skipNotImplemented(t, err)
+		require.NoError(t, err, "search posts")
+		dnas := []string{}
+		for _, p := range posts {
+			dnas = append(dnas, p.DNA)
+		}
+		return dnas
+	}
+	assert.Equal(t, expected, search(0, -1), "all pages")
+	assert.Equal(t, expected[:10], search(0, 10), "first page")
+	assert.Equal(t, expected[90:130], search(90, 40), "page across batches")
+	assert.Equal(t, expected[160:], search(160, 100), "last page")
+	assert.Empty(t, search(200, 10), "after the last page")
+}
+
+func testPostsByTerms(t *testing.T, s store.Store) {
+	now := time.Now().Truncate(time.Second)
+	require.NoError(t, s.SavePost(newPost("wb-0", 1, "The quick brown fox jumps over the lazy dog", now)), "save post")
+	require.NoError(t, s.SavePost(newPost("wb-1", 1, "A lazy brown dog sleeps all day", now.Add(time.Second))), "save post")
+	require.NoError(t, s.SavePost(newPost("qq-0", 1, "Brown bears are not foxes", now.Add(2*time.Second))), "save post")
+	require.NoError(t, s.SavePost(newPost("qq-1", 1, "A red cat", now.Add(3*time.Second))), "save post")
+
+	lookup := func(text string, limit int) []string {
+		posts, err := s.LookupPostsByTerms(content.SearchTokens(text), limit)
+		require.NoError(t, err, "lookup posts by the terms of %q", text)
+		dnas := []string{}
+		for _, p := range posts {
+			dnas = append(dnas, p.DNA)
+		}
+		return dnas
+	}
+
+	_, err := s.LookupPostsByTerms([]string{"brown"}, -1)
+	skipNotImplemented(t, err)
+	assert.Equal(t, []string{"dna-wb-0-1", "dna-qq-0-1", "dna-wb-1-1"}, lookup("brown fox dog dog", -1), "ordered by shared terms and then by created_at desc")
+	assert.Equal(t, []string{"dna-wb-0-1"}, lookup("brown fox dog", 1), "limit")
+	assert.Equal(t, []string{"dna-qq-1-1", "dna-wb-1-1", "dna-qq-0-1", "dna-wb-0-1"}, lookup("a red brown", 0), "any of the terms")
+	assert.Empty(t, lookup("", -1), "no terms")
+	assert.Empty(t, lookup("blockchain", -1), "no match")
+}
+
+func testCursors(t *testing.T, s store.Store) {
+	now := time.Now().Truncate(time.Second)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, s.SaveAccount(&model.Account{Name: fmt.Sprintf("wb-%d", i), WIF: testWIF}), "save account")
+	}
+	// posts 3 and 4 are created at the same time, ordered by dna.
+	for i := 0; i < 5; i++ {
+		createdAt := now.Add(time.Duration(i) * time.Second)
+		if i == 4 {
+			createdAt = now.Add(3 * time.Second)
+		}
+		require.NoError(t, s.SavePost(newPost("wb-1", int64(i), "the same content", createdAt)), "save post")
+	}
+
+	_, _, err := s.GetAccountsAfter("wb", nil, 2)
+	skipNotImplemented(t, err)
+
+	var names []string
+	var cursor *store.Cursor
+	for i := 0; ; i++ {
+		accounts, next, err := s.GetAccountsAfter("wb", cursor, 2)
+		require.NoError(t, err, "get accounts after cursor")
+		assert.True(t, len(accounts) <= 2, "page size")
+		for _, a := range accounts {
+			names = append(names, a.Name)
+		}
+		if next == nil {
+			break
+		}
+		require.True(t, i < 5, "pages end")
+		cursor = next
+	}
+	assert.Len(t, names, 5, "accounts of all pages")
+
+	var mids []int64
+	cursor = nil
+	for i := 0; ; i++ {
+		posts, next, err := s.GetPostByAuthorAfter("wb-1", cursor, 2)
+		require.NoError(t, err, "get posts after cursor")
+		for _, p := range posts {
+			mids = append(mids, p.MSGID)
+		}
+		if i == 0 {
+			// a new post does not change the following pages.
+			require.NoError(t, s.SavePost(newPost("wb-1", 9, "new post", now.Add(time.Minute))), "save post")
+		}
+		if next == nil {
+			break
+		}
+		require.True(t, i < 5, "pages end")
+
+		// cursors are opaque tokens.
+		cursor, err = store.ParseCursor(next.String())
+		require.NoError(t, err, "parse cursor")
+	}
+	assert.Equal(t, []int64{3, 4, 2, 1, 0}, mids, "posts are ordered by created_at desc and dna")
+
+	p, err := s.LoadPost(model.DNA("dna-wb-1-0"))
+	require.NoError(t, err, "load post")
+	var dnas []string
+	cursor = nil
+	for i := 0; ; i++ {
+		posts, next, err := s.LookupSimilarPostsAfter(p.DNA, p.Keywords, cursor, 3)
+		require.NoError(t, err, "lookup similar posts after cursor")
+		assert.True(t, len(posts) <= 3, "page size")
+		for _, v := range posts {
+			dnas = append(dnas, v.DNA)
+		}
+		if next == nil {
+			break
+		}
+		require.True(t, i < 5, "pages end")
+		cursor = next
+	}
+	assert.Equal(t, []string{"dna-wb-1-3", "dna-wb-1-4", "dna-wb-1-2", "dna-wb-1-1"}, dnas, "similar posts exclude itself")
+
+	posts, next, err := s.GetPostByAuthorAfter("wb-1", nil, 0)
+	require.NoError(t, err, "get all posts")
+	assert.Len(t, posts, 6, "all posts")
+	assert.Nil(t, next, "no next page")
+
+	require.NoError(t, s.SavePost(newPost("wb-2", 1, "another author", now.Add(2*time.Minute))), "save post")
+	dnas = nil
+	cursor = nil
+	for i := 0; ; i++ {
+		posts, next, err := s.GetPostsAfter(cursor, 4)
+		if err == store.ErrNotImplemented {
+			return
+		}
+		require.NoError(t, err, "get all posts after cursor")
+		for _, v := range posts {
+			dnas = append(dnas, v.DNA)
+		}
+		if next == nil {
+			break
+		}
+		require.True(t, i < 5, "pages end")
+		cursor = next
+	}
+	assert.Equal(t, []string{"dna-wb-2-1", "dna-wb-1-9", "dna-wb-1-3", "dna-wb-1-4", "dna-wb-1-2", "dna-wb-1-1", "dna-wb-1-0"}, dnas, "posts of all authors")
+}
+
+func testReindexPost(t *testing.T, s store.Store) {
+	const text = "The quick brown fox jumps over the lazy dog, and runs away into the forest before the hunter comes back."
+	now := time.Now().Truncate(time.Second)
+	p := newPost("wb-1", 1, "ipfs-address", now)
+	p.StoreType = 3
+	require.NoError(t, s.SavePost(p), "save post")
+
+	fp := &model.Post{DNA: p.DNA, Keywords: "fox,hunter", Language: "en", SimHash: int64(content.SimHash(text))}
+	err := s.ReindexPost(fp)
+	skipNotImplemented(t, err)
+	require.NoError(t, err, "reindex post")
+	assert.Equal(t, store.ErrNonExist, s.ReindexPost(&model.Post{DNA: "dna-none"}), "reindex missing post")
+
+	got, err := s.LoadPost(model.DNA(p.DNA))
+	require.NoError(t, err, "load post")
+	assert.Equal(t, "fox,hunter", got.Keywords, "fingerprints are saved")
+	assert.Equal(t, "en", got.Language)
+	assert.Equal(t, fp.SimHash, got.SimHash)
+	assert.Equal(t, "ipfs-address", got.Content, "other fields are kept")
+	assert.Equal(t, uint8(3), got.StoreType)
+
+	posts, err := s.LookupNearDuplicatePosts(uint64(fp.SimHash), 0, 0)
+	require.NoError(t, err, "lookup near-duplicate posts")
+	if assert.Len(t, posts, 1, "simhash index is rebuilt") {
+		assert.Equal(t, p.DNA, posts[0].DNA)
+	}
+	posts, err = s.LookupSimilarPosts("", "fox,hunter", 0, 10)
+	require.NoError(t, err, "lookup similar posts")
+	assert.Len(t, posts, 1, "keywords index is rebuilt")
+}
+
+func testPostAnchor(t *testing.T, s store.Store) {
+	now := time.Now().Truncate(time.Second)
+	p := newPost("wb-1", 1, "content-1", now)
+	p.Retracted = true
+	p.RetractedAt = &now
+	require.NoError(t, s.SavePost(p), "save post")
+	// the post is cached before it is anchored.
+	_, err := s.GetPostByMsgID("wb-1", 1)
+	require.NoError(t, err, "get post")
+
+	blockTime := now.Add(3 * time.Second).UTC()
+	err = s.SetPostAnchor(model.DNA(p.DNA), "tx-1", 1024, blockTime)
+	skipNotImplemented(t, err)
+	require.NoError(t, err, "set post anchor")
+	assert.Equal(t, store.ErrNonExist, s.SetPostAnchor(model.DNA("dna-none"), "tx-2", 1025, blockTime), "anchor missing post")
+
+	for _, load := range []func() (*model.Post, error){
+		func() (*model.Post, error) { return s.LoadPost(model.DNA(p.DNA)) },
+		func() (*model.Post, error) { return s.GetPostByMsgID("wb-1", 1) },
+	} {
+		got, err := load()
+		require.NoError(t, err, "load post")
+		assert.Equal(t, "tx-1", got.TxID)
+		assert.Equal(t, uint32(1024), got.BlockNum)
+		if assert.NotNil(t, got.BlockTime) {
+			assert.True(t, blockTime.Equal(*got.BlockTime), "block time is saved")
+		}
+		assert.True(t, got.Retracted, "other fields are kept")
+		assert.Equal(t, "content-1", got.Content)
+	}
+}
+
+func testRetractedPost(t *testing.T, s store.Store) {
+	const (
+		text  = "The quick brown fox jumps over the lazy dog, and runs away into the forest before the hunter comes back."
+		frame = uint64(0x0123456789abcdef)
+	)
+	now := time.Now().Truncate(time.Second)
+	post := newPost("wb-1", 1, text, now)
+	require.NoError(t, s.SavePost(post), "save post")
+	media := newPost("wb-1", 2, "image", now)
+	media.MediaHashes = content.FormatHashes([]uint64{frame})
+	require.NoError(t, s.SavePost(media), "save media post")
+	simhash := uint64(post.SimHash)
+
+	// the fingerprints of the tombstones are not indexed even if they are given.
+	for _, p := range []*model.Post{post, media} {
+		p.Content = ""
+		p.Retracted = true
+		p.RetractedAt = &now
+		require.NoError(t, s.SavePost(p), "save tombstone")
+	}
+	got, err := s.LoadPost(model.DNA(post.DNA))
+	require.NoError(t, err, "load tombstone")
+	assert.True(t, got.Retracted)
+	assert.Zero(t, got.SimHash, "fingerprints are cleared")
+	assert.Empty(t, got.Keywords)
+	assert.Empty(t, got.NormalizedDigest)
+
+	posts, err := s.LookupNearDuplicatePosts(simhash, 3, 0)
+	skipNotImplemented(t, err)
+	require.NoError(t, err, "lookup near-duplicate posts")
+	assert.Empty(t, posts, "retracted posts are not near-duplicates")
+	posts, err = s.LookupMediaPosts([]uint64{frame}, 0)
+	require.NoError(t, err, "lookup media posts")
+	assert.Empty(t, posts, "retracted posts are not media matches")
+
+	// reindexing does not index them again.
+	err = s.ReindexPost(&model.Post{DNA: post.DNA, SimHash: int64(simhash), Keywords: "fox"})
+	require.NoError(t, err, "reindex tombstone")
+	posts, err = s.LookupNearDuplicatePosts(simhash, 3, 0)
+	require.NoError(t, err, "lookup near-duplicate posts")
+	assert.Empty(t, posts, "reindexed tombstone")
+}
+
+func testConcurrency(t *testing.T, s store.Store) {
+	const n = 20
+	now := time.Now().Truncate(time.Second)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 4*n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("wb-%d", i)
+			if err := s.SaveAccount(&model.Account{Name: name, WIF: testWIF}); err != nil {
+				errs <- err
+				return
+			}
+			if err := s.SavePost(newPost(name, 1, "concurrent post", now)); err != nil {
+				errs <- err
+				return
+			}
+			if _, err := s.LoadAccount(name); err != nil {
+				errs <- err
+			}
+			if _, err := s.GetPostByMsgID(name, 1); err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err, "concurrent access")
+	}
+
+	for i := 0; i < n; i++ {
+		exist, err := s.ExistPost(model.DNA(fmt.Sprintf("dna-wb-%d-1", i)))
+		require.NoError(t, err, "exist post")
+		assert.True(t, exist, "post saved concurrently")
+	}
+}
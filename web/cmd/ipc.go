@@ -2,18 +2,25 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	switcher "git.intra.weibo.com/platform/go-switcher"
 	"git.intra.weibo.com/platform/qservice/metrics"
 	_ "github.com/jinzhu/gorm/dialects/mysql"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
 
+	ipcclient "github.com/weibocom/ipc/client"
 	"github.com/weibocom/ipc/config"
 	"github.com/weibocom/ipc/content"
 	"github.com/weibocom/ipc/keys"
+	steemclient "github.com/weibocom/ipc/steem/client"
+	"github.com/weibocom/ipc/store"
 	"github.com/weibocom/ipc/web/server"
 	"github.com/weibocom/ipc/web/service"
 )
@@ -21,24 +28,47 @@ import (
 var (
 	company        = flag.String("company", "wb", "short company name")
 	httpAddress    = flag.String("http", ":8080", "http address")
-	dbAddress      = flag.String("db", "root@/ipc?charset=utf8mb4&parseTime=True&loc=Local&timeout=1s&writeTimeout=3s&readTimeout=3s", "mysql address")
+	dbAddress      = flag.String("db", "root@/ipc?charset=utf8mb4&parseTime=True&loc=Local&timeout=1s&writeTimeout=3s&readTimeout=3s", "database address: mysql dsn, postgres://... or sqlite3://path")
 	bcAddress      = flag.String("bc", "ws://52.80.76.2:38090", "blockchain rpc server address")
 	switcherAddr   = flag.String("switcher", "", "switcher addrress")
 	graphiteAddr   = flag.String("graphiteAddr", "", "graphite addrress")
 	ipcServicePool = flag.String("servicePool", "ipc", "monitor service pool")
 	creator        = flag.String("creator", "initminer", "init witness")
 	wif            = flag.String("wif", "5JzpcbsNCu6Hpad1TYmudH4rj1A22SW9Zhb1ofBGHRZSp5poqAX", "init wif")
-	jiebaData      = flag.String("jieba", "", "gojieba dict files. can download from https://github.com/yanyiwu/gojieba/tree/master/dict")
+	trxExpiration  = flag.Duration("trxExpiration", 30*time.Second, "blockchain transaction expiration")
+	trxIrrRef      = flag.Bool("trxIrreversibleRef", false, "reference the last irreversible block instead of head block in transactions")
+	propsCacheTTL  = flag.Duration("propsCacheTTL", 0, "cache time of dynamic global properties used to create transactions")
+	trxRebuilds    = flag.Int("trxRebuilds", 0, "max times to rebuild transactions failed with expiration or TaPoS errors")
+	contentStore   = flag.String("contentStore", "db", "where to store post contents: db, ipfs or fs")
+	ipfsAddr       = flag.String("ipfs", "localhost:5001", "ipfs api address, used if contentStore is ipfs")
+	blobDir        = flag.String("blobDir", "./blobs", "directory of post contents, used if contentStore is fs")
+	encryption     = flag.String("encryption", "none", "encrypt post contents with keys of: none, author or company")
+	simhashBands   = flag.Int("simhashBands", store.DefaultSimHashBands, "number of bands the simhash of posts are indexed by, a divisor of 64. posts within a distance of simhashBands-1 can be looked up, run the reindex command after it is changed")
+	jiebaData      = flag.String("jieba", "", "dir of gojieba dict files, the user dict is reloaded on SIGHUP. can download from https://github.com/yanyiwu/gojieba/tree/master/dict")
 )
 
 func main() {
 	flag.Parse()
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 
+	if flag.Arg(0) == "migrate" {
+		runMigrate(flag.Args()[1:])
+		return
+	}
+	if flag.Arg(0) == "reindex" {
+		runReindex(flag.Args()[1:])
+		return
+	}
+
 	if *jiebaData != "" {
-		content.ConfigGojieba("./jieba")
+		seg, err := content.NewSegmenter(*jiebaData)
+		if err != nil {
+			log.Fatalf("load jieba dicts in %s failed: %v", *jiebaData, err)
+		}
+		content.SetDefaultSegmenter(seg)
+		defer seg.Close()
+		go reloadUserDict(seg)
 	}
-	defer content.CleanGojieba()
 
 	if *switcherAddr != "" {
 		if err := switcher.Serve("tcp", *switcherAddr); err != nil {
@@ -54,8 +84,29 @@ func main() {
 
 	initConfig()
 
+	var err error
 	s := server.New(*httpAddress, *dbAddress, *bcAddress, *company)
-	err := s.Start()
+	s.ChainOptions = []steemclient.Option{
+		steemclient.SetExpiration(*trxExpiration),
+		steemclient.SetIrreversibleReference(*trxIrrRef),
+		steemclient.SetPropertiesCacheTTL(*propsCacheTTL),
+		steemclient.SetMaxRebuilds(*trxRebuilds),
+	}
+	s.StoreOptions = []store.Option{store.SetSimHashBands(*simhashBands)}
+	s.ClientOptions, err = contentStoreOptions()
+	if err != nil {
+		log.Fatal(err)
+	}
+	switch *encryption {
+	case "none":
+	case "author":
+		s.ClientOptions = append(s.ClientOptions, ipcclient.SetEncryption(ipcclient.EncryptForAuthor))
+	case "company":
+		s.ClientOptions = append(s.ClientOptions, ipcclient.SetEncryption(ipcclient.EncryptForCompany))
+	default:
+		log.Fatalf("unknown encryption %q", *encryption)
+	}
+	err = s.Start()
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -71,6 +122,43 @@ func main() {
 	log.Println("server is closing")
 }
 
+// reloadUserDict reloads the user dictionary of jieba on SIGHUP.
+func reloadUserDict(seg *content.Segmenter) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	for range ch {
+		if err := seg.ReloadUserDict(); err != nil {
+			log.Printf("reload jieba user dict failed: %v", err)
+			continue
+		}
+		log.Println("jieba user dict is reloaded")
+	}
+}
+
+// contentStoreOptions returns the client options of the contentStore flag.
+func contentStoreOptions() ([]ipcclient.Option, error) {
+	switch *contentStore {
+	case "db":
+		return nil, nil
+	case "ipfs":
+		return []ipcclient.Option{
+			ipcclient.SetBlobStore(ipcclient.StoreInIPFS, content.NewIPFSClient(*ipfsAddr)),
+			ipcclient.SetPostStoreType(ipcclient.StoreInIPFS),
+		}, nil
+	case "fs":
+		bs, err := content.NewFSBlobStore(*blobDir)
+		if err != nil {
+			return nil, err
+		}
+		return []ipcclient.Option{
+			ipcclient.SetBlobStore(ipcclient.StoreInFS, bs),
+			ipcclient.SetPostStoreType(ipcclient.StoreInFS),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown content store %q", *contentStore)
+	}
+}
+
 func initConfig() {
 	conf := config.GetConfig()
 	if *creator != "" {
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/weibocom/ipc/store"
+)
+
+const migrateUsage = `usage: ipc -db <dsn> migrate <command>
+
+commands:
+  up [version]  apply the pending migrations, up to version if given
+  down [n]      roll back the last n migrations, 1 if not given
+  status        print the migrations and whether they are applied`
+
+// runMigrate runs the migrate subcommand against the db of -db.
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		log.Fatal(migrateUsage)
+	}
+
+	dialect, dsn := store.SplitDSN(*dbAddress)
+	db, err := gorm.Open(dialect, dsn)
+	if err != nil {
+		log.Fatalf("failed to open db: %v", err)
+	}
+	defer db.Close()
+
+	switch args[0] {
+	case "up":
+		version := store.LatestVersion()
+		if len(args) > 1 {
+			if version, err = strconv.ParseInt(args[1], 10, 64); err != nil {
+				log.Fatalf("invalid version %s: %v", args[1], err)
+			}
+		}
+		err = store.MigrateTo(db, version)
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			if n, err = strconv.Atoi(args[1]); err != nil {
+				log.Fatalf("invalid n %s: %v", args[1], err)
+			}
+		}
+		err = store.Rollback(db, n)
+	case "status":
+	default:
+		log.Fatal(migrateUsage)
+	}
+	if err != nil {
+		log.Fatalf("migrate %s failed: %v", args[0], err)
+	}
+
+	printMigrations(db)
+}
+
+func printMigrations(db *gorm.DB) {
+	applied, err := store.AppliedMigrations(db)
+	if err != nil {
+		log.Fatalf("failed to load applied migrations: %v", err)
+	}
+	appliedAt := make(map[int64]string, len(applied))
+	for _, m := range applied {
+		appliedAt[m.Version] = m.AppliedAt.Format("2006-01-02 15:04:05")
+	}
+
+	for _, m := range store.Migrations() {
+		at, ok := appliedAt[m.Version]
+		if !ok {
+			at = "pending"
+		}
+		fmt.Printf("%4d  %-40s  %s\n", m.Version, m.Name, at)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"log"
+	"strconv"
+
+	ipcclient "github.com/weibocom/ipc/client"
+	"github.com/weibocom/ipc/store"
+)
+
+const reindexUsage = `usage: ipc -db <dsn> [-simhashBands n] [-contentStore ...] reindex [batch]
+
+fingerprints all the posts again and rebuilds their indexes, batch posts at a time, 100 if not given.
+run it after -simhashBands is changed, or to fingerprint the posts saved by older versions.`
+
+// runReindex runs the reindex subcommand against the db of -db.
+func runReindex(args []string) {
+	batch := 100
+	if len(args) > 0 {
+		var err error
+		if batch, err = strconv.Atoi(args[0]); err != nil || batch <= 0 {
+			log.Fatal(reindexUsage)
+		}
+	}
+
+	dialect, dsn := store.SplitDSN(*dbAddress)
+	dbStore, err := store.NewSQLStore(dialect, dsn, store.SetSimHashBands(*simhashBands))
+	if err != nil {
+		log.Fatalf("failed to new db store: %v", err)
+	}
+	defer dbStore.Close()
+
+	options, err := contentStoreOptions()
+	if err != nil {
+		log.Fatal(err)
+	}
+	// the chain is not used to reindex, the client is not closed which would close the chain.
+	c, err := ipcclient.NewClient(nil, dbStore, options...)
+	if err != nil {
+		log.Fatalf("failed to new client: %v", err)
+	}
+
+	var cursor string
+	total := 0
+	for {
+		n, next, err := c.ReindexPosts(cursor, batch)
+		if err != nil {
+			log.Fatalf("reindex posts after %q failed: %v", cursor, err)
+		}
+		total += n
+		if next == "" {
+			break
+		}
+		cursor = next
+		log.Printf("%d posts are reindexed, next: %s", total, cursor)
+	}
+	log.Printf("done, %d posts are reindexed", total)
+}
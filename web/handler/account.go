@@ -2,6 +2,7 @@ package handler
 
 import (
 	"bufio"
+	"errors"
 	"log"
 	"net/http"
 	"path/filepath"
@@ -10,6 +11,8 @@ import (
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/weibocom/ipc/client"
+	"github.com/weibocom/ipc/steem"
+	"github.com/weibocom/ipc/store"
 	"github.com/weibocom/ipc/web/model"
 	"github.com/weibocom/ipc/web/service"
 	"github.com/weibocom/ipc/web/weiboapi"
@@ -59,7 +62,7 @@ func createAccount(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
 
 	var resp *APIResponse
 	if err != nil {
-		if strings.Contains(err.Error(), "likely a uniqueness") {
+		if err == client.ErrAccountAlreadyExist || errors.Is(err, steem.ErrAccountExists) {
 			resp = NewErrorCodeResponse(40001002)
 		} else {
 			resp = NewErrorResponse(500, err.Error())
@@ -111,21 +114,38 @@ func queryAccount(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
 		return
 	}
 
-	users, err := service.GetUsers(company, int(page), int(pagesize), uid)
+	var (
+		users []*model.User
+		next  string
+		err   error
+	)
+	// 没有page参数时使用游标分页，page和pagesize参数保留兼容
+	if uid == -1 && r.FormValue("page") == "" {
+		users, next, err = service.GetUsersAfter(company, r.FormValue("cursor"), int(pagesize))
+		if err == store.ErrInvalidCursor {
+			resp := NewErrorCodeResponse(40001003)
+			w.Write(resp.ToBytes())
+			return
+		}
+	} else {
+		users, err = service.GetUsers(company, int(page), int(pagesize), uid)
+	}
 
 	var count int64
 
-	if uid == -1 {
-		count, err = service.UserCount()
-	} else {
-		count = int64(len(users))
+	if err == nil {
+		if uid == -1 {
+			count, err = service.UserCount()
+		} else {
+			count = int64(len(users))
+		}
 	}
 
 	var resp *APIResponse
 	if err != nil {
 		resp = NewErrorResponse(500, err.Error())
 	} else {
-		data := map[string]interface{}{"count": count, "users": users}
+		data := map[string]interface{}{"count": count, "users": users, "next_cursor": next}
 		resp = NewResponse(200, data)
 	}
 
@@ -5,7 +5,9 @@ import (
 	"net/http"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/weibocom/ipc/client"
 	con "github.com/weibocom/ipc/content"
+	"github.com/weibocom/ipc/store"
 	"github.com/weibocom/ipc/web/service"
 )
 
@@ -14,6 +16,9 @@ import (
 func configDCIRoutes(router *httprouter.Router) {
 	router.GET("/dci/content", auth(comparePost))
 	router.GET("/dci/text", auth(compareText))
+	router.GET("/dci/signer", auth(lookupSigner))
+	router.POST("/dci/similar", auth(findSimilarContent))
+	router.POST("/dci/originality", auth(checkOriginality))
 }
 
 func comparePost(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
@@ -32,6 +37,11 @@ func comparePost(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 
 // 根据uid和mid查询
 func comparePostByUserPostID(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	sim, algorithm, ok := getSimilarity(w, r)
+	if !ok {
+		return
+	}
+
 	uid1 := getInt(r, "src_uid", -1)
 	mid1 := getInt(r, "src_mid", -1)
 	company1 := r.FormValue("src_company")
@@ -45,24 +55,30 @@ func comparePostByUserPostID(w http.ResponseWriter, r *http.Request, ps httprout
 	}
 
 	post1, err := service.GetContentByMsgID(company1, uid1, mid1)
+	if err == nil {
+		err = service.DecryptContent(post1, r.FormValue("src_wif"))
+	}
 	if err != nil {
-		resp := NewErrorResponse(40003001, err.Error())
-		w.Write(resp.ToBytes())
+		writeContentError(w, err)
 		return
 	}
 
 	post2, err := service.GetContentByMsgID(company2, uid2, mid2)
+	if err == nil {
+		err = service.DecryptContent(post2, r.FormValue("dst_wif"))
+	}
 	if err != nil {
-		resp := NewErrorResponse(40003001, err.Error())
-		w.Write(resp.ToBytes())
+		writeContentError(w, err)
 		return
 	}
 
-	s := con.Similarity(post1.Content, post2.Content) * 100
+	s := compare(sim, post1.Content, post2.Content)
 
 	data := map[string]interface{}{"similarity": fmt.Sprintf("%.2f", s),
-		"src": post1.Content,
-		"dst": post2.Content}
+		"algorithm": algorithm,
+		"src":       post1.Content,
+		"dst":       post2.Content,
+		"report":    con.NewReport(post1.Content, post2.Content, sim)}
 
 	resp := NewResponse(200, data)
 	w.Write(resp.ToBytes())
@@ -70,6 +86,11 @@ func comparePostByUserPostID(w http.ResponseWriter, r *http.Request, ps httprout
 
 // 根据DNA查询
 func comparePostByDNA(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	sim, algorithm, ok := getSimilarity(w, r)
+	if !ok {
+		return
+	}
+
 	dna1 := r.FormValue("src_dna")
 	if dna1 == "" {
 		resp := NewErrorCodeResponse(40003005)
@@ -85,24 +106,30 @@ func comparePostByDNA(w http.ResponseWriter, r *http.Request, ps httprouter.Para
 	}
 
 	post1, err := service.GetContentByDNA(dna1)
+	if err == nil {
+		err = service.DecryptContent(post1, r.FormValue("src_wif"))
+	}
 	if err != nil {
-		resp := NewErrorResponse(40003001, err.Error())
-		w.Write(resp.ToBytes())
+		writeContentError(w, err)
 		return
 	}
 
 	post2, err := service.GetContentByDNA(dna2)
+	if err == nil {
+		err = service.DecryptContent(post2, r.FormValue("dst_wif"))
+	}
 	if err != nil {
-		resp := NewErrorResponse(40003001, err.Error())
-		w.Write(resp.ToBytes())
+		writeContentError(w, err)
 		return
 	}
 
-	s := con.Similarity(post1.Content, post2.Content) * 100
+	s := compare(sim, post1.Content, post2.Content)
 
 	data := map[string]interface{}{"similarity": fmt.Sprintf("%.2f", s),
-		"src": post1.Content,
-		"dst": post2.Content}
+		"algorithm": algorithm,
+		"src":       post1.Content,
+		"dst":       post2.Content,
+		"report":    con.NewReport(post1.Content, post2.Content, sim)}
 
 	resp := NewResponse(200, data)
 	w.Write(resp.ToBytes())
@@ -124,6 +151,11 @@ func compareText(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 
 // 根据uid和mid查询
 func compareTextByUserPostID(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	sim, algorithm, ok := getSimilarity(w, r)
+	if !ok {
+		return
+	}
+
 	uid1 := getInt(r, "src_uid", -1)
 	mid1 := getInt(r, "src_mid", -1)
 	company1 := r.FormValue("src_company")
@@ -138,19 +170,26 @@ func compareTextByUserPostID(w http.ResponseWriter, r *http.Request, ps httprout
 		w.Write(resp.ToBytes())
 		return
 	}
+	if !validateTextLength(w, sim, dstContent) {
+		return
+	}
 
 	post1, err := service.GetContentByMsgID(company1, uid1, mid1)
+	if err == nil {
+		err = service.DecryptContent(post1, r.FormValue("src_wif"))
+	}
 	if err != nil {
-		resp := NewErrorResponse(40003001, err.Error())
-		w.Write(resp.ToBytes())
+		writeContentError(w, err)
 		return
 	}
 
-	s := con.Similarity(post1.Content, dstContent) * 100
+	s := compare(sim, post1.Content, dstContent)
 
 	data := map[string]interface{}{"similarity": fmt.Sprintf("%.2f", s),
-		"src": post1.Content,
-		"dst": dstContent}
+		"algorithm": algorithm,
+		"src":       post1.Content,
+		"dst":       dstContent,
+		"report":    con.NewReport(post1.Content, dstContent, sim)}
 
 	resp := NewResponse(200, data)
 	w.Write(resp.ToBytes())
@@ -158,6 +197,11 @@ func compareTextByUserPostID(w http.ResponseWriter, r *http.Request, ps httprout
 
 // 根据DNA查询
 func compareTextByDNA(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	sim, algorithm, ok := getSimilarity(w, r)
+	if !ok {
+		return
+	}
+
 	dna1 := r.FormValue("src_dna")
 	if dna1 == "" {
 		resp := NewErrorCodeResponse(40003005)
@@ -171,21 +215,234 @@ func compareTextByDNA(w http.ResponseWriter, r *http.Request, ps httprouter.Para
 		w.Write(resp.ToBytes())
 		return
 	}
+	if !validateTextLength(w, sim, dstContent) {
+		return
+	}
 
 	post1, err := service.GetContentByDNA(dna1)
+	if err == nil {
+		err = service.DecryptContent(post1, r.FormValue("src_wif"))
+	}
 	if err != nil {
-		resp := NewErrorResponse(40003001, err.Error())
-		w.Write(resp.ToBytes())
+		writeContentError(w, err)
 		return
 	}
 
-	s := con.Similarity(post1.Content, dstContent) * 100
+	s := compare(sim, post1.Content, dstContent)
 
 	data := map[string]interface{}{"similarity": fmt.Sprintf("%.2f", s),
-		"src": post1.Content,
-		"dst": dstContent}
+		"algorithm": algorithm,
+		"src":       post1.Content,
+		"dst":       dstContent,
+		"report":    con.NewReport(post1.Content, dstContent, sim)}
 
 	resp := NewResponse(200, data)
 	w.Write(resp.ToBytes())
 
 }
+
+// 根据文本查找相似内容
+func findSimilarContent(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	sim, algorithm, ok := getSimilarity(w, r)
+	if !ok {
+		return
+	}
+
+	text := r.FormValue("content")
+	if text == "" {
+		resp := NewErrorCodeResponse(40003006)
+		w.Write(resp.ToBytes())
+		return
+	}
+	if !validateTextLength(w, sim, text) {
+		return
+	}
+
+	threshold := getFloat(r, "threshold", 60)
+	if threshold < 0 || threshold > 100 {
+		resp := NewErrorCodeResponse(40003009)
+		w.Write(resp.ToBytes())
+		return
+	}
+	limit := getInt(r, "limit", 10)
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+
+	posts, err := service.FindSimilarContent(text, sim, threshold, int(limit))
+	if err != nil {
+		resp := NewErrorResponse(500, err.Error())
+		w.Write(resp.ToBytes())
+		return
+	}
+
+	data := map[string]interface{}{"posts": posts, "algorithm": algorithm}
+	resp := NewResponse(200, data)
+	w.Write(resp.ToBytes())
+}
+
+// 原创性鉴定，按上链的区块时间找出相似内容中最早的登记
+func checkOriginality(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	sim, algorithm, ok := getSimilarity(w, r)
+	if !ok {
+		return
+	}
+
+	threshold := getFloat(r, "threshold", 60)
+	if threshold < 0 || threshold > 100 {
+		resp := NewErrorCodeResponse(40003009)
+		w.Write(resp.ToBytes())
+		return
+	}
+	limit := getInt(r, "limit", 10)
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+
+	var (
+		verdict *client.OriginalityVerdict
+		err     error
+	)
+	switch r.FormValue("checkType") {
+	case "user":
+		uid := getInt(r, "uid", -1)
+		mid := getInt(r, "mid", -1)
+		company := r.FormValue("company")
+		if !validateUIDMsgID(w, company, uid, mid) {
+			return
+		}
+		post, perr := service.GetContentByMsgID(company, uid, mid)
+		if perr != nil {
+			resp := NewErrorResponse(40003001, perr.Error())
+			w.Write(resp.ToBytes())
+			return
+		}
+		verdict, err = service.CheckPostOriginality(post.DNA, sim, threshold, int(limit))
+	case "dna":
+		dna := r.FormValue("dna")
+		if dna == "" {
+			resp := NewErrorCodeResponse(40003005)
+			w.Write(resp.ToBytes())
+			return
+		}
+		verdict, err = service.CheckPostOriginality(dna, sim, threshold, int(limit))
+	case "text":
+		text := r.FormValue("content")
+		if text == "" {
+			resp := NewErrorCodeResponse(40003006)
+			w.Write(resp.ToBytes())
+			return
+		}
+		if !validateTextLength(w, sim, text) {
+			return
+		}
+		verdict, err = service.CheckOriginality(text, sim, threshold, int(limit))
+	default:
+		resp := NewErrorCodeResponse(40003000)
+		w.Write(resp.ToBytes())
+		return
+	}
+
+	switch err {
+	case nil:
+	case store.ErrNonExist:
+		resp := NewErrorCodeResponse(40003001)
+		w.Write(resp.ToBytes())
+		return
+	case client.ErrContentEncrypted:
+		resp := NewErrorCodeResponse(40003011)
+		w.Write(resp.ToBytes())
+		return
+	case client.ErrPostRetracted:
+		resp := NewErrorCodeResponse(40003012)
+		w.Write(resp.ToBytes())
+		return
+	default:
+		resp := NewErrorResponse(500, err.Error())
+		w.Write(resp.ToBytes())
+		return
+	}
+
+	data := map[string]interface{}{"verdict": verdict, "algorithm": algorithm}
+	resp := NewResponse(200, data)
+	w.Write(resp.ToBytes())
+}
+
+// writeContentError 写入查询或解密内容失败的错误
+func writeContentError(w http.ResponseWriter, err error) {
+	var resp *APIResponse
+	switch err {
+	case client.ErrContentEncrypted:
+		resp = NewErrorCodeResponse(40003011)
+	case client.ErrPostRetracted:
+		resp = NewErrorCodeResponse(40003012)
+	case client.ErrUnauthorized:
+		resp = NewErrorCodeResponse(40003013)
+	default:
+		resp = NewErrorResponse(40003001, err.Error())
+	}
+	w.Write(resp.ToBytes())
+}
+
+// getSimilarity 返回algorithm参数指定的相似度算法，默认为con.DefaultSimilarity
+func getSimilarity(w http.ResponseWriter, r *http.Request) (con.Similarity, string, bool) {
+	algorithm := r.FormValue("algorithm")
+	if algorithm == "" {
+		algorithm = con.DefaultSimilarity
+	}
+	sim, ok := con.SimilarityByName(algorithm)
+	if !ok {
+		resp := NewErrorCodeResponse(40003010)
+		w.Write(resp.ToBytes())
+		return nil, "", false
+	}
+	return sim, algorithm, true
+}
+
+// LCS和Levenshtein的计算量与文本长度的平方成正比，超过长度上限的文本直接拒绝
+func validateTextLength(w http.ResponseWriter, sim con.Similarity, text string) bool {
+	if con.ExceedsRuneLimit(sim, text) {
+		resp := NewErrorCodeResponse(40003014)
+		w.Write(resp.ToBytes())
+		return false
+	}
+	return true
+}
+
+// 根据dna和digest查询签名的用户
+func lookupSigner(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	dna := r.FormValue("dna")
+	if dna == "" {
+		resp := NewErrorCodeResponse(40003005)
+		w.Write(resp.ToBytes())
+		return
+	}
+
+	digest := r.FormValue("digest")
+	if digest == "" {
+		resp := NewErrorCodeResponse(40003007)
+		w.Write(resp.ToBytes())
+		return
+	}
+
+	user, err := service.GetSigner(dna, digest)
+	if err != nil {
+		if err == service.ErrInvalidDigest {
+			resp := NewErrorCodeResponse(40003007)
+			w.Write(resp.ToBytes())
+			return
+		}
+		resp := NewErrorResponse(40003008, err.Error())
+		w.Write(resp.ToBytes())
+		return
+	}
+
+	data := map[string]interface{}{"user": user}
+	resp := NewResponse(200, data)
+	w.Write(resp.ToBytes())
+}
+
+// compare 比较归一化(见content.Normalize)后的文本，返回百分比的相似度。报告的偏移仍是原文的。
+func compare(sim con.Similarity, a, b string) float64 {
+	return sim.Compare(con.Normalize(a), con.Normalize(b)) * 100
+}
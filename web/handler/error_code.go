@@ -10,6 +10,7 @@ var (
 		40001000: "用户ID填写错误",
 		40001001: "公司名称填写错误",
 		40001002: "用户已经存在",
+		40001003: "分页游标错误",
 		40001010: "文件格式错误",
 		40001020: "无权限查看",
 		40001021: "此用户未上链",
@@ -22,11 +23,26 @@ var (
 		40002004: "内容不存在",
 		40002005: "dna参数设置错误",
 		40002006: "内容不能为空",
+		40002007: "分页游标错误",
+		40002008: "内容已撤回",
+		40002009: "搜索条件不能为空",
+		40002010: "搜索条件格式错误",
+		40002011: "内容已加密",
+		40002012: "无权解密内容",
+		40002013: "相同内容已登记",
 
 		// 鉴权错误码
 		40003000: "不支持的鉴权方式",
 		40003001: "查询content失败",
 		40003005: "dna参数设置错误",
 		40003006: "文本内容不能为空",
+		40003007: "digest参数设置错误",
+		40003008: "未找到签名用户",
+		40003009: "threshold参数设置错误",
+		40003010: "不支持的相似度算法",
+		40003011: "内容已加密",
+		40003012: "内容已撤回",
+		40003013: "无权解密内容",
+		40003014: "文本超过算法的长度上限",
 	}
 )
@@ -6,6 +6,11 @@ import (
 	"time"
 
 	"github.com/julienschmidt/httprouter"
+	ipcclient "github.com/weibocom/ipc/client"
+	"github.com/weibocom/ipc/content"
+	"github.com/weibocom/ipc/model"
+	"github.com/weibocom/ipc/store"
+	webmodel "github.com/weibocom/ipc/web/model"
 	"github.com/weibocom/ipc/web/service"
 )
 
@@ -17,7 +22,11 @@ func configPostRoutes(router *httprouter.Router) {
 	router.GET("/posts", auth(queryPost))
 	router.GET("/account_posts", auth(queryAccountPost))
 	router.POST("/posts", auth(addPost))
+	router.POST("/posts/update", auth(updatePost))
+	router.POST("/posts/retract", auth(retractPost))
+	router.GET("/post_versions", auth(queryPostVersions))
 	router.GET("/similar/post", auth(LookSimilarPosts))
+	router.GET("/search", auth(searchPosts))
 }
 
 func postCount(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
@@ -154,15 +163,32 @@ func LookSimilarPostsByUserPostID(w http.ResponseWriter, r *http.Request, ps htt
 		w.Write(resp.ToBytes())
 		return
 	}
+	if !decryptContent(w, r, post) {
+		return
+	}
 
-	posts, err := service.GetSimilarPostsByDNA(post.DNA, post.Content, post.Keywords, int(page), int(pagesize))
+	var (
+		posts []*webmodel.Post
+		next  string
+	)
+	// 没有page参数时使用游标分页，page和pagesize参数保留兼容
+	if r.FormValue("page") == "" {
+		posts, next, err = service.GetSimilarPostsByDNAAfter(post.DNA, post.Content, post.Keywords, r.FormValue("cursor"), int(pagesize))
+	} else {
+		posts, err = service.GetSimilarPostsByDNA(post.DNA, post.Content, post.Keywords, int(page), int(pagesize))
+	}
+	if err == store.ErrInvalidCursor {
+		resp := NewErrorCodeResponse(40002007)
+		w.Write(resp.ToBytes())
+		return
+	}
 	if err != nil {
 		resp := NewErrorResponse(500, err.Error())
 		w.Write(resp.ToBytes())
 		return
 	}
 
-	data := map[string]interface{}{"posts": posts}
+	data := map[string]interface{}{"posts": posts, "next_cursor": next}
 	resp := NewResponse(200, data)
 	w.Write(resp.ToBytes())
 }
@@ -184,15 +210,32 @@ func LookSimilarPostsByDNA(w http.ResponseWriter, r *http.Request, ps httprouter
 		w.Write(resp.ToBytes())
 		return
 	}
+	if !decryptContent(w, r, post) {
+		return
+	}
 
-	posts, err := service.GetSimilarPostsByDNA(post.DNA, post.Content, post.Keywords, int(page), int(pagesize))
+	var (
+		posts []*webmodel.Post
+		next  string
+	)
+	// 没有page参数时使用游标分页，page和pagesize参数保留兼容
+	if r.FormValue("page") == "" {
+		posts, next, err = service.GetSimilarPostsByDNAAfter(post.DNA, post.Content, post.Keywords, r.FormValue("cursor"), int(pagesize))
+	} else {
+		posts, err = service.GetSimilarPostsByDNA(post.DNA, post.Content, post.Keywords, int(page), int(pagesize))
+	}
+	if err == store.ErrInvalidCursor {
+		resp := NewErrorCodeResponse(40002007)
+		w.Write(resp.ToBytes())
+		return
+	}
 	if err != nil {
 		resp := NewErrorResponse(500, err.Error())
 		w.Write(resp.ToBytes())
 		return
 	}
 
-	data := map[string]interface{}{"posts": posts}
+	data := map[string]interface{}{"posts": posts, "next_cursor": next}
 	resp := NewResponse(200, data)
 	w.Write(resp.ToBytes())
 }
@@ -216,14 +259,30 @@ func queryAccountPost(w http.ResponseWriter, r *http.Request, ps httprouter.Para
 	page := getInt(r, "page", 1)
 	pagesize := getInt(r, "pagesize", 20)
 
-	posts, postCount, err := service.GetUserPosts(company, uid, int(page), int(pagesize))
+	var (
+		posts     []*model.Post
+		postCount int
+		next      string
+		err       error
+	)
+	// 没有page参数时使用游标分页，page和pagesize参数保留兼容
+	if r.FormValue("page") == "" {
+		posts, postCount, next, err = service.GetUserPostsAfter(company, uid, r.FormValue("cursor"), int(pagesize))
+	} else {
+		posts, postCount, err = service.GetUserPosts(company, uid, int(page), int(pagesize))
+	}
+	if err == store.ErrInvalidCursor {
+		resp := NewErrorCodeResponse(40002007)
+		w.Write(resp.ToBytes())
+		return
+	}
 	if err != nil {
 		resp := NewErrorResponse(500, err.Error())
 		w.Write(resp.ToBytes())
 		return
 	}
 
-	data := map[string]interface{}{"post_count": postCount, "posts": posts}
+	data := map[string]interface{}{"post_count": postCount, "posts": posts, "next_cursor": next}
 	resp := NewResponse(200, data)
 	w.Write(resp.ToBytes())
 }
@@ -251,12 +310,45 @@ func addPost(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	}
 
 	dna, err := service.AddPost(company, uid, mid, title, content, time.Now().UnixNano()/1e6, contentType)
+	if !checkPostError(w, err) {
+		return
+	}
+
+	post, err := service.GetContentByDNA(dna.String())
 	if err != nil {
 		resp := NewErrorResponse(500, err.Error())
 		w.Write(resp.ToBytes())
 		return
 	}
 
+	data := map[string]interface{}{"post": post}
+	resp := NewResponse(200, data)
+	w.Write(resp.ToBytes())
+}
+
+// 更新内容，保留之前版本的dna和digest
+func updatePost(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	uid := getInt(r, "uid", -1)
+	mid := getInt(r, "mid", -1)
+	company := r.FormValue("company")
+	contentType := r.FormValue("contentType")
+	content := r.FormValue("content")
+
+	if !validateUIDMsgID(w, company, uid, mid) {
+		return
+	}
+
+	if content == "" {
+		resp := NewErrorCodeResponse(40002006)
+		w.Write(resp.ToBytes())
+		return
+	}
+
+	dna, err := service.UpdatePost(company, uid, mid, content, contentType)
+	if !checkPostError(w, err) {
+		return
+	}
+
 	post, err := service.GetContentByDNA(dna.String())
 	if err != nil {
 		resp := NewErrorResponse(500, err.Error())
@@ -268,3 +360,142 @@ func addPost(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	resp := NewResponse(200, data)
 	w.Write(resp.ToBytes())
 }
+
+// 撤回内容，保留不含内容的记录作为存证
+func retractPost(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	uid := getInt(r, "uid", -1)
+	mid := getInt(r, "mid", -1)
+	company := r.FormValue("company")
+
+	if !validateUIDMsgID(w, company, uid, mid) {
+		return
+	}
+
+	err := service.RetractPost(company, uid, mid)
+	if !checkPostError(w, err) {
+		return
+	}
+
+	post, err := service.GetContentByMsgID(company, uid, mid)
+	if err != nil {
+		resp := NewErrorResponse(500, err.Error())
+		w.Write(resp.ToBytes())
+		return
+	}
+
+	data := map[string]interface{}{"post": post}
+	resp := NewResponse(200, data)
+	w.Write(resp.ToBytes())
+}
+
+// 根据uid和mid查询之前的版本
+func queryPostVersions(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	uid := getInt(r, "uid", -1)
+	mid := getInt(r, "mid", -1)
+	company := r.FormValue("company")
+
+	if !validateUIDMsgID(w, company, uid, mid) {
+		return
+	}
+
+	post, err := service.GetContentByMsgID(company, uid, mid)
+	if !checkPostError(w, err) {
+		return
+	}
+	versions, err := service.GetPostVersions(company, uid, mid)
+	if err != nil {
+		resp := NewErrorResponse(500, err.Error())
+		w.Write(resp.ToBytes())
+		return
+	}
+
+	data := map[string]interface{}{"post": post, "versions": versions}
+	resp := NewResponse(200, data)
+	w.Write(resp.ToBytes())
+}
+
+// 全文检索内容，可按公司、用户和时间范围过滤
+func searchPosts(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	q := r.FormValue("q")
+	company := r.FormValue("company")
+	uid := getInt(r, "uid", -1)
+
+	if q == "" {
+		resp := NewErrorCodeResponse(40002009)
+		w.Write(resp.ToBytes())
+		return
+	}
+
+	// 按用户过滤时需要指定公司
+	if uid != -1 && company == "" {
+		resp := NewErrorCodeResponse(40002001)
+		w.Write(resp.ToBytes())
+		return
+	}
+
+	// 时间范围为毫秒时间戳
+	var since, until time.Time
+	if ms := getInt(r, "since", 0); ms > 0 {
+		since = time.Unix(0, ms*int64(time.Millisecond))
+	}
+	if ms := getInt(r, "until", 0); ms > 0 {
+		until = time.Unix(0, ms*int64(time.Millisecond))
+	}
+
+	page := getInt(r, "page", 1)
+	pagesize := getInt(r, "pagesize", 20)
+
+	posts, err := service.SearchPosts(q, company, uid, since, until, int(page), int(pagesize))
+	if err == content.ErrEmptyQuery || err == content.ErrUnclosedQuote {
+		resp := NewErrorCodeResponse(40002010)
+		w.Write(resp.ToBytes())
+		return
+	}
+	if err != nil {
+		resp := NewErrorResponse(500, err.Error())
+		w.Write(resp.ToBytes())
+		return
+	}
+
+	data := map[string]interface{}{"posts": posts}
+	resp := NewResponse(200, data)
+	w.Write(resp.ToBytes())
+}
+
+// checkPostError writes the error response of err, and returns false if err is not nil.
+func checkPostError(w http.ResponseWriter, err error) bool {
+	var resp *APIResponse
+	switch err {
+	case nil:
+		return true
+	case store.ErrNonExist:
+		resp = NewErrorCodeResponse(40002004)
+	case ipcclient.ErrPostRetracted:
+		resp = NewErrorCodeResponse(40002008)
+	case ipcclient.ErrPostExist:
+		resp = NewErrorCodeResponse(40002013)
+	default:
+		resp = NewErrorResponse(500, err.Error())
+	}
+	w.Write(resp.ToBytes())
+	return false
+}
+
+// decryptContent 用wif参数解密加密的内容，内容无法比较时写入错误并返回false
+func decryptContent(w http.ResponseWriter, r *http.Request, post *model.Post) bool {
+	var resp *APIResponse
+	switch err := service.DecryptContent(post, r.FormValue("wif")); err {
+	case nil:
+		return true
+	case ipcclient.ErrContentEncrypted:
+		resp = NewErrorCodeResponse(40002011)
+	case ipcclient.ErrPostRetracted:
+		resp = NewErrorCodeResponse(40002008)
+	case ipcclient.ErrUnauthorized:
+		resp = NewErrorCodeResponse(40002012)
+	default:
+		resp = NewErrorResponse(500, err.Error())
+	}
+	w.Write(resp.ToBytes())
+	return false
+}
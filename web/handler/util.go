@@ -33,3 +33,15 @@ func getInt(r *http.Request, name string, defaultValue int64) int64 {
 	}
 	return n
 }
+
+func getFloat(r *http.Request, name string, defaultValue float64) float64 {
+	p := r.FormValue(name)
+	if p == "" {
+		return defaultValue
+	}
+	f, err := strconv.ParseFloat(p, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return f
+}
@@ -28,6 +28,13 @@ type Server struct {
 
 	DB     *gorm.DB
 	Client ipcclient.Client
+
+	// ChainOptions are passed to the blockchain client.
+	ChainOptions []client.Option
+	// StoreOptions are passed to the db store.
+	StoreOptions []store.Option
+	// ClientOptions are passed to the ipc client.
+	ClientOptions []ipcclient.Option
 }
 
 func New(httpAddress, dbAddress, bcAddress string, company string) *Server {
@@ -50,7 +57,8 @@ func (s *Server) Start() error {
 	go http.Serve(s.ln, handler.ConfigRouter())
 
 	// 2. db
-	s.DB, err = gorm.Open("mysql", s.dbAddress)
+	dialect, dsn := store.SplitDSN(s.dbAddress)
+	s.DB, err = gorm.Open(dialect, dsn)
 	if err != nil {
 		return err
 	}
@@ -63,8 +71,12 @@ func (s *Server) Start() error {
 		log.Fatalf("failed to new transport: %v", err)
 	}
 
-	chain := client.NewSteemClient(tran, config.GetCreator(), keys.GetPrivateKeys()[0], s.company)
-	s.Client, err = ipcclient.NewClient(chain, store.NewMySQLStore(s.dbAddress))
+	chain := client.NewSteemClient(tran, config.GetCreator(), keys.GetPrivateKeys()[0], s.company, s.ChainOptions...)
+	dbStore, err := store.NewSQLStore(dialect, dsn, s.StoreOptions...)
+	if err != nil {
+		log.Fatalf("failed to new db store: %v", err)
+	}
+	s.Client, err = ipcclient.NewClient(chain, dbStore, s.ClientOptions...)
 	if err != nil {
 		log.Fatalf("failed to new blockchain client: %v", err)
 	}
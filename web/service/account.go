@@ -1,6 +1,7 @@
 package service
 
 import (
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"strconv"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/weibocom/ipc/keys"
 	ipcmodel "github.com/weibocom/ipc/model"
+	"github.com/weibocom/ipc/signature"
 	"github.com/weibocom/ipc/web/model"
 )
 
@@ -15,6 +17,7 @@ var (
 	ErrCompanyIsNotInConsortium = errors.New("not a memeber of consortium")
 	ErrUserNotExist             = errors.New("user does not exist")
 	ErrUserAlreadyExist         = errors.New("user has already existed")
+	ErrInvalidDigest            = errors.New("invalid digest")
 )
 
 func generateUniqueAccount(company string, user int64) string {
@@ -84,6 +87,33 @@ func GetUser(company string, uid int64) (*model.User, error) {
 	return user, nil
 }
 
+// GetSigner returns the user who signed digest with the given dna.
+// Only the public information of the user is returned.
+func GetSigner(dna string, digest string) (*model.User, error) {
+	d, err := hex.DecodeString(digest)
+	if err != nil {
+		return nil, ErrInvalidDigest
+	}
+
+	acc, err := ipcClient.LookupSigner(ipcmodel.DNA(dna), d)
+	if err == signature.ErrInvalidDigest {
+		return nil, ErrInvalidDigest
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	company, _ := splitCompanyAccount(acc.Name)
+	user := &model.User{
+		ID:        getUserID(acc.Name),
+		Company:   company,
+		CreatedAt: acc.CreatedAt,
+		PublicKey: acc.PublicKey,
+	}
+	user.PostCount, _ = ipcClient.GetAccountPostCount(acc.Name)
+	return user, nil
+}
+
 func GetUsers(company string, page int, pagesize int, uid int64) ([]*model.User, error) {
 	offset := (page - 1) * pagesize
 
@@ -104,6 +134,19 @@ func GetUsers(company string, page int, pagesize int, uid int64) ([]*model.User,
 		accounts = append(accounts, acc)
 	}
 
+	return toUsers(company, accounts), nil
+}
+
+// GetUsersAfter returns the page of users after the cursor, and the cursor of the next page.
+func GetUsersAfter(company string, cursor string, pagesize int) ([]*model.User, string, error) {
+	accounts, next, err := ipcClient.GetAccountsAfter(company, cursor, pagesize)
+	if err != nil {
+		return nil, "", err
+	}
+	return toUsers(company, accounts), next, nil
+}
+
+func toUsers(company string, accounts []*ipcmodel.Account) []*model.User {
 	var users = make([]*model.User, 0, len(accounts))
 	for _, acc := range accounts {
 		user := &model.User{
@@ -124,7 +167,7 @@ func GetUsers(company string, page int, pagesize int, uid int64) ([]*model.User,
 		users = append(users, user)
 	}
 
-	return users, nil
+	return users
 }
 
 func UserCount() (int64, error) {
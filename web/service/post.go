@@ -2,6 +2,7 @@ package service
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/weibocom/ipc/client"
 	"github.com/weibocom/ipc/content"
@@ -24,6 +25,30 @@ func AddPost(company string, uid int64, mid int64, title string, content string,
 	return ipcClient.Post(author, mid, []byte(content), ct)
 }
 
+// UpdatePost replaces the content of the post with a new version, and returns the dna of it.
+func UpdatePost(company string, uid int64, mid int64, content string, contentType string) (model.DNA, error) {
+	author := generateUniqueAccount(company, uid)
+	ct := client.ParseContentType(contentType)
+	return ipcClient.UpdatePost(author, mid, []byte(content), ct)
+}
+
+// RetractPost retracts the post, which is kept as a tombstone.
+func RetractPost(company string, uid int64, mid int64) error {
+	author := generateUniqueAccount(company, uid)
+	return ipcClient.RetractPost(author, mid)
+}
+
+// GetPostVersions returns the previous versions of the post.
+func GetPostVersions(company string, uid int64, mid int64) ([]*model.PostVersion, error) {
+	author := generateUniqueAccount(company, uid)
+	versions, err := ipcClient.LookupPostVersions(author, mid)
+
+	for _, v := range versions {
+		_, v.Author = splitCompanyAccount(v.Author)
+	}
+	return versions, err
+}
+
 func GetContentByMsgID(company string, uid int64, mid int64) (*model.Post, error) {
 	author := generateUniqueAccount(company, uid)
 	post, err := ipcClient.LookupPostByMsgID(author, mid)
@@ -45,6 +70,28 @@ func GetContentByDNA(dna string) (*model.Post, error) {
 	return post, err
 }
 
+// DecryptContent sets the content of the encrypted post, which is decrypted by wif, the key of
+// the key owner of the post. It returns client.ErrContentEncrypted if wif is empty, and
+// client.ErrPostRetracted if the post is retracted, as their contents can not be compared.
+func DecryptContent(post *model.Post, wif string) error {
+	if post.Retracted {
+		return client.ErrPostRetracted
+	}
+	if post.WrappedKey == "" {
+		return nil
+	}
+	if wif == "" {
+		return client.ErrContentEncrypted
+	}
+
+	data, err := ipcClient.LookupContentWithKey(model.DNA(post.DNA), wif)
+	if err != nil {
+		return err
+	}
+	post.Content = string(data)
+	return nil
+}
+
 func GetLatestPost() (*model.Post, error) {
 	post, err := ipcClient.GetLatestPost()
 	if post != nil {
@@ -72,21 +119,101 @@ func GetUserPosts(company string, uid int64, page int, pagesize int) (posts []*m
 	return posts, postCount, err
 }
 
+// GetUserPostsAfter returns the page of posts after the cursor, and the cursor of the next page.
+func GetUserPostsAfter(company string, uid int64, cursor string, pagesize int) (posts []*model.Post, postCount int, next string, err error) {
+	author := generateUniqueAccount(company, uid)
+
+	postCount, err = ipcClient.GetAccountPostCount(author)
+	if err != nil {
+		return
+	}
+
+	posts, next, err = ipcClient.LookupPostByAuthorAfter(author, cursor, pagesize)
+
+	for _, p := range posts {
+		_, p.Author = splitCompanyAccount(p.Author)
+	}
+	return posts, postCount, next, err
+}
+
 func GetSimilarPostsByDNA(dna string, c string, keywords string, page int, pagesize int) ([]*webmodel.Post, error) {
 	offset := (page - 1) * pagesize
 	posts, err := ipcClient.LookupSimilarPosts(dna, keywords, offset, pagesize)
 
+	return toSimilarPosts(c, posts), err
+}
+
+// GetSimilarPostsByDNAAfter returns the page of similar posts after the cursor, and the cursor of the next page.
+func GetSimilarPostsByDNAAfter(dna string, c string, keywords string, cursor string, pagesize int) ([]*webmodel.Post, string, error) {
+	posts, next, err := ipcClient.LookupSimilarPostsAfter(dna, keywords, cursor, pagesize)
+
+	return toSimilarPosts(c, posts), next, err
+}
+
+// FindSimilarContent returns at most limit posts similar to content c, whose similarity
+// by sim in percentage is at least threshold.
+func FindSimilarContent(c string, sim content.Similarity, threshold float64, limit int) ([]*webmodel.Post, error) {
+	matches, err := ipcClient.FindSimilarContent(c, sim, threshold/100, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	webposts := make([]*webmodel.Post, 0, len(matches))
+	for _, m := range matches {
+		_, m.Author = splitCompanyAccount(m.Author)
+		webposts = append(webposts, &webmodel.Post{
+			Post:       m.Post,
+			Similarity: fmt.Sprintf("%.2f", m.Similarity*100),
+		})
+	}
+	return webposts, nil
+}
+
+// SearchPosts returns the page of posts matching the full-text query text, the newest first.
+// The posts are of the company and the user of uid if they are set, and created in [since, until)
+// if the times are not zero.
+func SearchPosts(text string, company string, uid int64, since time.Time, until time.Time, page int, pagesize int) ([]*model.Post, error) {
+	q := &store.SearchQuery{Text: text, Company: company, Since: since, Until: until}
+	if uid != -1 {
+		q.Author = generateUniqueAccount(company, uid)
+	}
+
+	posts, err := ipcClient.SearchPosts(q, (page-1)*pagesize, pagesize)
+	for _, p := range posts {
+		_, p.Author = splitCompanyAccount(p.Author)
+	}
+	return posts, err
+}
+
+// CheckOriginality returns the verdict of which post similar to content c is registered first.
+// threshold is the min similarity in percentage. The authors are kept as the account names,
+// which include the companies.
+func CheckOriginality(c string, sim content.Similarity, threshold float64, limit int) (*client.OriginalityVerdict, error) {
+	return ipcClient.CheckOriginality(c, sim, threshold/100, limit)
+}
+
+// CheckPostOriginality returns the verdict of which post similar to the post of dna is registered first.
+func CheckPostOriginality(dna string, sim content.Similarity, threshold float64, limit int) (*client.OriginalityVerdict, error) {
+	return ipcClient.CheckPostOriginality(model.DNA(dna), sim, threshold/100, limit)
+}
+
+// toSimilarPosts computes the similarity of posts to content c. The similarity of encrypted
+// or retracted posts is left empty, as their contents are unknown.
+func toSimilarPosts(c string, posts []*model.Post) []*webmodel.Post {
 	var webposts []*webmodel.Post
+	c = content.Normalize(c)
 
 	for _, p := range posts {
 		_, p.Author = splitCompanyAccount(p.Author)
 		pp := &webmodel.Post{}
 		pp.Post = p
 
-		pp.Similarity = fmt.Sprintf("%.2f", content.Similarity(c, p.Content)*100)
+		if p.WrappedKey == "" && !p.Retracted {
+			pp.Similarity = fmt.Sprintf("%.2f", content.Compare(c, content.Normalize(p.Content))*100)
+		}
 		webposts = append(webposts, pp)
 	}
-	return webposts, err
+	return webposts
 }
 
 func PostCount() (int64, error) {